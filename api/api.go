@@ -3,15 +3,36 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/jniewt/gotodo/internal/core"
 )
 
 type ListResponse struct {
-	Name   string          `json:"name"`
-	Colour RGB             `json:"colour"`
-	Items  []*TaskResponse `json:"items"`
+	Name string `json:"name"`
+	// Owner and Members are only set for shared lists.
+	Owner   string       `json:"owner,omitempty"`
+	Members []ListMember `json:"members,omitempty"`
+	Colour  RGB          `json:"colour"`
+	// Statuses is the workflow of the list, omitted for the default one, "todo" and "done".
+	Statuses []string `json:"statuses,omitempty"`
+	// ArchiveAfter is the number of days after which done tasks are archived, omitted if they aren't.
+	ArchiveAfter int             `json:"archive_after,omitempty"`
+	Items        []*TaskResponse `json:"items"`
+}
+
+// ListMember is a user a list is shared with.
+type ListMember struct {
+	User string `json:"user"`
+	// Role is viewer, editor or owner.
+	Role string `json:"role"`
+}
+
+// ListShare is used to share a list with a user.
+type ListShare struct {
+	// Role is viewer, editor or owner.
+	Role string `json:"role"`
 }
 
 func FromList(l core.List) ListResponse {
@@ -20,15 +41,24 @@ func FromList(l core.List) ListResponse {
 		t := FromTask(*task)
 		tasks[i] = &t
 	}
-	return ListResponse{
+	resp := ListResponse{
 		Name: l.Name,
 		Colour: RGB{
 			R: l.Colour.R,
 			G: l.Colour.G,
 			B: l.Colour.B,
 		},
-		Items: tasks,
+		Items:        tasks,
+		Statuses:     l.Statuses,
+		ArchiveAfter: l.ArchiveAfter,
 	}
+	if len(l.Members) > 0 {
+		resp.Owner = l.Owner
+		for _, m := range l.Members {
+			resp.Members = append(resp.Members, ListMember{User: m.User, Role: string(m.Role)})
+		}
+	}
+	return resp
 }
 
 type TaskResponse struct {
@@ -42,27 +72,174 @@ type TaskResponse struct {
 	Due      time.Time `json:"due,omitempty"`
 	Created  time.Time `json:"created"`
 	DoneOn   time.Time `json:"done_on,omitempty"`
+	Assignee string    `json:"assignee,omitempty"`
+	// Comments is the number of comments on the task.
+	Comments int `json:"comments"`
+	// Reminders are left out if the task has none.
+	Reminders []TaskReminder `json:"reminders,omitempty"`
+	// HiddenUntil is set while the task is snoozed.
+	HiddenUntil time.Time `json:"hidden_until,omitempty"`
+	// BlockedBy are the IDs of the tasks that must be done first, Blocked is set while any of them isn't.
+	BlockedBy []int `json:"blocked_by,omitempty"`
+	Blocked   bool  `json:"blocked"`
+	// Status is the step of the workflow of the list, see ListResponse.
+	Status string `json:"status"`
+	// Estimate is the expected effort, e.g. "2h0m0s", Tracked the time spent on the task so far.
+	Estimate string `json:"estimate,omitempty"`
+	Tracked  string `json:"tracked,omitempty"`
+	// Tags are left out if the task has none.
+	Tags []string `json:"tags,omitempty"`
+	// Pomodoros is the number of completed focus sessions on the task.
+	Pomodoros int `json:"pomodoros"`
+	// Archived is set for tasks in the archive.
+	Archived time.Time `json:"archived,omitempty"`
+}
+
+// TaskReminder is a reminder of a task.
+type TaskReminder struct {
+	At time.Time `json:"at"`
+	// Before is set for reminders relative to the due date, e.g. "30m0s".
+	Before string `json:"before,omitempty"`
+	Fired  bool   `json:"fired"`
+}
+
+// CommentResponse is a comment on a task.
+type CommentResponse struct {
+	ID      int        `json:"id"`
+	Author  string     `json:"author,omitempty"`
+	Text    string     `json:"text"`
+	Created time.Time  `json:"created"`
+	Edited  *time.Time `json:"edited,omitempty"`
+}
+
+// TaskSnooze hides a task until a time like "1h", "3d", "tomorrow", "next week" or "2026-10-20T08:00:00Z". An empty
+// time shows the task again.
+type TaskSnooze struct {
+	Until string `json:"until"`
+}
+
+// ListStatuses changes the workflow of a list.
+type ListStatuses struct {
+	Statuses []string `json:"statuses"`
+}
+
+// ListArchive changes after how many days done tasks of a list are archived, 0 turns archiving off.
+type ListArchive struct {
+	ArchiveAfter int `json:"archive_after"`
+}
+
+// BoardResponse is a list with its tasks grouped by status, in the order of the workflow.
+type BoardResponse struct {
+	List    string        `json:"list"`
+	Columns []BoardColumn `json:"columns"`
+}
+
+// BoardColumn holds the tasks with one status.
+type BoardColumn struct {
+	Status string         `json:"status"`
+	Tasks  []TaskResponse `json:"tasks"`
+}
+
+// NewBoard groups the tasks of a list by status.
+func NewBoard(l core.List) BoardResponse {
+	board := BoardResponse{List: l.Name}
+	for _, status := range l.Workflow() {
+		column := BoardColumn{Status: status, Tasks: []TaskResponse{}}
+		for _, t := range l.Items {
+			if l.StatusFor(*t) == status {
+				column.Tasks = append(column.Tasks, FromTask(*t))
+			}
+		}
+		board.Columns = append(board.Columns, column)
+	}
+	return board
+}
+
+// CommentAdd is used to add or edit a comment.
+type CommentAdd struct {
+	Text string `json:"text"`
+}
+
+func FromComment(c core.Comment) CommentResponse {
+	resp := CommentResponse{ID: c.ID, Author: c.Author, Text: c.Text, Created: c.Created}
+	if !c.Edited.IsZero() {
+		resp.Edited = &c.Edited
+	}
+	return resp
+}
+
+// TaskEvent is a change of a task field in the task history.
+type TaskEvent struct {
+	Time  time.Time `json:"time"`
+	User  string    `json:"user,omitempty"`
+	Field string    `json:"field"`
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+}
+
+// FromHistory converts the history of a task.
+func FromHistory(t core.Task) []TaskEvent {
+	events := make([]TaskEvent, 0, len(t.History))
+	for _, e := range t.History {
+		events = append(events, TaskEvent{Time: e.Time, User: e.User, Field: e.Field, From: e.From, To: e.To})
+	}
+	return events
 }
 
 func FromTask(t core.Task) TaskResponse {
 	resp := TaskResponse{
-		ID:       t.ID,
-		Title:    t.Title,
-		List:     t.List,
-		Done:     t.Done,
-		Priority: t.Priority,
-		DueType:  string(t.DueType),
-		Due:      t.Due,
-		AllDay:   t.AllDay,
-		Created:  t.Created,
-		DoneOn:   t.DoneOn,
+		ID:        t.ID,
+		Title:     t.Title,
+		List:      t.List,
+		Done:      t.Done,
+		Priority:  t.Priority,
+		DueType:   string(t.DueType),
+		Due:       t.Due,
+		AllDay:    t.AllDay,
+		Created:   t.Created,
+		DoneOn:    t.DoneOn,
+		Assignee:  t.Assignee,
+		Comments:  len(t.Comments),
+		Pomodoros: len(t.Pomodoros),
+		Blocked:   t.Blocked,
+		Status:    t.CurrentStatus(),
+		Archived:  t.Archived,
+	}
+	if len(t.BlockedBy) > 0 {
+		resp.BlockedBy = slices.Clone(t.BlockedBy)
+	}
+	if t.IsHidden() {
+		resp.HiddenUntil = t.HiddenUntil
+	}
+	resp.Estimate = NewEstimate(t)
+	if len(t.Tags) > 0 {
+		resp.Tags = slices.Clone(t.Tags)
+	}
+	if len(t.TimeEntries) > 0 {
+		resp.Tracked = t.Tracked(time.Now()).Round(time.Second).String()
+	}
+	for _, r := range t.Reminders {
+		reminder := TaskReminder{At: r.At, Fired: r.Fired}
+		if r.Relative {
+			reminder.Before = r.Before.String()
+		}
+		resp.Reminders = append(resp.Reminders, reminder)
 	}
 	return resp
 }
 
+// NewReminders formats the reminders of a task for TaskAdd and TaskChange.
+func NewReminders(t core.Task) []string {
+	reminders := make([]string, 0, len(t.Reminders))
+	for _, r := range t.Reminders {
+		reminders = append(reminders, r.String())
+	}
+	return reminders
+}
+
 // MarshalJSON overwrites JSON marshalling to not send zero-value time fields
 func (t TaskResponse) MarshalJSON() ([]byte, error) {
-	var due, doneOn string
+	var due, doneOn, hiddenUntil, archived string
 
 	if !t.Due.IsZero() {
 		due = t.Due.Format(time.RFC3339)
@@ -71,15 +248,27 @@ func (t TaskResponse) MarshalJSON() ([]byte, error) {
 	if !t.DoneOn.IsZero() {
 		doneOn = t.DoneOn.Format(time.RFC3339)
 	}
+
+	if !t.HiddenUntil.IsZero() {
+		hiddenUntil = t.HiddenUntil.Format(time.RFC3339)
+	}
+
+	if !t.Archived.IsZero() {
+		archived = t.Archived.Format(time.RFC3339)
+	}
 	type Alias TaskResponse
 	return json.Marshal(&struct {
 		Alias
-		Due    string `json:"due,omitempty"`
-		DoneOn string `json:"done_on,omitempty"`
+		Due         string `json:"due,omitempty"`
+		DoneOn      string `json:"done_on,omitempty"`
+		HiddenUntil string `json:"hidden_until,omitempty"`
+		Archived    string `json:"archived,omitempty"`
 	}{
-		Alias:  Alias(t),
-		Due:    due,
-		DoneOn: doneOn,
+		Alias:       Alias(t),
+		Due:         due,
+		DoneOn:      doneOn,
+		HiddenUntil: hiddenUntil,
+		Archived:    archived,
 	})
 }
 
@@ -95,11 +284,23 @@ type RGB struct {
 }
 
 type TaskAdd struct {
-	Title    string       `json:"title"`
+	Title string `json:"title"`
+	// List is only used where the list isn't part of the request path, e.g. for quick add.
+	List     string       `json:"list,omitempty"`
 	Priority int          `json:"priority"`
 	AllDay   bool         `json:"all_day"`
 	DueType  core.DueType `json:"due_type"`
 	Due      time.Time    `json:"due"`
+	// Reminders are times like "2026-10-20T13:30" or durations before the due date like "30m before".
+	Reminders []string `json:"reminders,omitempty"`
+	// BlockedBy are the IDs of the tasks that must be done first.
+	BlockedBy []int `json:"blocked_by,omitempty"`
+	// Status is the step of the workflow of the list to start with, the first one if empty.
+	Status string `json:"status,omitempty"`
+	// Estimate is the expected effort, e.g. "2h30m".
+	Estimate string `json:"estimate,omitempty"`
+	// Tags label the task across lists, e.g. "acme" for the time report of a client.
+	Tags []string `json:"tags,omitempty"`
 }
 
 // UnmarshalJSON overwrites JSON unmarshalling to parse time fields properly
@@ -115,20 +316,53 @@ func (t *TaskAdd) UnmarshalJSON(data []byte) error {
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
 	}
-	format := "2006-01-02T15:04"
-	if aux.AllDay {
-		format = "2006-01-02"
-	}
 	if string(aux.DueType) != "none" && aux.DueType != core.DueNone {
-		due, err := time.ParseInLocation(format, aux.Due, time.Local)
+		due, err := parseDue(aux.Due, aux.AllDay)
 		if err != nil {
-			return err
+			return &FieldError{Field: "due", Message: fmt.Sprintf("invalid due date %q", aux.Due)}
 		}
 		t.Due = due
 	}
 	return nil
 }
 
+// MarshalJSON writes the due date in the format expected by UnmarshalJSON.
+func (t TaskAdd) MarshalJSON() ([]byte, error) {
+	var due string
+	if t.DueType != core.DueNone {
+		due = formatDue(t.Due, t.AllDay)
+	}
+	type Alias TaskAdd
+	return json.Marshal(&struct {
+		Alias
+		Due string `json:"due"`
+	}{
+		Alias: Alias(t),
+		Due:   due,
+	})
+}
+
+// formatDue formats a due date for parseDue: RFC 3339, which keeps the seconds that the minutes sent by the web UI
+// would drop, or only the local date for all-day tasks.
+func formatDue(due time.Time, allDay bool) string {
+	if allDay {
+		return due.In(time.Local).Format("2006-01-02")
+	}
+	return due.Format(time.RFC3339Nano)
+}
+
+// parseDue parses a due date in RFC 3339 or the local time to the minute, e.g. "2026-10-20T14:00". All-day due dates
+// are local dates like "2026-10-20".
+func parseDue(s string, allDay bool) (time.Time, error) {
+	if allDay {
+		return time.ParseInLocation("2006-01-02", s, time.Local)
+	}
+	if due, err := time.Parse(time.RFC3339, s); err == nil {
+		return due.In(time.Local), nil
+	}
+	return time.ParseInLocation("2006-01-02T15:04", s, time.Local)
+}
+
 // TaskChange is used to change a task. It is used in PATCH requests. Only fields that are set will be changed.
 // DueType must be set to one of TypeDueOn, TypeDueBy or TypeDueNone in requests to change the due date, otherwise
 // the due date supplied in the request will be ignored.
@@ -138,21 +372,60 @@ type TaskChange struct {
 	Done     bool   `json:"done"`
 	Priority int    `json:"priority"`
 	AllDay   bool   `json:"all_day"`
+	// Assignee must have access to the list, empty unassigns the task.
+	Assignee string `json:"assignee"`
+	// Reminders replace all reminders of the task, see TaskAdd.
+	Reminders []string `json:"reminders"`
+	// BlockedBy replaces the tasks the task depends on, see TaskAdd.
+	BlockedBy []int `json:"blocked_by"`
+	// Status moves the task to another step of the workflow of its list and takes precedence over Done.
+	Status string `json:"status"`
+	// Estimate is the expected effort, e.g. "2h30m", empty removes it.
+	Estimate string `json:"estimate"`
+	// Tags replace the tags of the task, see TaskAdd.
+	Tags []string `json:"tags"`
 
 	// DueType must be set to one of TypeDueOn, TypeDueBy or TypeDueNone in requests to change the due date.
 	DueType core.DueType `json:"due_type"`
 	Due     time.Time    `json:"due,omitempty"`
 }
 
+// NewTaskChange returns a change that leaves the given task as it is, to be modified by the caller.
+func NewTaskChange(t core.Task) TaskChange {
+	return TaskChange{
+		Title:     t.Title,
+		List:      t.List,
+		Done:      t.Done,
+		Priority:  t.Priority,
+		AllDay:    t.AllDay,
+		DueType:   t.DueType,
+		Due:       t.Due,
+		Assignee:  t.Assignee,
+		Reminders: NewReminders(t),
+		BlockedBy: slices.Clone(t.BlockedBy),
+		Status:    t.CurrentStatus(),
+		Estimate:  NewEstimate(t),
+		Tags:      slices.Clone(t.Tags),
+	}
+}
+
+// NewEstimate formats the estimate of a task for TaskAdd and TaskChange, empty if it has none.
+func NewEstimate(t core.Task) string {
+	if t.Estimate == 0 {
+		return ""
+	}
+	return t.Estimate.String()
+}
+
 func (t *TaskChange) Validate() error {
 	if t.Title == "" {
-		return fmt.Errorf("missing task title")
+		return &FieldError{Field: "title", Message: "missing task title"}
 	}
 	if t.List == "" {
-		return fmt.Errorf("missing list name")
+		return &FieldError{Field: "list", Message: "missing list name"}
 	}
 	if t.Priority < core.PrioLowest || t.Priority > core.PrioHighest {
-		return fmt.Errorf("priority outside of bounds")
+		return &FieldError{Field: "priority", Message: "priority outside of bounds"}
 	}
 
 	return nil
@@ -170,28 +443,28 @@ func (t *TaskChange) UnmarshalJSON(data []byte) error {
 	if title, ok := input["title"]; ok {
 		t.Title, ok = title.(string)
 		if !ok {
-			return fmt.Errorf("title must be a string")
+			return &FieldError{Field: "title", Message: "title must be a string"}
 		}
 	}
 
 	if list, ok := input["list"]; ok {
 		t.List, ok = list.(string)
 		if !ok {
-			return fmt.Errorf("list must be a string")
+			return &FieldError{Field: "list", Message: "list must be a string"}
 		}
 	}
 
 	if done, ok := input["done"]; ok {
 		t.Done, ok = done.(bool)
 		if !ok {
-			return fmt.Errorf("done must be a boolean")
+			return &FieldError{Field: "done", Message: "done must be a boolean"}
 		}
 	}
 
 	if priority, ok := input["priority"]; ok {
 		prioFloat, ok := priority.(float64)
 		if !ok {
-			return fmt.Errorf("priority must be an integer")
+			return &FieldError{Field: "priority", Message: "priority must be an integer"}
 		}
 		t.Priority = int(prioFloat)
 	}
@@ -199,7 +472,81 @@ func (t *TaskChange) UnmarshalJSON(data []byte) error {
 	if allDay, ok := input["all_day"]; ok {
 		t.AllDay, ok = allDay.(bool)
 		if !ok {
-			return fmt.Errorf("all_day must be a boolean")
+			return &FieldError{Field: "all_day", Message: "all_day must be a boolean"}
+		}
+	}
+
+	if assignee, ok := input["assignee"]; ok {
+		switch v := assignee.(type) {
+		case nil:
+			t.Assignee = ""
+		case string:
+			t.Assignee = v
+		default:
+			return &FieldError{Field: "assignee", Message: "assignee must be a string"}
+		}
+	}
+
+	if reminders, ok := input["reminders"]; ok {
+		values, ok := reminders.([]interface{})
+		if !ok && reminders != nil {
+			return &FieldError{Field: "reminders", Message: "reminders must be an array of strings"}
+		}
+		t.Reminders = make([]string, 0, len(values))
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return &FieldError{Field: "reminders", Message: "reminders must be an array of strings"}
+			}
+			t.Reminders = append(t.Reminders, s)
+		}
+	}
+
+	if status, ok := input["status"]; ok {
+		t.Status, ok = status.(string)
+		if !ok {
+			return &FieldError{Field: "status", Message: "status must be a string"}
+		}
+	}
+
+	if estimate, ok := input["estimate"]; ok {
+		switch v := estimate.(type) {
+		case nil:
+			t.Estimate = ""
+		case string:
+			t.Estimate = v
+		default:
+			return &FieldError{Field: "estimate", Message: "estimate must be a string"}
+		}
+	}
+
+	if tags, ok := input["tags"]; ok {
+		values, ok := tags.([]interface{})
+		if !ok && tags != nil {
+			return &FieldError{Field: "tags", Message: "tags must be an array of strings"}
+		}
+		t.Tags = make([]string, 0, len(values))
+		for _, v := range values {
+			s, ok := v.(string)
+			if !ok {
+				return &FieldError{Field: "tags", Message: "tags must be an array of strings"}
+			}
+			t.Tags = append(t.Tags, s)
+		}
+	}
+
+	if blockedBy, ok := input["blocked_by"]; ok {
+		values, ok := blockedBy.([]interface{})
+		if !ok && blockedBy != nil {
+			return &FieldError{Field: "blocked_by", Message: "blocked_by must be an array of task IDs"}
+		}
+		t.BlockedBy = make([]int, 0, len(values))
+		for _, v := range values {
+			id, ok := v.(float64)
+			if !ok || id != float64(int(id)) {
+				return &FieldError{Field: "blocked_by", Message: "blocked_by must be an array of task IDs"}
+			}
+			t.BlockedBy = append(t.BlockedBy, int(id))
 		}
 	}
 
@@ -211,20 +558,43 @@ func (t *TaskChange) UnmarshalJSON(data []byte) error {
 	} else {
 		// if due_type is not set, but due is, inform the user that due_type is required
 		if _, ok = input["due"]; ok {
-			return fmt.Errorf("due_type must be set (to due_on, due_by or none) when changing the due date")
+			return &FieldError{Field: "due_type", Message: "due_type must be set (to due_on, due_by or none) when changing the due date"}
 		}
 	}
 
 	return nil
 }
 
+// MarshalJSON writes all fields in the format expected by UnmarshalJSON, so the due date is always changed as well.
+func (t TaskChange) MarshalJSON() ([]byte, error) {
+	out := map[string]interface{}{
+		"title":      t.Title,
+		"list":       t.List,
+		"done":       t.Done,
+		"priority":   t.Priority,
+		"all_day":    t.AllDay,
+		"assignee":   t.Assignee,
+		"reminders":  t.Reminders,
+		"blocked_by": t.BlockedBy,
+		"status":     t.Status,
+		"estimate":   t.Estimate,
+		"tags":       t.Tags,
+		"due_type":   "none",
+	}
+	if t.DueType != core.DueNone {
+		out["due_type"] = string(t.DueType)
+		out["due"] = formatDue(t.Due, t.AllDay)
+	}
+	return json.Marshal(out)
+}
+
 func (t *TaskChange) overwriteDueFields(input map[string]interface{}) error {
 
 	// gracefully handle bad input
 	var dueTyp core.DueType
 	v, ok := input["due_type"].(string)
 	if !ok {
-		return fmt.Errorf("missing due_type field")
+		return &FieldError{Field: "due_type", Message: "due_type must be a string"}
 	}
 	switch v {
 	case string(core.DueBy), string(core.DueOn), string(core.DueNone):
@@ -232,12 +602,7 @@ func (t *TaskChange) overwriteDueFields(input map[string]interface{}) error {
 	case "none":
 		dueTyp = core.DueNone
 	default:
-		return fmt.Errorf("invalid due_type")
-	}
-
-	format := "2006-01-02T15:04"
-	if t.AllDay {
-		format = "2006-01-02"
+		return &FieldError{Field: "due_type", Message: "invalid due_type"}
 	}
 
 	switch dueTyp {
@@ -245,13 +610,13 @@ func (t *TaskChange) overwriteDueFields(input map[string]interface{}) error {
 		t.Due = time.Time{}
 		t.DueType = core.DueNone
 	case core.DueOn, core.DueBy:
-		dueRaw, ok := input["due"]
+		dueRaw, ok := input["due"].(string)
 		if !ok {
-			return fmt.Errorf("missing due field")
+			return &FieldError{Field: "due", Message: "missing due field"}
 		}
-		due, err := time.ParseInLocation(format, dueRaw.(string), time.Local)
+		due, err := parseDue(dueRaw, t.AllDay)
 		if err != nil {
-			return err
+			return &FieldError{Field: "due", Message: fmt.Sprintf("invalid due date %q", dueRaw)}
 		}
 		t.Due = due
 		t.DueType = dueTyp
@@ -0,0 +1,41 @@
+package api
+
+import "time"
+
+// Login is used to log in with a password, the session is returned as cookie.
+type Login struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// TokenAdd is used to create an API token.
+type TokenAdd struct {
+	Name string `json:"name"`
+}
+
+// TokenResponse describes an API token without the token itself, which is only returned when it is created.
+type TokenResponse struct {
+	ID       int        `json:"id"`
+	Name     string     `json:"name"`
+	Created  time.Time  `json:"created"`
+	LastUsed *time.Time `json:"last_used,omitempty"`
+}
+
+// UserResponse describes a user.
+type UserResponse struct {
+	Name  string `json:"name"`
+	Admin bool   `json:"admin"`
+}
+
+// UserAdd is used by admins to create a user.
+type UserAdd struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+	Admin    bool   `json:"admin"`
+}
+
+// UserChange is used by admins to change a user, fields that are nil are left unchanged.
+type UserChange struct {
+	Password *string `json:"password,omitempty"`
+	Admin    *bool   `json:"admin,omitempty"`
+}
@@ -0,0 +1,53 @@
+package api
+
+// ErrorCode is a machine-readable code identifying the kind of error in an ErrorResponse.
+type ErrorCode string
+
+const (
+	// CodeBadRequest is used for malformed requests, e.g. invalid JSON or path parameters.
+	CodeBadRequest ErrorCode = "bad_request"
+	// CodeValidationFailed is used for well-formed requests with invalid fields, see Error.Details.
+	CodeValidationFailed ErrorCode = "validation_failed"
+	// CodeUnauthenticated is used for requests without valid API token or session cookie, and for failed logins.
+	CodeUnauthenticated ErrorCode = "unauthenticated"
+	// CodeForbidden is used for requests of authenticated users lacking a required role, e.g. admin.
+	CodeForbidden    ErrorCode = "forbidden"
+	CodeListNotFound ErrorCode = "list_not_found"
+	CodeListExists   ErrorCode = "list_exists"
+	CodeTaskNotFound ErrorCode = "task_not_found"
+	CodeUserExists   ErrorCode = "user_exists"
+	// CodeGroupExists is used for adding a group of lists with the name of another group of the user.
+	CodeGroupExists ErrorCode = "group_exists"
+	// CodeTimerRunning is used for starting a timer while another one of the user is running.
+	CodeTimerRunning ErrorCode = "timer_running"
+	// CodeTimerNotRunning is used for stopping a timer that isn't running.
+	CodeTimerNotRunning ErrorCode = "timer_not_running"
+	// CodePomodoroRunning is used for starting a Pomodoro session while another one of the user is running.
+	CodePomodoroRunning ErrorCode = "pomodoro_running"
+	CodeNotFound        ErrorCode = "not_found"
+	CodeInternal        ErrorCode = "internal_error"
+)
+
+// ErrorResponse is the body of all error responses.
+type ErrorResponse struct {
+	Error Error `json:"error"`
+}
+
+// Error describes why a request failed.
+type Error struct {
+	Code    ErrorCode `json:"code"`
+	Message string    `json:"message"`
+	// Details lists the invalid fields of a request, it is only set for CodeValidationFailed.
+	Details []FieldError `json:"details,omitempty"`
+}
+
+// FieldError reports an invalid field of a request. It is used as error by validations, so that the server can report
+// the field to the client.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
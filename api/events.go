@@ -0,0 +1,16 @@
+package api
+
+import "time"
+
+// ChangeEvent is a change of a task or list as sent to webhooks and the event stream.
+type ChangeEvent struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	// User made the change, it is left out if authentication is disabled and for reminders.
+	User string `json:"user,omitempty"`
+	List string `json:"list"`
+	// Task is left out for list events.
+	Task *TaskResponse `json:"task,omitempty"`
+	// Pomodoro is the session of Pomodoro events.
+	Pomodoro *PomodoroSession `json:"pomodoro,omitempty"`
+}
@@ -0,0 +1,631 @@
+package api
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+)
+
+// ExportVersion is the version of the export schema. It is increased whenever the schema changes in a way that older
+// readers can't handle.
+const ExportVersion = 1
+
+// Export is the interchange format used by the export and import endpoints. Unlike the storage layout it is stable:
+// fields are only ever added, never renamed or removed, and Version is bumped on incompatible changes.
+type Export struct {
+	Version       int              `json:"version"`
+	Exported      time.Time        `json:"exported"`
+	Lists         []ExportList     `json:"lists"`
+	FilteredLists []ExportFiltered `json:"filtered_lists"`
+}
+
+type ExportList struct {
+	Name   string       `json:"name"`
+	Colour RGB          `json:"colour"`
+	Tasks  []ExportTask `json:"tasks"`
+}
+
+// ExportTask is a single task. An ID of 0 means "assign a new ID on import". DueType is one of "due_on", "due_by" or
+// "none", times are RFC 3339 and omitted when not set. The fields after DoneOn are only exported as JSON, CSV leaves
+// them out.
+type ExportTask struct {
+	ID       int        `json:"id"`
+	Title    string     `json:"title"`
+	Done     bool       `json:"done"`
+	Priority int        `json:"priority"`
+	AllDay   bool       `json:"all_day"`
+	DueType  string     `json:"due_type"`
+	Due      *time.Time `json:"due,omitempty"`
+	Created  *time.Time `json:"created,omitempty"`
+	DoneOn   *time.Time `json:"done_on,omitempty"`
+	Assignee string     `json:"assignee,omitempty"`
+	// Status is the step of the workflow of the list, it is recomputed if the list doesn't have it.
+	Status      string     `json:"status,omitempty"`
+	HiddenUntil *time.Time `json:"hidden_until,omitempty"`
+	// BlockedBy are IDs of tasks in the export, they follow the tasks if these get a new ID on import.
+	BlockedBy []int `json:"blocked_by,omitempty"`
+	// Estimate is a duration like "1h30m0s".
+	Estimate    string              `json:"estimate,omitempty"`
+	Tags        []string            `json:"tags,omitempty"`
+	Reminders   []TaskReminder      `json:"reminders,omitempty"`
+	Comments    []CommentResponse   `json:"comments,omitempty"`
+	History     []TaskEvent         `json:"history,omitempty"`
+	TimeEntries []TimeEntryResponse `json:"time_entries,omitempty"`
+	Pomodoros   []ExportPomodoro    `json:"pomodoros,omitempty"`
+	// LastCommentID is the highest ID given to a comment, IDs of deleted comments aren't given again.
+	LastCommentID int `json:"last_comment_id,omitempty"`
+	// LastEntryID is the highest ID given to a time entry, IDs of deleted entries aren't given again.
+	LastEntryID int `json:"last_time_entry_id,omitempty"`
+}
+
+// ExportPomodoro is a completed focus session on a task, Focus is a duration like "25m0s".
+type ExportPomodoro struct {
+	User  string    `json:"user,omitempty"`
+	Start time.Time `json:"start"`
+	Focus string    `json:"focus"`
+}
+
+// ExportFiltered is a filtered list definition. A task matches if all rules of any of the rule sets match.
+type ExportFiltered struct {
+	Name     string         `json:"name"`
+	RuleSets [][]ExportRule `json:"rule_sets"`
+}
+
+type ExportRule struct {
+	Field string `json:"field"`
+	Value string `json:"value"`
+}
+
+// NewExport converts lists and filtered lists to the export format.
+func NewExport(lists []*core.List, filtered []*filter.List) Export {
+	e := Export{
+		Version:       ExportVersion,
+		Exported:      time.Now(),
+		Lists:         make([]ExportList, 0, len(lists)),
+		FilteredLists: make([]ExportFiltered, 0, len(filtered)),
+	}
+	for _, l := range lists {
+		el := ExportList{
+			Name:   l.Name,
+			Colour: RGB{R: l.Colour.R, G: l.Colour.G, B: l.Colour.B},
+			Tasks:  make([]ExportTask, 0, len(l.Items)),
+		}
+		for _, t := range l.Items {
+			el.Tasks = append(el.Tasks, exportTask(*t))
+		}
+		e.Lists = append(e.Lists, el)
+	}
+	for _, fl := range filtered {
+		e.FilteredLists = append(e.FilteredLists, ExportFiltered{Name: fl.Name, RuleSets: FromFilter(fl.Filter)})
+	}
+	return e
+}
+
+// FromFilter converts the rule sets of a filter.
+func FromFilter(f filter.Filter) [][]ExportRule {
+	ruleSets := make([][]ExportRule, 0, len(f.RuleSets))
+	for _, rs := range f.RuleSets {
+		rules := make([]ExportRule, 0, len(rs.Rules))
+		for _, r := range rs.Rules {
+			rules = append(rules, ExportRule{Field: r.Field, Value: fmt.Sprint(r.Value)})
+		}
+		ruleSets = append(ruleSets, rules)
+	}
+	return ruleSets
+}
+
+// NewFilter returns a filter with the given rule sets.
+func NewFilter(ruleSets [][]ExportRule) (filter.Filter, error) {
+	var f filter.Filter
+	for _, ers := range ruleSets {
+		var rs filter.RuleSet
+		for _, er := range ers {
+			rule, err := filter.NewRule(er.Field, er.Value)
+			if err != nil {
+				return filter.Filter{}, err
+			}
+			rs.Rules = append(rs.Rules, rule)
+		}
+		f.RuleSets = append(f.RuleSets, rs)
+	}
+	return f, nil
+}
+
+func exportTask(t core.Task) ExportTask {
+	et := ExportTask{
+		ID:       t.ID,
+		Title:    t.Title,
+		Done:     t.Done,
+		Priority: t.Priority,
+		AllDay:   t.AllDay,
+		DueType:  exportDueType(t.DueType),
+		Due:      timePtr(t.Due),
+		Created:  timePtr(t.Created),
+		DoneOn:   timePtr(t.DoneOn),
+	}
+	if !t.HasDueDate() {
+		et.Due = nil
+	}
+	et.Assignee = t.Assignee
+	et.Status = t.Status
+	et.HiddenUntil = timePtr(t.HiddenUntil)
+	et.BlockedBy = slices.Clone(t.BlockedBy)
+	et.Estimate = NewEstimate(t)
+	et.Tags = slices.Clone(t.Tags)
+	for _, r := range t.Reminders {
+		reminder := TaskReminder{At: r.At, Fired: r.Fired}
+		if r.Relative {
+			reminder.Before = r.Before.String()
+		}
+		et.Reminders = append(et.Reminders, reminder)
+	}
+	for _, c := range t.Comments {
+		et.Comments = append(et.Comments, FromComment(c))
+	}
+	et.LastCommentID = t.LastCommentID
+	if len(t.History) > 0 {
+		et.History = FromHistory(t)
+	}
+	now := time.Now()
+	for _, e := range t.TimeEntries {
+		et.TimeEntries = append(et.TimeEntries, FromTimeEntry(e, now))
+	}
+	et.LastEntryID = t.LastEntryID
+	for _, p := range t.Pomodoros {
+		et.Pomodoros = append(et.Pomodoros, ExportPomodoro{User: p.User, Start: p.Start, Focus: p.Focus.String()})
+	}
+	return et
+}
+
+// Core validates the export and converts it to lists and filtered lists. Nothing is partially converted: if any list,
+// task or rule is invalid, an error is returned.
+func (e Export) Core() ([]*core.List, []*filter.List, error) {
+	if e.Version != ExportVersion {
+		return nil, nil, fmt.Errorf("unsupported export version %d", e.Version)
+	}
+
+	names := make(map[string]bool)
+	ids := make(map[int]bool)
+	lists := make([]*core.List, 0, len(e.Lists))
+	for _, el := range e.Lists {
+		if el.Name == "" {
+			return nil, nil, errors.New("missing list name")
+		}
+		if names[el.Name] {
+			return nil, nil, fmt.Errorf("duplicate list %q", el.Name)
+		}
+		names[el.Name] = true
+
+		l := &core.List{Name: el.Name, Colour: core.RGB{R: el.Colour.R, G: el.Colour.G, B: el.Colour.B}}
+		for _, et := range el.Tasks {
+			t, err := et.core(el.Name)
+			if err != nil {
+				return nil, nil, fmt.Errorf("list %q: %w", el.Name, err)
+			}
+			if t.ID != 0 {
+				if ids[t.ID] {
+					return nil, nil, fmt.Errorf("duplicate task ID %d", t.ID)
+				}
+				ids[t.ID] = true
+			}
+			l.Items = append(l.Items, &t)
+		}
+		lists = append(lists, l)
+	}
+
+	names = make(map[string]bool)
+	filtered := make([]*filter.List, 0, len(e.FilteredLists))
+	for _, ef := range e.FilteredLists {
+		if ef.Name == "" {
+			return nil, nil, errors.New("missing filtered list name")
+		}
+		if names[ef.Name] {
+			return nil, nil, fmt.Errorf("duplicate filtered list %q", ef.Name)
+		}
+		names[ef.Name] = true
+
+		f, err := NewFilter(ef.RuleSets)
+		if err != nil {
+			return nil, nil, fmt.Errorf("filtered list %q: %w", ef.Name, err)
+		}
+		filtered = append(filtered, &filter.List{Name: ef.Name, Filter: f})
+	}
+
+	return lists, filtered, nil
+}
+
+func (et ExportTask) core(list string) (core.Task, error) {
+	if et.Title == "" {
+		return core.Task{}, errors.New("missing task title")
+	}
+	if et.ID < 0 {
+		return core.Task{}, fmt.Errorf("invalid task ID %d", et.ID)
+	}
+	if et.Priority < core.PrioLowest || et.Priority > core.PrioHighest {
+		return core.Task{}, fmt.Errorf("task %q: priority outside of bounds", et.Title)
+	}
+
+	t := core.Task{
+		ID:       et.ID,
+		Title:    et.Title,
+		List:     list,
+		Done:     et.Done,
+		Priority: et.Priority,
+		AllDay:   et.AllDay,
+	}
+
+	switch et.DueType {
+	case "none", "":
+		t.DueType = core.DueNone
+	case string(core.DueOn), string(core.DueBy):
+		if et.Due == nil {
+			return core.Task{}, fmt.Errorf("task %q: missing due date", et.Title)
+		}
+		t.DueType = core.DueType(et.DueType)
+		t.Due = *et.Due
+	default:
+		return core.Task{}, fmt.Errorf("task %q: invalid due_type %q", et.Title, et.DueType)
+	}
+
+	if et.Created != nil {
+		t.Created = *et.Created
+	} else {
+		t.Created = time.Now()
+	}
+	if et.Done && et.DoneOn != nil {
+		t.DoneOn = *et.DoneOn
+	}
+	if err := et.coreExtras(&t); err != nil {
+		return core.Task{}, fmt.Errorf("task %q: %w", et.Title, err)
+	}
+
+	return t, nil
+}
+
+// coreExtras converts the fields that are only exported as JSON.
+func (et ExportTask) coreExtras(t *core.Task) error {
+	t.Assignee = et.Assignee
+	t.Status = et.Status
+	if et.HiddenUntil != nil {
+		t.HiddenUntil = *et.HiddenUntil
+	}
+	for _, id := range et.BlockedBy {
+		if id <= 0 || id == et.ID {
+			return fmt.Errorf("invalid blocked_by ID %d", id)
+		}
+	}
+	t.BlockedBy = slices.Clone(et.BlockedBy)
+	if et.Estimate != "" {
+		d, err := time.ParseDuration(et.Estimate)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid estimate %q", et.Estimate)
+		}
+		t.Estimate = d
+	}
+	if et.Tags != nil {
+		if err := t.SetTags(et.Tags); err != nil {
+			return err
+		}
+	}
+	for _, r := range et.Reminders {
+		reminder := core.Reminder{At: r.At, Fired: r.Fired}
+		if r.Before != "" {
+			d, err := time.ParseDuration(r.Before)
+			if err != nil || d < 0 {
+				return fmt.Errorf("invalid reminder before %q", r.Before)
+			}
+			reminder.Relative, reminder.Before = true, d
+		}
+		t.Reminders = append(t.Reminders, reminder)
+	}
+	for _, c := range et.Comments {
+		comment := core.Comment{ID: c.ID, Author: c.Author, Text: c.Text, Created: c.Created}
+		if c.Edited != nil {
+			comment.Edited = *c.Edited
+		}
+		t.Comments = append(t.Comments, comment)
+	}
+	t.LastCommentID = et.LastCommentID
+	for _, e := range et.History {
+		t.History = append(t.History, core.Event{Time: e.Time, User: e.User, Field: e.Field, From: e.From, To: e.To})
+	}
+	for _, e := range et.TimeEntries {
+		entry := core.TimeEntry{ID: e.ID, User: e.User, Start: e.Start, Note: e.Note}
+		if e.End != nil {
+			entry.End = *e.End
+		}
+		t.TimeEntries = append(t.TimeEntries, entry)
+	}
+	t.LastEntryID = et.LastEntryID
+	for _, p := range et.Pomodoros {
+		focus, err := time.ParseDuration(p.Focus)
+		if err != nil || focus <= 0 {
+			return fmt.Errorf("invalid pomodoro focus %q", p.Focus)
+		}
+		t.Pomodoros = append(t.Pomodoros, core.Pomodoro{User: p.User, Start: p.Start, Focus: focus})
+	}
+	return nil
+}
+
+// csvHeader is the header row of the CSV export. Every row describes one record, the "record" column is one of "list",
+// "task" or "filtered". List rows only use the list and colour columns, filtered rows only use list and filter.
+var csvHeader = []string{
+	"record", "list", "colour", "id", "title", "done", "priority", "all_day", "due_type", "due", "created", "done_on",
+	"filter",
+}
+
+// WriteCSV writes the export as CSV. Colours are written as #rrggbb, times as RFC 3339 and filters as rule sets
+// separated by "|", each consisting of URL-encoded field=value pairs separated by "&", e.g. "done=false&due_on=0|overdue=true".
+func (e Export) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+	for _, l := range e.Lists {
+		colour := fmt.Sprintf("#%02x%02x%02x", l.Colour.R, l.Colour.G, l.Colour.B)
+		if err := cw.Write([]string{"list", l.Name, colour, "", "", "", "", "", "", "", "", "", ""}); err != nil {
+			return err
+		}
+		for _, t := range l.Tasks {
+			row := []string{
+				"task", l.Name, "", strconv.Itoa(t.ID), t.Title, strconv.FormatBool(t.Done), strconv.Itoa(t.Priority),
+				strconv.FormatBool(t.AllDay), t.DueType, formatTimePtr(t.Due), formatTimePtr(t.Created),
+				formatTimePtr(t.DoneOn), "",
+			}
+			if err := cw.Write(row); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range e.FilteredLists {
+		if err := cw.Write([]string{"filtered", f.Name, "", "", "", "", "", "", "", "", "", "", encodeRuleSets(f.RuleSets)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// ReadCSV reads an export written by WriteCSV. Tasks may be listed before or after their list row, but the list row
+// must be present.
+func ReadCSV(r io.Reader) (Export, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = len(csvHeader)
+
+	header, err := cr.Read()
+	if err != nil {
+		return Export{}, fmt.Errorf("failed to read CSV header: %w", err)
+	}
+	for i, col := range csvHeader {
+		if header[i] != col {
+			return Export{}, fmt.Errorf("unexpected CSV column %q, want %q", header[i], col)
+		}
+	}
+
+	e := Export{Version: ExportVersion, Exported: time.Now()}
+	index := make(map[string]int)
+	var tasks []ExportTask
+	var taskLists []string
+	for line := 2; ; line++ {
+		row, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return Export{}, err
+		}
+		switch row[0] {
+		case "list":
+			colour, err := parseHexColour(row[2])
+			if err != nil {
+				return Export{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			if _, ok := index[row[1]]; ok {
+				return Export{}, fmt.Errorf("line %d: duplicate list %q", line, row[1])
+			}
+			index[row[1]] = len(e.Lists)
+			e.Lists = append(e.Lists, ExportList{Name: row[1], Colour: colour})
+		case "task":
+			t, err := parseCSVTask(row)
+			if err != nil {
+				return Export{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			tasks = append(tasks, t)
+			taskLists = append(taskLists, row[1])
+		case "filtered":
+			ruleSets, err := decodeRuleSets(row[12])
+			if err != nil {
+				return Export{}, fmt.Errorf("line %d: %w", line, err)
+			}
+			e.FilteredLists = append(e.FilteredLists, ExportFiltered{Name: row[1], RuleSets: ruleSets})
+		default:
+			return Export{}, fmt.Errorf("line %d: unknown record type %q", line, row[0])
+		}
+	}
+
+	for i, t := range tasks {
+		li, ok := index[taskLists[i]]
+		if !ok {
+			return Export{}, fmt.Errorf("task %q references unknown list %q", t.Title, taskLists[i])
+		}
+		e.Lists[li].Tasks = append(e.Lists[li].Tasks, t)
+	}
+
+	return e, nil
+}
+
+func parseCSVTask(row []string) (ExportTask, error) {
+	var t ExportTask
+	var err error
+	if row[3] != "" {
+		if t.ID, err = strconv.Atoi(row[3]); err != nil {
+			return ExportTask{}, fmt.Errorf("invalid id: %w", err)
+		}
+	}
+	t.Title = row[4]
+	if t.Done, err = parseCSVBool(row[5]); err != nil {
+		return ExportTask{}, fmt.Errorf("invalid done: %w", err)
+	}
+	if row[6] != "" {
+		if t.Priority, err = strconv.Atoi(row[6]); err != nil {
+			return ExportTask{}, fmt.Errorf("invalid priority: %w", err)
+		}
+	}
+	if t.AllDay, err = parseCSVBool(row[7]); err != nil {
+		return ExportTask{}, fmt.Errorf("invalid all_day: %w", err)
+	}
+	t.DueType = row[8]
+	if t.Due, err = parseTimePtr(row[9]); err != nil {
+		return ExportTask{}, fmt.Errorf("invalid due: %w", err)
+	}
+	if t.Created, err = parseTimePtr(row[10]); err != nil {
+		return ExportTask{}, fmt.Errorf("invalid created: %w", err)
+	}
+	if t.DoneOn, err = parseTimePtr(row[11]); err != nil {
+		return ExportTask{}, fmt.Errorf("invalid done_on: %w", err)
+	}
+	return t, nil
+}
+
+func parseCSVBool(s string) (bool, error) {
+	if s == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(s)
+}
+
+func parseHexColour(s string) (RGB, error) {
+	if s == "" {
+		return RGB{}, nil
+	}
+	var c RGB
+	if _, err := fmt.Sscanf(s, "#%02x%02x%02x", &c.R, &c.G, &c.B); err != nil {
+		return RGB{}, fmt.Errorf("invalid colour %q", s)
+	}
+	return c, nil
+}
+
+func encodeRuleSets(ruleSets [][]ExportRule) string {
+	sets := make([]string, 0, len(ruleSets))
+	for _, rs := range ruleSets {
+		rules := make([]string, 0, len(rs))
+		for _, r := range rs {
+			rules = append(rules, url.QueryEscape(r.Field)+"="+url.QueryEscape(r.Value))
+		}
+		sets = append(sets, strings.Join(rules, "&"))
+	}
+	return strings.Join(sets, "|")
+}
+
+func decodeRuleSets(s string) ([][]ExportRule, error) {
+	if s == "" {
+		return nil, nil
+	}
+	var ruleSets [][]ExportRule
+	for _, set := range strings.Split(s, "|") {
+		var rules []ExportRule
+		for _, pair := range strings.Split(set, "&") {
+			field, value, ok := strings.Cut(pair, "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid filter rule %q", pair)
+			}
+			var err error
+			var r ExportRule
+			if r.Field, err = url.QueryUnescape(field); err != nil {
+				return nil, err
+			}
+			if r.Value, err = url.QueryUnescape(value); err != nil {
+				return nil, err
+			}
+			rules = append(rules, r)
+		}
+		ruleSets = append(ruleSets, rules)
+	}
+	return ruleSets, nil
+}
+
+func exportDueType(d core.DueType) string {
+	if d == core.DueNone {
+		return "none"
+	}
+	return string(d)
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func formatTimePtr(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseTimePtr(s string) (*time.Time, error) {
+	if s == "" {
+		return nil, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// MergeStrategy decides what happens when imported data conflicts with existing lists or tasks.
+type MergeStrategy string
+
+const (
+	// MergeSkip keeps the existing list or task and drops the imported one.
+	MergeSkip MergeStrategy = "skip"
+	// MergeOverwrite replaces the existing list properties or task with the imported one. Task fields the import doesn't
+	// set, e.g. comments in a CSV import, are kept.
+	MergeOverwrite MergeStrategy = "overwrite"
+	// MergeDuplicate keeps both, the imported list is renamed and the imported task gets a new ID.
+	MergeDuplicate MergeStrategy = "duplicate"
+)
+
+// ParseMergeStrategy parses a merge strategy, an empty string defaults to MergeSkip.
+func ParseMergeStrategy(s string) (MergeStrategy, error) {
+	switch MergeStrategy(s) {
+	case "":
+		return MergeSkip, nil
+	case MergeSkip, MergeOverwrite, MergeDuplicate:
+		return MergeStrategy(s), nil
+	}
+	return "", fmt.Errorf("invalid merge strategy %q", s)
+}
+
+// ImportReport summarises the result of an import.
+type ImportReport struct {
+	Strategy        MergeStrategy    `json:"strategy"`
+	ListsCreated    int              `json:"lists_created"`
+	FilteredCreated int              `json:"filtered_created"`
+	TasksImported   int              `json:"tasks_imported"`
+	TasksSkipped    int              `json:"tasks_skipped"`
+	Conflicts       []ImportConflict `json:"conflicts"`
+}
+
+// ImportConflict describes a list, filtered list or task that already existed and how it was resolved. Lists are
+// identified by Name, tasks by ID.
+type ImportConflict struct {
+	Kind       string        `json:"kind"` // "list", "filtered_list" or "task"
+	Name       string        `json:"name,omitempty"`
+	ID         int           `json:"id,omitempty"`
+	Resolution MergeStrategy `json:"resolution"`
+	NewName    string        `json:"new_name,omitempty"`
+	NewID      int           `json:"new_id,omitempty"`
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+)
+
+func TestExport_CSVRoundTrip(t *testing.T) {
+	due := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	created := time.Date(2026, 10, 1, 9, 30, 0, 0, time.UTC)
+	lists := []*core.List{
+		{Name: "Home, sweet home", Colour: core.RGB{R: 255, G: 165}, Items: []*core.Task{
+			{ID: 1, Title: `Buy "avocados"`, List: "Home, sweet home", Priority: core.PrioHigh, DueType: core.DueOn, Due: due, Created: created},
+			{ID: 2, Title: "Walk the cat", List: "Home, sweet home", Done: true, Created: created, DoneOn: due},
+		}},
+		{Name: "Empty"},
+	}
+	rule, _ := filter.NewRule("list", "Home, sweet home,Work")
+	pending, _ := filter.NewRule("done", "false")
+	filtered := []*filter.List{
+		{Name: "Soon", Filter: filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{rule, pending}}, {Rules: []filter.Rule{pending}}}}},
+	}
+
+	var buf bytes.Buffer
+	if err := NewExport(lists, filtered).WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	e, err := ReadCSV(&buf)
+	if err != nil {
+		t.Fatalf("ReadCSV() error = %v", err)
+	}
+	gotLists, gotFiltered, err := e.Core()
+	if err != nil {
+		t.Fatalf("Core() error = %v", err)
+	}
+
+	if len(gotLists) != 2 || gotLists[0].Name != "Home, sweet home" || gotLists[0].Colour != lists[0].Colour {
+		t.Fatalf("lists = %+v, want %+v", gotLists, lists)
+	}
+	for i, want := range lists[0].Items {
+		got := gotLists[0].Items[i]
+		if got.ID != want.ID || got.Title != want.Title || got.Done != want.Done || got.Priority != want.Priority ||
+			got.DueType != want.DueType || !got.Due.Equal(want.Due) || !got.Created.Equal(want.Created) ||
+			!got.DoneOn.Equal(want.DoneOn) {
+			t.Errorf("task %d = %+v, want %+v", i, got, want)
+		}
+	}
+	if len(gotFiltered) != 1 || len(gotFiltered[0].Filter.RuleSets) != 2 {
+		t.Fatalf("filtered = %+v, want %+v", gotFiltered, filtered)
+	}
+	if got := gotFiltered[0].Filter.RuleSets[0].Rules[0].Value; got != "Home, sweet home,Work" {
+		t.Errorf("rule value = %v, want %v", got, "Home, sweet home,Work")
+	}
+}
+
+func TestExport_JSONRoundTrip(t *testing.T) {
+	at := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	task := &core.Task{
+		ID: 2, Title: "Slides", List: "Work", DueType: core.DueOn, Due: at, Created: at, Assignee: "bob",
+		History:     []core.Event{{Time: at, User: "alice", Field: "assignee", To: "bob"}},
+		Comments:    []core.Comment{{ID: 1, Author: "alice", Text: "Draft?", Created: at, Edited: at}},
+		Reminders:   []core.Reminder{{At: at.Add(-time.Hour), Relative: true, Before: time.Hour}, {At: at, Fired: true}},
+		HiddenUntil: at,
+		BlockedBy:   []int{1},
+		Status:      "review",
+		Estimate:    90 * time.Minute,
+		TimeEntries: []core.TimeEntry{{ID: 1, User: "bob", Start: at, End: at.Add(time.Hour), Note: "outline"}},
+		Pomodoros:   []core.Pomodoro{{User: "bob", Start: at, Focus: 25 * time.Minute}},
+	}
+	lists := []*core.List{{Name: "Work", Items: []*core.Task{{ID: 1, Title: "Report", List: "Work", Created: at}, task}}}
+
+	data, err := json.Marshal(NewExport(lists, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Export
+	if err = json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	got, _, err := e.Core()
+	if err != nil {
+		t.Fatalf("Core() error = %v", err)
+	}
+
+	if !reflect.DeepEqual(got[0].Items[1], task) {
+		t.Errorf("task = %+v, want %+v", got[0].Items[1], task)
+	}
+}
+
+// TestExport_JSONRoundTripFields checks every field added to tasks after the export on its own, so a field that the
+// export forgets shows up by name.
+func TestExport_JSONRoundTripFields(t *testing.T) {
+	at := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		set  func(t *core.Task)
+	}{
+		{"assignee", func(t *core.Task) { t.Assignee = "bob" }},
+		{"history", func(t *core.Task) {
+			t.History = []core.Event{{Time: at, User: "alice", Field: "title", From: "a", To: "b"}}
+		}},
+		{"comments", func(t *core.Task) {
+			t.Comments = []core.Comment{{ID: 3, Author: "alice", Text: "Draft?", Created: at, Edited: at.Add(time.Hour)}}
+		}},
+		{"last comment id", func(t *core.Task) { t.LastCommentID = 4 }},
+		{"reminders", func(t *core.Task) {
+			t.DueType, t.Due = core.DueOn, at
+			t.Reminders = []core.Reminder{{At: at.Add(-time.Hour), Relative: true, Before: time.Hour}, {At: at, Fired: true}}
+		}},
+		{"hidden until", func(t *core.Task) { t.HiddenUntil = at }},
+		{"blocked by", func(t *core.Task) { t.BlockedBy = []int{1} }},
+		{"status", func(t *core.Task) { t.Status = "review" }},
+		{"estimate", func(t *core.Task) { t.Estimate = 90 * time.Minute }},
+		{"tags", func(t *core.Task) { t.Tags = []string{"acme", "billable"} }},
+		{"time entries", func(t *core.Task) {
+			t.TimeEntries = []core.TimeEntry{{ID: 2, User: "bob", Start: at, End: at.Add(time.Hour), Note: "outline"}}
+		}},
+		{"last time entry id", func(t *core.Task) { t.LastEntryID = 5 }},
+		{"pomodoros", func(t *core.Task) { t.Pomodoros = []core.Pomodoro{{User: "bob", Start: at, Focus: 25 * time.Minute}} }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &core.Task{ID: 2, Title: "Slides", List: "Work", Created: at}
+			tt.set(task)
+			lists := []*core.List{{Name: "Work", Items: []*core.Task{{ID: 1, Title: "Report", List: "Work", Created: at}, task}}}
+
+			data, err := json.Marshal(NewExport(lists, nil))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var e Export
+			if err = json.Unmarshal(data, &e); err != nil {
+				t.Fatal(err)
+			}
+			got, _, err := e.Core()
+			if err != nil {
+				t.Fatalf("Core() error = %v", err)
+			}
+			if !reflect.DeepEqual(got[0].Items[1], task) {
+				t.Errorf("task = %+v, want %+v", got[0].Items[1], task)
+			}
+		})
+	}
+}
+
+func TestExport_Core(t *testing.T) {
+	tests := []struct {
+		name    string
+		export  Export
+		wantErr bool
+	}{
+		{"empty", Export{Version: ExportVersion}, false},
+		{"wrong version", Export{Version: 99}, true},
+		{"duplicate list", Export{Version: ExportVersion, Lists: []ExportList{{Name: "A"}, {Name: "A"}}}, true},
+		{"duplicate task ID", Export{Version: ExportVersion, Lists: []ExportList{
+			{Name: "A", Tasks: []ExportTask{{ID: 1, Title: "a"}}},
+			{Name: "B", Tasks: []ExportTask{{ID: 1, Title: "b"}}},
+		}}, true},
+		{"missing due date", Export{Version: ExportVersion, Lists: []ExportList{
+			{Name: "A", Tasks: []ExportTask{{Title: "a", DueType: "due_by"}}},
+		}}, true},
+		{"invalid estimate", Export{Version: ExportVersion, Lists: []ExportList{
+			{Name: "A", Tasks: []ExportTask{{Title: "a", Estimate: "soon"}}},
+		}}, true},
+		{"blocked by itself", Export{Version: ExportVersion, Lists: []ExportList{
+			{Name: "A", Tasks: []ExportTask{{ID: 1, Title: "a", BlockedBy: []int{1}}}},
+		}}, true},
+		{"invalid rule", Export{Version: ExportVersion, FilteredLists: []ExportFiltered{
+			{Name: "F", RuleSets: [][]ExportRule{{{Field: "nope"}}}},
+		}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := tt.export.Core(); (err != nil) != tt.wantErr {
+				t.Errorf("Core() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,32 @@
+package api
+
+import "github.com/jniewt/gotodo/internal/core"
+
+// GroupAdd is used to add or change a group of lists, renaming groups is not supported.
+type GroupAdd struct {
+	Name   string `json:"name"`
+	Colour RGB    `json:"colour"`
+	// Lists are the names of the lists in the group in order, they are moved out of other groups.
+	Lists []string `json:"lists"`
+}
+
+// GroupResponse is a group of lists of the user.
+type GroupResponse struct {
+	Name   string   `json:"name"`
+	Colour RGB      `json:"colour"`
+	Lists  []string `json:"lists"`
+}
+
+// GroupOrder changes the order of the groups of the user.
+type GroupOrder struct {
+	// Groups are the names of the groups in the new order, groups left out follow them.
+	Groups []string `json:"groups"`
+}
+
+func FromGroup(g core.Group) GroupResponse {
+	lists := g.Lists
+	if lists == nil {
+		lists = []string{}
+	}
+	return GroupResponse{Name: g.Name, Colour: RGB{R: g.Colour.R, G: g.Colour.G, B: g.Colour.B}, Lists: lists}
+}
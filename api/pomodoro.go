@@ -0,0 +1,90 @@
+package api
+
+import (
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// Phases of a Pomodoro session.
+const (
+	PhaseFocus = "focus"
+	PhaseBreak = "break"
+)
+
+// PomodoroStart is used to start a Pomodoro session on a task.
+type PomodoroStart struct {
+	// Focus and Break are durations like "50m", they default to 25 and 5 minutes.
+	Focus string `json:"focus,omitempty"`
+	Break string `json:"break,omitempty"`
+}
+
+// PomodoroSession is the running Pomodoro session of a user.
+type PomodoroSession struct {
+	Task  int    `json:"task"`
+	User  string `json:"user,omitempty"`
+	Phase string `json:"phase"`
+	// Started is the start of the focus phase, PhaseEnd the end of the current phase.
+	Started  time.Time `json:"started"`
+	PhaseEnd time.Time `json:"phase_end"`
+	Focus    string    `json:"focus"`
+	Break    string    `json:"break"`
+}
+
+// PomodoroStats are the completed focus sessions of a user per day, oldest first. Days without sessions are included.
+type PomodoroStats struct {
+	Days  []PomodoroDay `json:"days"`
+	Total PomodoroDay   `json:"total"`
+}
+
+// PomodoroDay are the focus sessions completed on a day, the total has no date.
+type PomodoroDay struct {
+	Date     string `json:"date,omitempty"`
+	Sessions int    `json:"sessions"`
+	// Focus is the time spent in the sessions, e.g. "1h40m0s".
+	Focus string `json:"focus"`
+	// Tasks is the number of different tasks worked on.
+	Tasks int `json:"tasks"`
+}
+
+// NewPomodoroStats counts the focus sessions of user on the tasks of the lists from the first until the last day,
+// including, in the location of from. Without user, e.g. if authentication is disabled, all sessions count.
+func NewPomodoroStats(lists []*core.List, user string, from, to time.Time) PomodoroStats {
+	type day struct {
+		sessions int
+		focus    time.Duration
+		tasks    map[int]bool
+	}
+	days := make(map[string]*day)
+	total := &day{tasks: make(map[int]bool)}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		days[d.Format("2006-01-02")] = &day{tasks: make(map[int]bool)}
+	}
+	for _, l := range lists {
+		for _, t := range l.Items {
+			for _, p := range t.Pomodoros {
+				if user != "" && p.User != user {
+					continue
+				}
+				d, ok := days[p.Start.In(from.Location()).Format("2006-01-02")]
+				if !ok {
+					continue
+				}
+				for _, d := range []*day{d, total} {
+					d.sessions++
+					d.focus += p.Focus
+					d.tasks[t.ID] = true
+				}
+			}
+		}
+	}
+
+	stats := PomodoroStats{Days: make([]PomodoroDay, 0, len(days))}
+	for d := from; !d.After(to); d = d.AddDate(0, 0, 1) {
+		date := d.Format("2006-01-02")
+		s := days[date]
+		stats.Days = append(stats.Days, PomodoroDay{Date: date, Sessions: s.sessions, Focus: s.focus.String(), Tasks: len(s.tasks)})
+	}
+	stats.Total = PomodoroDay{Sessions: total.sessions, Focus: total.focus.String(), Tasks: len(total.tasks)}
+	return stats
+}
@@ -0,0 +1,229 @@
+package api
+
+import (
+	"cmp"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// Intervals of the periods of statistics.
+const (
+	IntervalDay  = "day"
+	IntervalWeek = "week"
+)
+
+// MaxStatsPeriods is the maximum number of periods of statistics, e.g. about two years of days.
+const MaxStatsPeriods = 750
+
+// Stats are the productivity statistics of the tasks a user can see from From until To, excluding To. Counts of
+// created and completed tasks only include tasks created or completed in the range, Open and Overdue are counted at
+// the time the statistics are made.
+type Stats struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Interval is day or week, weeks start on Monday.
+	Interval string        `json:"interval"`
+	Periods  []StatsPeriod `json:"periods"`
+	Total    StatsGroup    `json:"total"`
+	// Lists and Priorities break the total down, sorted by list name and priority.
+	Lists      []StatsGroup `json:"lists"`
+	Priorities []StatsGroup `json:"priorities"`
+}
+
+// StatsPeriod are the tasks created and completed in a day or week.
+type StatsPeriod struct {
+	// Start is the first day of the period, e.g. "2026-10-19".
+	Start     string `json:"start"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// StatsGroup are the statistics of all tasks, a list or a priority.
+type StatsGroup struct {
+	// Key is the name of the list or the priority, empty for the total.
+	Key       string `json:"key,omitempty"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+	// CompletedLate counts the completed tasks that were done after their due date.
+	CompletedLate int `json:"completed_late"`
+	Open          int `json:"open"`
+	Overdue       int `json:"overdue"`
+	// AvgCompletionHours is the average time from creating to completing the completed tasks, zero if there are none.
+	AvgCompletionHours float64 `json:"avg_completion_hours"`
+
+	completion time.Duration
+}
+
+// NewStats makes the statistics of the tasks of the lists from from until to, excluding to, in periods of interval.
+// from is moved back to the start of its week for weekly statistics. It goes through all tasks once, so it is fast
+// also for many tasks.
+func NewStats(lists []*core.List, from, to time.Time, interval string, now time.Time) (Stats, error) {
+	if interval == "" {
+		interval = IntervalDay
+	}
+	days := 1
+	switch interval {
+	case IntervalDay:
+	case IntervalWeek:
+		days = 7
+		// weeks start on Monday
+		from = from.AddDate(0, 0, -(int(from.Weekday())+6)%7)
+	default:
+		return Stats{}, &FieldError{Field: "interval", Message: fmt.Sprintf("invalid interval %q, must be day or week", interval)}
+	}
+	if !to.After(from) {
+		return Stats{}, &FieldError{Field: "to", Message: "to must be after from"}
+	}
+
+	var periods []StatsPeriod
+	for d := from; d.Before(to); d = d.AddDate(0, 0, days) {
+		if len(periods) == MaxStatsPeriods {
+			return Stats{}, &FieldError{Field: "to", Message: fmt.Sprintf("too many periods, at most %d %ss", MaxStatsPeriods, interval)}
+		}
+		periods = append(periods, StatsPeriod{Start: d.Format("2006-01-02")})
+	}
+	// period returns the index of the period of t, or -1 if t is out of range
+	period := func(t time.Time) int {
+		if t.Before(from) || !t.Before(to) {
+			return -1
+		}
+		// calendar days, as days with a switch to or from daylight saving time don't have 24 hours
+		t = t.In(from.Location())
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		first := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+		return int(day.Sub(first).Hours()/24) / days
+	}
+
+	total := &StatsGroup{}
+	byList := make(map[string]*StatsGroup)
+	byPrio := make(map[int]*StatsGroup)
+	for _, l := range lists {
+		for _, t := range l.Items {
+			groups := []*StatsGroup{total, group(byList, l.Name, l.Name), group(byPrio, t.Priority, strconv.Itoa(t.Priority))}
+			created := period(t.Created)
+			completed := -1
+			if t.Done {
+				completed = period(t.DoneOn)
+			}
+			if created >= 0 {
+				periods[created].Created++
+			}
+			if completed >= 0 {
+				periods[completed].Completed++
+			}
+			for _, g := range groups {
+				if created >= 0 {
+					g.Created++
+				}
+				if completed >= 0 {
+					g.Completed++
+					g.completion += t.DoneOn.Sub(t.Created)
+					if late(*t) {
+						g.CompletedLate++
+					}
+				}
+				if !t.Done {
+					g.Open++
+					if t.IsOverdueAt(now) {
+						g.Overdue++
+					}
+				}
+			}
+		}
+	}
+
+	stats := Stats{
+		From:       from,
+		To:         to,
+		Interval:   interval,
+		Periods:    periods,
+		Total:      total.done(),
+		Lists:      make([]StatsGroup, 0, len(byList)),
+		Priorities: make([]StatsGroup, 0, len(byPrio)),
+	}
+	for _, g := range byList {
+		stats.Lists = append(stats.Lists, g.done())
+	}
+	slices.SortFunc(stats.Lists, func(a, b StatsGroup) int { return cmp.Compare(a.Key, b.Key) })
+	for _, g := range byPrio {
+		stats.Priorities = append(stats.Priorities, g.done())
+	}
+	slices.SortFunc(stats.Priorities, func(a, b StatsGroup) int {
+		pa, _ := strconv.Atoi(a.Key)
+		pb, _ := strconv.Atoi(b.Key)
+		return cmp.Compare(pa, pb)
+	})
+	return stats, nil
+}
+
+// group returns the group of key, adding it if it's missing.
+func group[K comparable](groups map[K]*StatsGroup, key K, name string) *StatsGroup {
+	g, ok := groups[key]
+	if !ok {
+		g = &StatsGroup{Key: name}
+		groups[key] = g
+	}
+	return g
+}
+
+// done returns the group with the average time to complete its tasks.
+func (g *StatsGroup) done() StatsGroup {
+	res := *g
+	res.completion = 0
+	if g.Completed > 0 {
+		res.AvgCompletionHours = math.Round((g.completion/time.Duration(g.Completed)).Hours()*100) / 100
+	}
+	return res
+}
+
+// late reports whether a task was done after its due date, all-day tasks after the end of the day.
+func late(t core.Task) bool {
+	if !t.Done || !t.HasDueDate() {
+		return false
+	}
+	if t.AllDay {
+		return !t.DoneOn.Before(t.Due.AddDate(0, 0, 1))
+	}
+	return t.DoneOn.After(t.Due)
+}
+
+// statsHeader are the columns of WriteCSV.
+var statsHeader = []string{"kind", "key", "created", "completed", "completed_late", "open", "overdue", "avg_completion_hours"}
+
+// WriteCSV writes the statistics as CSV: a row per period, with the start of the period as key, followed by the
+// total, the lists and the priorities. The kind column tells them apart.
+func (s Stats) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(statsHeader); err != nil {
+		return err
+	}
+	for _, p := range s.Periods {
+		if err := cw.Write([]string{s.Interval, p.Start, strconv.Itoa(p.Created), strconv.Itoa(p.Completed), "", "", "", ""}); err != nil {
+			return err
+		}
+	}
+	rows := []struct {
+		kind   string
+		groups []StatsGroup
+	}{{"total", []StatsGroup{s.Total}}, {"list", s.Lists}, {"priority", s.Priorities}}
+	for _, row := range rows {
+		for _, g := range row.groups {
+			record := []string{
+				row.kind, g.Key, strconv.Itoa(g.Created), strconv.Itoa(g.Completed), strconv.Itoa(g.CompletedLate),
+				strconv.Itoa(g.Open), strconv.Itoa(g.Overdue), strconv.FormatFloat(g.AvgCompletionHours, 'f', 2, 64),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,109 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+func TestNewStats(t *testing.T) {
+	day := func(d, h int) time.Time { return time.Date(2026, 10, d, h, 0, 0, 0, time.UTC) }
+	lists := []*core.List{
+		{Name: "Work", Items: []*core.Task{
+			{ID: 1, Priority: core.PrioHigh, Created: day(12, 10), Done: true, DoneOn: day(13, 10), DueType: core.DueOn, Due: day(12, 18)},
+			{ID: 2, Created: day(14, 9), DueType: core.DueBy, Due: day(15, 0), AllDay: true},
+			{ID: 3, Created: day(10, 12), Done: true, DoneOn: day(16, 12)},
+		}},
+		{Name: "Home", Items: []*core.Task{
+			{ID: 4, Priority: core.PrioLow, Created: day(18, 8)},
+			{ID: 5, Created: day(19, 0)},
+		}},
+	}
+	now := day(18, 12)
+
+	s, err := NewStats(lists, day(12, 0), day(19, 0), "", now)
+	if err != nil {
+		t.Fatalf("NewStats() error = %v", err)
+	}
+	want := StatsGroup{Created: 3, Completed: 2, CompletedLate: 1, Open: 3, Overdue: 1, AvgCompletionHours: 84}
+	if s.Total != want {
+		t.Errorf("total = %+v, want %+v", s.Total, want)
+	}
+	if len(s.Periods) != 7 {
+		t.Fatalf("periods = %+v, want 7 days", s.Periods)
+	}
+	for i, want := range []StatsPeriod{
+		{"2026-10-12", 1, 0}, {"2026-10-13", 0, 1}, {"2026-10-14", 1, 0}, {"2026-10-15", 0, 0},
+		{"2026-10-16", 0, 1}, {"2026-10-17", 0, 0}, {"2026-10-18", 1, 0},
+	} {
+		if s.Periods[i] != want {
+			t.Errorf("period %d = %+v, want %+v", i, s.Periods[i], want)
+		}
+	}
+	wantLists := []StatsGroup{
+		{Key: "Home", Created: 1, Open: 2},
+		{Key: "Work", Created: 2, Completed: 2, CompletedLate: 1, Open: 1, Overdue: 1, AvgCompletionHours: 84},
+	}
+	for i, want := range wantLists {
+		if len(s.Lists) != len(wantLists) || s.Lists[i] != want {
+			t.Errorf("lists = %+v, want %+v", s.Lists, wantLists)
+			break
+		}
+	}
+	wantPrios := []StatsGroup{
+		{Key: "-1", Created: 1, Open: 1},
+		{Key: "0", Created: 1, Completed: 1, Open: 2, Overdue: 1, AvgCompletionHours: 144},
+		{Key: "1", Created: 1, Completed: 1, CompletedLate: 1, AvgCompletionHours: 24},
+	}
+	for i, want := range wantPrios {
+		if len(s.Priorities) != len(wantPrios) || s.Priorities[i] != want {
+			t.Errorf("priorities = %+v, want %+v", s.Priorities, wantPrios)
+			break
+		}
+	}
+
+	var buf bytes.Buffer
+	if err = s.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 14 || lines[1] != "day,2026-10-12,1,0,,,," || lines[8] != "total,,3,2,1,3,1,84.00" {
+		t.Errorf("CSV = %s", buf.String())
+	}
+
+	// weeks start on Monday
+	s, err = NewStats(lists, day(14, 0), day(19, 0), IntervalWeek, now)
+	if err != nil {
+		t.Fatalf("NewStats() error = %v", err)
+	}
+	if len(s.Periods) != 1 || s.Periods[0] != (StatsPeriod{"2026-10-12", 3, 2}) {
+		t.Errorf("weeks = %+v, want one from 2026-10-12", s.Periods)
+	}
+}
+
+func TestNewStats_Invalid(t *testing.T) {
+	from := time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		to       time.Time
+		interval string
+		field    string
+	}{
+		{"Interval", from.AddDate(0, 0, 1), "month", "interval"},
+		{"Empty range", from, IntervalDay, "to"},
+		{"Too many periods", from.AddDate(10, 0, 0), IntervalDay, "to"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewStats(nil, from, tt.to, tt.interval, from)
+			var fe *FieldError
+			if !errors.As(err, &fe) || fe.Field != tt.field {
+				t.Errorf("NewStats() error = %v, want field error of %s", err, tt.field)
+			}
+		})
+	}
+}
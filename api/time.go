@@ -0,0 +1,172 @@
+package api
+
+import (
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// TimeEntryResponse is time a user spent on a task.
+type TimeEntryResponse struct {
+	ID    int       `json:"id"`
+	User  string    `json:"user,omitempty"`
+	Start time.Time `json:"start"`
+	// End is left out while the timer is running, Duration counts until now then.
+	End      *time.Time `json:"end,omitempty"`
+	Duration string     `json:"duration"`
+	Running  bool       `json:"running"`
+	Note     string     `json:"note,omitempty"`
+}
+
+// TimeEntryAdd is used to add time spent on a task without a timer.
+type TimeEntryAdd struct {
+	// Start defaults to Duration before now.
+	Start time.Time `json:"start,omitempty"`
+	// Duration is e.g. "1h30m".
+	Duration string `json:"duration"`
+	Note     string `json:"note,omitempty"`
+}
+
+// MarshalJSON leaves out a zero start time, see TimeEntryAdd.Start.
+func (e TimeEntryAdd) MarshalJSON() ([]byte, error) {
+	var start string
+	if !e.Start.IsZero() {
+		start = e.Start.Format(time.RFC3339)
+	}
+	type Alias TimeEntryAdd
+	return json.Marshal(&struct {
+		Alias
+		Start string `json:"start,omitempty"`
+	}{
+		Alias: Alias(e),
+		Start: start,
+	})
+}
+
+func FromTimeEntry(e core.TimeEntry, now time.Time) TimeEntryResponse {
+	resp := TimeEntryResponse{
+		ID:       e.ID,
+		User:     e.User,
+		Start:    e.Start,
+		Duration: e.Duration(now).Round(time.Second).String(),
+		Running:  e.Running(),
+		Note:     e.Note,
+	}
+	if !e.Running() {
+		resp.End = &e.End
+	}
+	return resp
+}
+
+// Groups of time reports.
+const (
+	GroupList = "list"
+	GroupTag  = "tag"
+	GroupDay  = "day"
+)
+
+// TimeReport sums up the time tracked on tasks from From until To, excluding To, e.g. for billing.
+type TimeReport struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+	// Group is one of list, tag or day.
+	Group string          `json:"group"`
+	Rows  []TimeReportRow `json:"rows"`
+	Total TimeReportRow   `json:"total"`
+}
+
+// TimeReportRow is the time tracked for a list, a tag or on a day, sorted by Key. The row of the tasks without tags
+// has an empty Key.
+type TimeReportRow struct {
+	Key      string  `json:"key"`
+	Duration string  `json:"duration"`
+	Hours    float64 `json:"hours"`
+}
+
+// NewTimeReport sums up the time tracked on the tasks of the lists from from until to, grouped by group. Only the
+// part of an entry within the range counts, running timers count until now. Entries are added to the day they start
+// on in the location of from, and to every tag of their task, so the rows per tag may add up to more than the total.
+func NewTimeReport(lists []*core.List, from, to time.Time, group string, now time.Time) (TimeReport, error) {
+	if group == "" {
+		group = GroupList
+	}
+	if group != GroupList && group != GroupTag && group != GroupDay {
+		return TimeReport{}, &FieldError{Field: "group", Message: fmt.Sprintf("invalid group %q, must be list, tag or day", group)}
+	}
+	if !to.After(from) {
+		return TimeReport{}, &FieldError{Field: "to", Message: "to must be after from"}
+	}
+
+	sums := make(map[string]time.Duration)
+	var total time.Duration
+	for _, l := range lists {
+		for _, t := range l.Items {
+			for _, e := range t.TimeEntries {
+				start, end := e.Start, e.End
+				if e.Running() {
+					end = now
+				}
+				if start.Before(from) {
+					start = from
+				}
+				if end.After(to) {
+					end = to
+				}
+				d := end.Sub(start)
+				if d <= 0 {
+					continue
+				}
+				total += d
+				keys := []string{l.Name}
+				switch group {
+				case GroupTag:
+					keys = t.Tags
+					if len(keys) == 0 {
+						keys = []string{""}
+					}
+				case GroupDay:
+					keys = []string{start.In(from.Location()).Format("2006-01-02")}
+				}
+				for _, key := range keys {
+					sums[key] += d
+				}
+			}
+		}
+	}
+
+	report := TimeReport{From: from, To: to, Group: group, Rows: make([]TimeReportRow, 0, len(sums))}
+	for key, d := range sums {
+		report.Rows = append(report.Rows, newTimeReportRow(key, d))
+	}
+	slices.SortFunc(report.Rows, func(a, b TimeReportRow) int { return cmp.Compare(a.Key, b.Key) })
+	report.Total = newTimeReportRow("total", total)
+	return report, nil
+}
+
+func newTimeReportRow(key string, d time.Duration) TimeReportRow {
+	d = d.Round(time.Second)
+	return TimeReportRow{Key: key, Duration: d.String(), Hours: math.Round(d.Hours()*100) / 100}
+}
+
+// WriteCSV writes the rows of the report and the total as CSV with the columns group, duration and hours.
+func (r TimeReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{r.Group, "duration", "hours"}); err != nil {
+		return err
+	}
+	for _, row := range append(slices.Clone(r.Rows), r.Total) {
+		if err := cw.Write([]string{row.Key, row.Duration, strconv.FormatFloat(row.Hours, 'f', 2, 64)}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
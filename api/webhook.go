@@ -0,0 +1,38 @@
+package api
+
+import "time"
+
+// WebhookAdd is used to register a webhook.
+type WebhookAdd struct {
+	URL string `json:"url"`
+	// Secret is the key of the HMAC-SHA256 signature of the payloads, a random secret is generated if it is empty.
+	Secret string `json:"secret,omitempty"`
+	// Events are the event types to send, e.g. task.completed, all events if empty.
+	Events []string `json:"events,omitempty"`
+	// RuleSets only send task events for tasks matching them, like the rules of filtered lists. List events are
+	// always sent.
+	RuleSets [][]ExportRule `json:"rule_sets,omitempty"`
+}
+
+// WebhookResponse describes a webhook without its secret, which is only returned when it is registered.
+type WebhookResponse struct {
+	ID       int            `json:"id"`
+	URL      string         `json:"url"`
+	Events   []string       `json:"events"`
+	RuleSets [][]ExportRule `json:"rule_sets,omitempty"`
+	Created  time.Time      `json:"created"`
+}
+
+// WebhookDelivery is a delivery of an event to a webhook, including its retries.
+type WebhookDelivery struct {
+	ID    int    `json:"id"`
+	Event string `json:"event"`
+	// Time is the time of the event.
+	Time     time.Time `json:"time"`
+	Attempts int       `json:"attempts"`
+	// State is pending while the delivery is retried, delivered or failed.
+	State string `json:"state"`
+	// StatusCode is the HTTP status of the last attempt, left out if there was no response.
+	StatusCode int    `json:"status_code,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
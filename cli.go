@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/client"
+	"github.com/jniewt/gotodo/internal/remote"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/rest"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+// backend selects what the client commands operate on: the running server if there is one, the local database
+// otherwise.
+type backend struct {
+	server string
+	token  string
+	db     string
+	local  bool
+}
+
+func (b *backend) register(flags *flag.FlagSet) {
+	server := os.Getenv("GOTASKS_SERVER")
+	if server == "" {
+		server = "http://localhost:8080"
+	}
+	flags.StringVar(&b.server, "server", server, "URL of the server, defaults to $GOTASKS_SERVER")
+	flags.StringVar(&b.token, "token", os.Getenv("GOTASKS_TOKEN"), "API token for the server, defaults to $GOTASKS_TOKEN")
+	flags.StringVar(&b.db, "db", defaultDBPath(), "path to the database, used if the server isn't running")
+	flags.BoolVar(&b.local, "local", false, "use the database even if the server is running")
+}
+
+// open returns an organiser for the server or, if it refuses the connection, for the local database. Using the database
+// while a server is running is unsafe, as the server keeps its own copy of the data.
+func (b *backend) open() (rest.Organiser, error) {
+	if b.local {
+		return repository.NewRepository(storage.NewFile(b.db)), nil
+	}
+
+	r := remote.New(strings.TrimSuffix(b.server, "/"), b.token)
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	err := r.Ping(ctx)
+	if err == nil {
+		return r, nil
+	}
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) && apiErr.Code == api.CodeUnauthenticated {
+		return nil, fmt.Errorf("server at %s: %w, set -token or $GOTASKS_TOKEN", b.server, err)
+	}
+	// only fall back if nothing listens, a slow server may still be running and would overwrite the changes
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return nil, fmt.Errorf("server at %s: %w, use -local to use the database anyway", b.server, err)
+	}
+
+	return repository.NewRepository(storage.NewFile(b.db)), nil
+}
+
+// lastErr returns the error of the last organiser call that can't return one itself, e.g. Lists on a remote server.
+func lastErr(o rest.Organiser) error {
+	if e, ok := o.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+// parseArgs parses flags that may appear before, between or after the positional arguments, which it returns.
+func parseArgs(flags *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		_ = flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
@@ -0,0 +1,719 @@
+// Package client is a Go client for the gotodo REST API.
+//
+//	c := client.New("http://localhost:8080")
+//	lists, err := c.ListAll(ctx)
+//
+// Failed requests return an *Error with the status code and the error code and message sent by the server. Idempotent requests are
+// retried on network errors and 5xx responses.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+// Client calls the REST API of a gotodo server. The exported fields may be changed before the first request.
+type Client struct {
+	// BaseURL is the URL of the server without the /api/v1 path, e.g. "http://localhost:8080".
+	BaseURL string
+	// Token is the API token sent with every request, it can be created with CreateToken or "gotasks token create".
+	Token      string
+	HTTPClient *http.Client
+	// MaxRetries is the number of retries of idempotent requests (GET, PUT, DELETE) that failed with a network error
+	// or a 5xx status.
+	MaxRetries int
+	// RetryBackoff is the wait before the first retry, it doubles with every further retry.
+	RetryBackoff time.Duration
+}
+
+// New returns a client for the server at baseURL with sensible defaults.
+func New(baseURL string) *Client {
+	return &Client{
+		BaseURL:      strings.TrimSuffix(baseURL, "/"),
+		HTTPClient:   &http.Client{Timeout: 30 * time.Second},
+		MaxRetries:   2,
+		RetryBackoff: 200 * time.Millisecond,
+	}
+}
+
+// Error is returned for requests the server answered with an error status.
+type Error struct {
+	StatusCode int
+	// Code identifies the kind of error, e.g. api.CodeListNotFound. It is empty if the response had no error body.
+	Code    api.ErrorCode
+	Message string
+	// Details lists the invalid fields of the request for api.CodeValidationFailed.
+	Details []api.FieldError
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("gotodo: %s (%d %s)", e.Message, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// Lists contains all lists and filtered lists with their tasks.
+type Lists struct {
+	// Groups are the groups of the user with the names of their lists.
+	Groups        []api.GroupResponse `json:"groups"`
+	Lists         []api.ListResponse  `json:"lists"`
+	FilteredLists []api.ListResponse  `json:"filtered_lists"`
+}
+
+// ListAll returns all lists and filtered lists.
+func (c *Client) ListAll(ctx context.Context) (Lists, error) {
+	var resp Lists
+	err := c.do(ctx, http.MethodGet, "/api/v1/list", nil, &resp)
+	return resp, err
+}
+
+// Groups returns the groups of lists of the user in order.
+func (c *Client) Groups(ctx context.Context) ([]api.GroupResponse, error) {
+	var resp struct {
+		Groups []api.GroupResponse `json:"groups"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/groups", nil, &resp)
+	return resp.Groups, err
+}
+
+// AddGroup adds a group of lists, its lists are moved out of other groups.
+func (c *Client) AddGroup(ctx context.Context, group api.GroupAdd) (api.GroupResponse, error) {
+	return c.sendGroup(ctx, http.MethodPost, "/api/v1/groups", group)
+}
+
+// EditGroup changes the colour and the lists of a group, renaming is not supported.
+func (c *Client) EditGroup(ctx context.Context, name string, group api.GroupAdd) (api.GroupResponse, error) {
+	return c.sendGroup(ctx, http.MethodPut, "/api/v1/groups/"+url.PathEscape(name), group)
+}
+
+func (c *Client) sendGroup(ctx context.Context, method, path string, group api.GroupAdd) (api.GroupResponse, error) {
+	var resp struct {
+		Group api.GroupResponse `json:"group"`
+	}
+	err := c.do(ctx, method, path, group, &resp)
+	return resp.Group, err
+}
+
+// DeleteGroup deletes a group, its lists are kept.
+func (c *Client) DeleteGroup(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/groups/"+url.PathEscape(name), nil, nil)
+}
+
+// OrderGroups changes the order of the groups, groups left out follow the given ones.
+func (c *Client) OrderGroups(ctx context.Context, names []string) ([]api.GroupResponse, error) {
+	var resp struct {
+		Groups []api.GroupResponse `json:"groups"`
+	}
+	err := c.do(ctx, http.MethodPut, "/api/v1/groups", api.GroupOrder{Groups: names}, &resp)
+	return resp.Groups, err
+}
+
+// GetList returns a list or filtered list and whether it's a filtered list.
+func (c *Client) GetList(ctx context.Context, name string) (api.ListResponse, bool, error) {
+	var resp struct {
+		List     api.ListResponse `json:"list"`
+		Filtered bool             `json:"filtered"`
+	}
+	err := c.do(ctx, http.MethodGet, listPath(name), nil, &resp)
+	return resp.List, resp.Filtered, err
+}
+
+// SetStatuses changes the workflow of a list, e.g. to "todo", "in progress", "review" and "done".
+func (c *Client) SetStatuses(ctx context.Context, name string, statuses []string) (api.ListResponse, error) {
+	return c.sendList(ctx, http.MethodPut, listPath(name)+"/statuses", api.ListStatuses{Statuses: statuses})
+}
+
+// SetArchiveAfter sets after how many days done tasks of a list are archived, 0 turns archiving off.
+func (c *Client) SetArchiveAfter(ctx context.Context, name string, days int) (api.ListResponse, error) {
+	return c.sendList(ctx, http.MethodPut, listPath(name)+"/archive", api.ListArchive{ArchiveAfter: days})
+}
+
+// Board returns the tasks of a list grouped by status.
+func (c *Client) Board(ctx context.Context, name string) (api.BoardResponse, error) {
+	var resp struct {
+		Board api.BoardResponse `json:"board"`
+	}
+	err := c.do(ctx, http.MethodGet, listPath(name)+"/board", nil, &resp)
+	return resp.Board, err
+}
+
+// GetListMarkdown returns a list or filtered list as Markdown checklist.
+func (c *Client) GetListMarkdown(ctx context.Context, name string) (string, error) {
+	var buf bytes.Buffer
+	err := c.do(ctx, http.MethodGet, listPath(name)+".md", nil, &buf)
+	return buf.String(), err
+}
+
+// AddList creates a list.
+func (c *Client) AddList(ctx context.Context, list api.ListAdd) (api.ListResponse, error) {
+	return c.sendList(ctx, http.MethodPost, "/api/v1/list", list)
+}
+
+// EditList changes a list, renaming is not supported.
+func (c *Client) EditList(ctx context.Context, name string, list api.ListAdd) (api.ListResponse, error) {
+	return c.sendList(ctx, http.MethodPatch, listPath(name), list)
+}
+
+func (c *Client) sendList(ctx context.Context, method, path string, list interface{}) (api.ListResponse, error) {
+	var resp struct {
+		List api.ListResponse `json:"list"`
+	}
+	err := c.do(ctx, method, path, list, &resp)
+	return resp.List, err
+}
+
+// DeleteList deletes a list with all its tasks.
+func (c *Client) DeleteList(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, listPath(name), nil, nil)
+}
+
+// ShareList gives a user a role (viewer, editor or owner) in a list or changes it, only owners of the list may call it.
+func (c *Client) ShareList(ctx context.Context, name, user, role string) (api.ListResponse, error) {
+	return c.sendList(ctx, http.MethodPut, memberPath(name, user), api.ListShare{Role: role})
+}
+
+// UnshareList removes a user from a shared list. Owners may remove anyone, members only themselves.
+func (c *Client) UnshareList(ctx context.Context, name, user string) error {
+	return c.do(ctx, http.MethodDelete, memberPath(name, user), nil, nil)
+}
+
+// ImportMarkdown adds all checklist items of a Markdown document as tasks to a list.
+func (c *Client) ImportMarkdown(ctx context.Context, list, markdown string) ([]api.TaskResponse, error) {
+	var resp struct {
+		Tasks []api.TaskResponse `json:"tasks"`
+	}
+	err := c.do(ctx, http.MethodPost, listPath(list)+"/import-md", rawBody{"text/markdown", []byte(markdown)}, &resp)
+	return resp.Tasks, err
+}
+
+// AddTask adds a task to a list.
+func (c *Client) AddTask(ctx context.Context, list string, task api.TaskAdd) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodPost, listPath(list), task)
+}
+
+// QuickAdd adds a task from a one-line description like "Call client tomorrow 9am !high #Work". list is used if the
+// description doesn't name a list.
+func (c *Client) QuickAdd(ctx context.Context, text, list string) (api.TaskResponse, error) {
+	req := struct {
+		Text string `json:"text"`
+		List string `json:"list,omitempty"`
+	}{Text: text, List: list}
+	return c.sendTask(ctx, http.MethodPost, "/api/v1/quickadd", req)
+}
+
+// GetTask returns a task.
+func (c *Client) GetTask(ctx context.Context, id int) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodGet, itemPath(id), nil)
+}
+
+// UpdateTask changes all fields of a task, see api.NewTaskChange.
+func (c *Client) UpdateTask(ctx context.Context, id int, change api.TaskChange) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodPatch, itemPath(id), change)
+}
+
+// SetDone marks a task as done or not done without changing anything else.
+func (c *Client) SetDone(ctx context.Context, id int, done bool) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodPatch, itemPath(id), map[string]bool{"done": done})
+}
+
+// SnoozeTask hides a task from lists until a time like "1h", "tomorrow", "next week" or in RFC 3339 format, resolved in
+// the server's time zone. An empty time shows the task again.
+func (c *Client) SnoozeTask(ctx context.Context, id int, until string) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodPost, itemPath(id)+"/snooze", api.TaskSnooze{Until: until})
+}
+
+// TaskHistory returns the changes of a task, oldest first.
+func (c *Client) TaskHistory(ctx context.Context, id int) ([]api.TaskEvent, error) {
+	var resp struct {
+		History []api.TaskEvent `json:"history"`
+	}
+	err := c.do(ctx, http.MethodGet, itemPath(id)+"/history", nil, &resp)
+	return resp.History, err
+}
+
+// Comments returns the comments on a task, oldest first.
+func (c *Client) Comments(ctx context.Context, taskID int) ([]api.CommentResponse, error) {
+	var resp struct {
+		Comments []api.CommentResponse `json:"comments"`
+	}
+	err := c.do(ctx, http.MethodGet, itemPath(taskID)+"/comments", nil, &resp)
+	return resp.Comments, err
+}
+
+// AddComment comments on a task.
+func (c *Client) AddComment(ctx context.Context, taskID int, text string) (api.CommentResponse, error) {
+	return c.sendComment(ctx, http.MethodPost, itemPath(taskID)+"/comments", text)
+}
+
+// EditComment changes the text of an own comment.
+func (c *Client) EditComment(ctx context.Context, taskID, commentID int, text string) (api.CommentResponse, error) {
+	return c.sendComment(ctx, http.MethodPatch, commentPath(taskID, commentID), text)
+}
+
+// DeleteComment deletes an own comment.
+func (c *Client) DeleteComment(ctx context.Context, taskID, commentID int) error {
+	return c.do(ctx, http.MethodDelete, commentPath(taskID, commentID), nil, nil)
+}
+
+func (c *Client) sendComment(ctx context.Context, method, path, text string) (api.CommentResponse, error) {
+	var resp struct {
+		Comment api.CommentResponse `json:"comment"`
+	}
+	err := c.do(ctx, method, path, api.CommentAdd{Text: text}, &resp)
+	return resp.Comment, err
+}
+
+// StartTimer starts tracking time on a task, it fails if another timer of the user is running.
+func (c *Client) StartTimer(ctx context.Context, taskID int) (api.TimeEntryResponse, error) {
+	return c.sendTimeEntry(ctx, itemPath(taskID)+"/timer/start", nil)
+}
+
+// StopTimer stops the running timer of the user on a task.
+func (c *Client) StopTimer(ctx context.Context, taskID int) (api.TimeEntryResponse, error) {
+	return c.sendTimeEntry(ctx, itemPath(taskID)+"/timer/stop", nil)
+}
+
+// TimeEntries returns the time tracked on a task, oldest first.
+func (c *Client) TimeEntries(ctx context.Context, taskID int) ([]api.TimeEntryResponse, error) {
+	var resp struct {
+		Entries []api.TimeEntryResponse `json:"entries"`
+	}
+	err := c.do(ctx, http.MethodGet, itemPath(taskID)+"/time", nil, &resp)
+	return resp.Entries, err
+}
+
+// AddTimeEntry adds time spent on a task without a timer.
+func (c *Client) AddTimeEntry(ctx context.Context, taskID int, entry api.TimeEntryAdd) (api.TimeEntryResponse, error) {
+	return c.sendTimeEntry(ctx, itemPath(taskID)+"/time", entry)
+}
+
+// DeleteTimeEntry deletes an own time entry.
+func (c *Client) DeleteTimeEntry(ctx context.Context, taskID, entryID int) error {
+	return c.do(ctx, http.MethodDelete, itemPath(taskID)+"/time/"+strconv.Itoa(entryID), nil, nil)
+}
+
+func (c *Client) sendTimeEntry(ctx context.Context, path string, body interface{}) (api.TimeEntryResponse, error) {
+	var resp struct {
+		Entry api.TimeEntryResponse `json:"entry"`
+	}
+	err := c.do(ctx, http.MethodPost, path, body, &resp)
+	return resp.Entry, err
+}
+
+// TimeReportOptions select the time summed up by TimeReport, the zero value reports the current month per list.
+type TimeReportOptions struct {
+	// From and To are the first and last day of the report.
+	From, To time.Time
+	// Group is one of api.GroupList, api.GroupTag or api.GroupDay.
+	Group string
+}
+
+// TimeReport sums up the time tracked on the tasks the user can see.
+func (c *Client) TimeReport(ctx context.Context, opts TimeReportOptions) (api.TimeReport, error) {
+	q := url.Values{}
+	if !opts.From.IsZero() {
+		q.Set("from", opts.From.Format("2006-01-02"))
+	}
+	if !opts.To.IsZero() {
+		q.Set("to", opts.To.Format("2006-01-02"))
+	}
+	if opts.Group != "" {
+		q.Set("group", opts.Group)
+	}
+	path := "/api/v1/time/report"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var report api.TimeReport
+	err := c.do(ctx, http.MethodGet, path, nil, &report)
+	return report, err
+}
+
+// ArchiveOptions select the archived tasks returned by Archive, the zero value returns all of them.
+type ArchiveOptions struct {
+	// List limits the tasks to those archived from a list.
+	List string
+	// From and To are the first and last day the tasks were done on.
+	From, To time.Time
+}
+
+// Archive returns the archived tasks the user can see, most recently done first.
+func (c *Client) Archive(ctx context.Context, opts ArchiveOptions) ([]api.TaskResponse, error) {
+	q := url.Values{}
+	if opts.List != "" {
+		q.Set("list", opts.List)
+	}
+	if !opts.From.IsZero() {
+		q.Set("from", opts.From.Format("2006-01-02"))
+	}
+	if !opts.To.IsZero() {
+		q.Set("to", opts.To.Format("2006-01-02"))
+	}
+	path := "/api/v1/archive"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var resp struct {
+		Tasks []api.TaskResponse `json:"tasks"`
+	}
+	err := c.do(ctx, http.MethodGet, path, nil, &resp)
+	return resp.Tasks, err
+}
+
+// RestoreTask moves an archived task back to its list, it is open again.
+func (c *Client) RestoreTask(ctx context.Context, id int) (api.TaskResponse, error) {
+	return c.sendTask(ctx, http.MethodPost, "/api/v1/archive/"+strconv.Itoa(id)+"/restore", nil)
+}
+
+// StatsOptions select the tasks counted by Stats, the zero value counts the last 30 days per day.
+type StatsOptions struct {
+	// From and To are the first and last day of the statistics.
+	From, To time.Time
+	// Interval is api.IntervalDay or api.IntervalWeek.
+	Interval string
+}
+
+// Stats returns the productivity statistics of the tasks the user can see.
+func (c *Client) Stats(ctx context.Context, opts StatsOptions) (api.Stats, error) {
+	q := url.Values{}
+	if !opts.From.IsZero() {
+		q.Set("from", opts.From.Format("2006-01-02"))
+	}
+	if !opts.To.IsZero() {
+		q.Set("to", opts.To.Format("2006-01-02"))
+	}
+	if opts.Interval != "" {
+		q.Set("interval", opts.Interval)
+	}
+	path := "/api/v1/stats"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var stats api.Stats
+	err := c.do(ctx, http.MethodGet, path, nil, &stats)
+	return stats, err
+}
+
+// StartPomodoro starts a Pomodoro session on a task, the phase changes are sent on the event stream.
+func (c *Client) StartPomodoro(ctx context.Context, taskID int, start api.PomodoroStart) (api.PomodoroSession, error) {
+	var resp struct {
+		Pomodoro api.PomodoroSession `json:"pomodoro"`
+	}
+	err := c.do(ctx, http.MethodPost, itemPath(taskID)+"/pomodoro", start, &resp)
+	return resp.Pomodoro, err
+}
+
+// Pomodoro returns the running Pomodoro session of the user, nil if there is none.
+func (c *Client) Pomodoro(ctx context.Context) (*api.PomodoroSession, error) {
+	var resp struct {
+		Pomodoro *api.PomodoroSession `json:"pomodoro"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/pomodoro", nil, &resp)
+	return resp.Pomodoro, err
+}
+
+// StopPomodoro stops the running Pomodoro session of the user.
+func (c *Client) StopPomodoro(ctx context.Context) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/pomodoro", nil, nil)
+}
+
+// PomodoroStats counts the completed Pomodoro sessions of the user per day from the first until the last day. Zero
+// days select the last 7 days.
+func (c *Client) PomodoroStats(ctx context.Context, from, to time.Time) (api.PomodoroStats, error) {
+	q := url.Values{}
+	if !from.IsZero() {
+		q.Set("from", from.Format("2006-01-02"))
+	}
+	if !to.IsZero() {
+		q.Set("to", to.Format("2006-01-02"))
+	}
+	path := "/api/v1/pomodoro/stats"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var stats api.PomodoroStats
+	err := c.do(ctx, http.MethodGet, path, nil, &stats)
+	return stats, err
+}
+
+// DeleteTask deletes a task.
+func (c *Client) DeleteTask(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, itemPath(id), nil, nil)
+}
+
+func (c *Client) sendTask(ctx context.Context, method, path string, body interface{}) (api.TaskResponse, error) {
+	var resp struct {
+		Task api.TaskResponse `json:"task"`
+	}
+	err := c.do(ctx, method, path, body, &resp)
+	return resp.Task, err
+}
+
+// Export returns all lists, tasks and filtered lists.
+func (c *Client) Export(ctx context.Context) (api.Export, error) {
+	var resp api.Export
+	err := c.do(ctx, http.MethodGet, "/api/v1/export?format=json", nil, &resp)
+	return resp, err
+}
+
+// ExportCSV writes all lists, tasks and filtered lists as CSV to w.
+func (c *Client) ExportCSV(ctx context.Context, w io.Writer) error {
+	var buf bytes.Buffer
+	if err := c.do(ctx, http.MethodGet, "/api/v1/export?format=csv", nil, &buf); err != nil {
+		return err
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// ImportOptions control an import, the zero value imports with api.MergeSkip.
+type ImportOptions struct {
+	Strategy api.MergeStrategy
+	// DryRun only returns the report without importing anything.
+	DryRun bool
+}
+
+// Import merges an export into the server's data.
+func (c *Client) Import(ctx context.Context, export api.Export, opts ImportOptions) (api.ImportReport, error) {
+	q := url.Values{}
+	if opts.Strategy != "" {
+		q.Set("strategy", string(opts.Strategy))
+	}
+	if opts.DryRun {
+		q.Set("dry_run", "true")
+	}
+	path := "/api/v1/import"
+	if len(q) > 0 {
+		path += "?" + q.Encode()
+	}
+	var report api.ImportReport
+	err := c.do(ctx, http.MethodPost, path, export, &report)
+	return report, err
+}
+
+// Tokens returns the API tokens of the authenticated user.
+func (c *Client) Tokens(ctx context.Context) ([]api.TokenResponse, error) {
+	var resp struct {
+		Tokens []api.TokenResponse `json:"tokens"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/tokens", nil, &resp)
+	return resp.Tokens, err
+}
+
+// CreateToken creates an API token for the authenticated user and returns it together with the secret to be used as
+// Token, which can't be retrieved again.
+func (c *Client) CreateToken(ctx context.Context, name string) (api.TokenResponse, string, error) {
+	var resp struct {
+		Token  api.TokenResponse `json:"token"`
+		Secret string            `json:"secret"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/v1/tokens", api.TokenAdd{Name: name}, &resp)
+	return resp.Token, resp.Secret, err
+}
+
+// RevokeToken deletes an API token of the authenticated user.
+func (c *Client) RevokeToken(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, "/api/v1/tokens/"+strconv.Itoa(id), nil, nil)
+}
+
+// Me returns the authenticated user.
+func (c *Client) Me(ctx context.Context) (api.UserResponse, error) {
+	return c.sendUser(ctx, http.MethodGet, "/api/v1/me", nil)
+}
+
+// Users returns all users, only admins may call it.
+func (c *Client) Users(ctx context.Context) ([]api.UserResponse, error) {
+	var resp struct {
+		Users []api.UserResponse `json:"users"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/users", nil, &resp)
+	return resp.Users, err
+}
+
+// AddUser creates a user, only admins may call it.
+func (c *Client) AddUser(ctx context.Context, user api.UserAdd) (api.UserResponse, error) {
+	return c.sendUser(ctx, http.MethodPost, "/api/v1/users", user)
+}
+
+// UpdateUser changes the password or admin role of a user, only admins may call it.
+func (c *Client) UpdateUser(ctx context.Context, name string, change api.UserChange) (api.UserResponse, error) {
+	return c.sendUser(ctx, http.MethodPatch, userPath(name), change)
+}
+
+// DeleteUser deletes a user with their API tokens, only admins may call it.
+func (c *Client) DeleteUser(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, userPath(name), nil, nil)
+}
+
+func (c *Client) sendUser(ctx context.Context, method, path string, body interface{}) (api.UserResponse, error) {
+	var resp struct {
+		User api.UserResponse `json:"user"`
+	}
+	err := c.do(ctx, method, path, body, &resp)
+	return resp.User, err
+}
+
+// Webhooks returns the webhooks of the authenticated user.
+func (c *Client) Webhooks(ctx context.Context) ([]api.WebhookResponse, error) {
+	var resp struct {
+		Webhooks []api.WebhookResponse `json:"webhooks"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/webhooks", nil, &resp)
+	return resp.Webhooks, err
+}
+
+// AddWebhook registers a webhook and returns it together with the secret signing its payloads, which can't be
+// retrieved again.
+func (c *Client) AddWebhook(ctx context.Context, hook api.WebhookAdd) (api.WebhookResponse, string, error) {
+	var resp struct {
+		Webhook api.WebhookResponse `json:"webhook"`
+		Secret  string              `json:"secret"`
+	}
+	err := c.do(ctx, http.MethodPost, "/api/v1/webhooks", hook, &resp)
+	return resp.Webhook, resp.Secret, err
+}
+
+// DeleteWebhook deletes a webhook of the authenticated user.
+func (c *Client) DeleteWebhook(ctx context.Context, id int) error {
+	return c.do(ctx, http.MethodDelete, webhookPath(id), nil, nil)
+}
+
+// WebhookDeliveries returns the latest deliveries to a webhook, newest first.
+func (c *Client) WebhookDeliveries(ctx context.Context, id int) ([]api.WebhookDelivery, error) {
+	var resp struct {
+		Deliveries []api.WebhookDelivery `json:"deliveries"`
+	}
+	err := c.do(ctx, http.MethodGet, webhookPath(id)+"/deliveries", nil, &resp)
+	return resp.Deliveries, err
+}
+
+// rawBody is a request body that is sent as it is instead of being encoded as JSON.
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// do sends a request and decodes the response into out: JSON unless out is a *bytes.Buffer, which receives the raw
+// body. Idempotent requests are retried.
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var data []byte
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case rawBody:
+		data, contentType = b.data, b.contentType
+	default:
+		var err error
+		if data, err = json.Marshal(body); err != nil {
+			return err
+		}
+	}
+
+	retries := 0
+	if method == http.MethodGet || method == http.MethodPut || method == http.MethodDelete {
+		retries = c.MaxRetries
+	}
+
+	backoff := c.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := c.try(ctx, method, path, contentType, data, out)
+		var apiErr *Error
+		retryable := err != nil && ctx.Err() == nil &&
+			(!errors.As(err, &apiErr) || apiErr.StatusCode >= http.StatusInternalServerError)
+		if !retryable || attempt >= retries {
+			return err
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) try(ctx context.Context, method, path, contentType string, data []byte, out interface{}) error {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if data != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return decodeError(resp)
+	}
+	switch o := out.(type) {
+	case nil:
+		return nil
+	case *bytes.Buffer:
+		_, err = o.ReadFrom(resp.Body)
+		return err
+	default:
+		if resp.StatusCode == http.StatusNoContent {
+			return nil
+		}
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+}
+
+// decodeError reads the api.ErrorResponse body the server sends with error responses.
+func decodeError(resp *http.Response) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+	var body api.ErrorResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err == nil && body.Error.Message != "" {
+		apiErr.Code = body.Error.Code
+		apiErr.Message = body.Error.Message
+		apiErr.Details = body.Error.Details
+	}
+	return apiErr
+}
+
+func listPath(name string) string {
+	return "/api/v1/list/" + url.PathEscape(name)
+}
+
+func memberPath(list, user string) string {
+	return listPath(list) + "/members/" + url.PathEscape(user)
+}
+
+func userPath(name string) string {
+	return "/api/v1/users/" + url.PathEscape(name)
+}
+
+func itemPath(id int) string {
+	return "/api/v1/items/" + strconv.Itoa(id)
+}
+
+func webhookPath(id int) string {
+	return "/api/v1/webhooks/" + strconv.Itoa(id)
+}
+
+func commentPath(taskID, commentID int) string {
+	return itemPath(taskID) + "/comments/" + strconv.Itoa(commentID)
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/rest"
+	"github.com/jniewt/gotodo/internal/storage"
+	"github.com/jniewt/gotodo/internal/webhook"
+)
+
+func newTestServer(t *testing.T) *Client {
+	t.Helper()
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	repo := repository.NewRepository(&storage.Fake{})
+	authStore, _ := auth.NewStore("")
+	if err := authStore.AddUser("alice", "password123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := authStore.SetAdmin("alice", true); err != nil {
+		t.Fatal(err)
+	}
+	_, token, err := authStore.CreateToken("alice", "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks, _ := webhook.NewDispatcher("", log.NewEntry(logger))
+	hooks.AllowPrivate = true
+	repo.Subscribe(hooks.Notify)
+	orga := func(user string) rest.Organiser { return repo.As(user) }
+	srv := httptest.NewServer(rest.NewServer(fstest.MapFS{}, orga, authStore, hooks, nil, nil, log.NewEntry(logger)))
+	t.Cleanup(srv.Close)
+	c := New(srv.URL)
+	c.Token = token
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+
+	l, err := c.AddList(ctx, api.ListAdd{Name: "Home", Colour: api.RGB{R: 255}})
+	if err != nil {
+		t.Fatalf("AddList() error = %v", err)
+	}
+	if l.Name != "Home" || l.Colour.R != 255 {
+		t.Errorf("AddList() = %+v", l)
+	}
+
+	due := time.Date(2026, 10, 20, 14, 30, 0, 0, time.Local)
+	task, err := c.AddTask(ctx, "Home", api.TaskAdd{Title: "Buy milk", Priority: 1, DueType: "due_on", Due: due})
+	if err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if task.ID == 0 || task.List != "Home" || !task.Due.Equal(due) || task.Priority != 1 {
+		t.Errorf("AddTask() = %+v", task)
+	}
+
+	task, err = c.SetDone(ctx, task.ID, true)
+	if err != nil {
+		t.Fatalf("SetDone() error = %v", err)
+	}
+	if !task.Done || task.Priority != 1 || !task.Due.Equal(due) {
+		t.Errorf("SetDone() = %+v", task)
+	}
+
+	change := api.TaskChange{Title: "Buy oat milk", List: "Home", Done: true, Priority: 2, AllDay: true, DueType: "due_by", Due: due}
+	task, err = c.UpdateTask(ctx, task.ID, change)
+	if err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	wantDue := time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)
+	if task.Title != "Buy oat milk" || task.DueType != "due_by" || !task.AllDay || !task.Due.Equal(wantDue) {
+		t.Errorf("UpdateTask() = %+v", task)
+	}
+
+	if _, err = c.QuickAdd(ctx, "Call mum tomorrow !high", "Home"); err != nil {
+		t.Fatalf("QuickAdd() error = %v", err)
+	}
+
+	all, err := c.ListAll(ctx)
+	if err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if len(all.Lists) != 1 || len(all.Lists[0].Items) != 2 {
+		t.Errorf("ListAll() = %+v", all)
+	}
+
+	md, err := c.GetListMarkdown(ctx, "Home")
+	if err != nil {
+		t.Fatalf("GetListMarkdown() error = %v", err)
+	}
+	if !strings.Contains(md, "- [x] Buy oat milk (due 2026-10-20, !highest)") {
+		t.Errorf("GetListMarkdown() = %q", md)
+	}
+
+	export, err := c.Export(ctx)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	report, err := c.Import(ctx, export, ImportOptions{Strategy: api.MergeDuplicate, DryRun: true})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if report.TasksImported != 2 || report.ListsCreated != 1 {
+		t.Errorf("Import() = %+v", report)
+	}
+
+	if err = c.DeleteTask(ctx, task.ID); err != nil {
+		t.Fatalf("DeleteTask() error = %v", err)
+	}
+	if err = c.DeleteList(ctx, "Home"); err != nil {
+		t.Fatalf("DeleteList() error = %v", err)
+	}
+}
+
+// TestClient_UpdateSameDue sends a task read back from the server unchanged, a due date with seconds mustn't be
+// recorded as changed.
+func TestClient_UpdateSameDue(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+	if _, err := c.AddList(ctx, api.ListAdd{Name: "Home"}); err != nil {
+		t.Fatal(err)
+	}
+	due := time.Date(2026, 10, 20, 14, 30, 45, 0, time.UTC)
+	task, err := c.AddTask(ctx, "Home", api.TaskAdd{Title: "Buy milk", DueType: "due_by", Due: due})
+	if err != nil {
+		t.Fatalf("AddTask() error = %v", err)
+	}
+	if !task.Due.Equal(due) {
+		t.Errorf("AddTask() due = %v, want %v", task.Due, due)
+	}
+
+	change := api.TaskChange{Title: task.Title, List: task.List, Status: task.Status, DueType: core.DueType(task.DueType), Due: task.Due}
+	if task, err = c.UpdateTask(ctx, task.ID, change); err != nil {
+		t.Fatalf("UpdateTask() error = %v", err)
+	}
+	history, err := c.TaskHistory(ctx, task.ID)
+	if err != nil {
+		t.Fatalf("TaskHistory() error = %v", err)
+	}
+	if len(history) != 0 || !task.Due.Equal(due) {
+		t.Errorf("UpdateTask() = %+v with history %+v, want the task unchanged", task, history)
+	}
+}
+
+func TestClient_Error(t *testing.T) {
+	c := newTestServer(t)
+
+	_, _, err := c.GetList(context.Background(), "Nope")
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("GetList() error = %v, want *Error", err)
+	}
+	if apiErr.StatusCode != http.StatusNotFound || apiErr.Code != api.CodeListNotFound || apiErr.Message != "list not found" {
+		t.Errorf("GetList() error = %+v", apiErr)
+	}
+
+	_, err = c.AddTask(context.Background(), "Nope", api.TaskAdd{Title: "Buy milk"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != api.CodeListNotFound {
+		t.Errorf("AddTask() error = %v, want list_not_found", err)
+	}
+
+	if _, err = c.AddList(context.Background(), api.ListAdd{Name: "Home"}); err != nil {
+		t.Fatalf("AddList() error = %v", err)
+	}
+	_, err = c.AddList(context.Background(), api.ListAdd{Name: "Home"})
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusConflict || apiErr.Code != api.CodeListExists {
+		t.Errorf("AddList() error = %v, want list_exists", err)
+	}
+
+	_, err = c.AddTask(context.Background(), "Home", api.TaskAdd{})
+	if !errors.As(err, &apiErr) || apiErr.Code != api.CodeValidationFailed ||
+		len(apiErr.Details) != 1 || apiErr.Details[0].Field != "title" {
+		t.Errorf("AddTask() error = %+v, want validation_failed for title", apiErr)
+	}
+
+	_, err = c.GetTask(context.Background(), 42)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Code != api.CodeTaskNotFound {
+		t.Errorf("GetTask() error = %v, want task_not_found", err)
+	}
+}
+
+func TestClient_Tokens(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+
+	token, secret, err := c.CreateToken(ctx, "script")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	tokens, err := c.Tokens(ctx)
+	if err != nil || len(tokens) != 2 {
+		t.Fatalf("Tokens() = %+v, %v, want 2 tokens", tokens, err)
+	}
+
+	other := New(c.BaseURL)
+	other.Token = secret
+	if _, err = other.ListAll(ctx); err != nil {
+		t.Fatalf("ListAll() with new token error = %v", err)
+	}
+	if err = c.RevokeToken(ctx, token.ID); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	var apiErr *Error
+	_, err = other.ListAll(ctx)
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Code != api.CodeUnauthenticated {
+		t.Errorf("ListAll() with revoked token error = %v, want unauthenticated", err)
+	}
+}
+
+func TestClient_Users(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+
+	if me, err := c.Me(ctx); err != nil || me.Name != "alice" || !me.Admin {
+		t.Errorf("Me() = %+v, %v, want admin alice", me, err)
+	}
+	if _, err := c.AddUser(ctx, api.UserAdd{Name: "bob", Password: "password123"}); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+	admin := true
+	if u, err := c.UpdateUser(ctx, "bob", api.UserChange{Admin: &admin}); err != nil || !u.Admin {
+		t.Errorf("UpdateUser() = %+v, %v, want admin", u, err)
+	}
+	if users, err := c.Users(ctx); err != nil || len(users) != 2 {
+		t.Errorf("Users() = %+v, %v, want 2 users", users, err)
+	}
+	if err := c.DeleteUser(ctx, "bob"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+
+	var apiErr *Error
+	_, err := c.AddUser(ctx, api.UserAdd{Name: "alice", Password: "password123"})
+	if !errors.As(err, &apiErr) || apiErr.Code != api.CodeUserExists {
+		t.Errorf("AddUser() existing error = %v, want %s", err, api.CodeUserExists)
+	}
+}
+
+func TestClient_Webhooks(t *testing.T) {
+	c := newTestServer(t)
+	ctx := context.Background()
+	events := make(chan *http.Request, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		events <- r
+	}))
+	defer receiver.Close()
+
+	add := api.WebhookAdd{
+		URL:      receiver.URL,
+		Secret:   "s3cret",
+		Events:   []string{"task.completed"},
+		RuleSets: [][]api.ExportRule{{{Field: "list", Value: "Work"}}},
+	}
+	hook, secret, err := c.AddWebhook(ctx, add)
+	if err != nil {
+		t.Fatalf("AddWebhook() error = %v", err)
+	}
+	if secret != "s3cret" {
+		t.Errorf("secret = %q", secret)
+	}
+	if _, _, err = c.AddWebhook(ctx, api.WebhookAdd{URL: receiver.URL, Events: []string{"task.exploded"}}); err == nil {
+		t.Error("AddWebhook() with unknown event succeeded")
+	}
+
+	for _, name := range []string{"Home", "Work"} {
+		if _, err = c.AddList(ctx, api.ListAdd{Name: name}); err != nil {
+			t.Fatal(err)
+		}
+		task, err := c.AddTask(ctx, name, api.TaskAdd{Title: "Close " + name})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err = c.SetDone(ctx, task.ID, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	select {
+	case r := <-events:
+		if got := r.Header.Get(webhook.EventHeader); got != "task.completed" {
+			t.Errorf("event = %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no webhook delivery")
+	}
+	deliveries, err := c.WebhookDeliveries(ctx, hook.ID)
+	if err != nil || len(deliveries) != 1 {
+		t.Errorf("WebhookDeliveries() = %+v, %v, want one delivery", deliveries, err)
+	}
+	if err = c.DeleteWebhook(ctx, hook.ID); err != nil {
+		t.Fatalf("DeleteWebhook() error = %v", err)
+	}
+	if hooks, err := c.Webhooks(ctx); err != nil || len(hooks) != 0 {
+		t.Errorf("Webhooks() = %+v, %v, want none", hooks, err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"lists":[],"filtered_lists":[]}`))
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL)
+	c.RetryBackoff = time.Millisecond
+	if _, err := c.ListAll(context.Background()); err != nil {
+		t.Fatalf("ListAll() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("server called %d times, want 3", calls.Load())
+	}
+
+	// non-idempotent requests are not retried
+	calls.Store(0)
+	if _, err := c.AddList(context.Background(), api.ListAdd{Name: "Home"}); err == nil {
+		t.Error("AddList() expected error")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server called %d times, want 1", calls.Load())
+	}
+}
@@ -0,0 +1,370 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/quickadd"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/rest"
+)
+
+// runAdd implements the add command. Unless -raw is given, the title is parsed for a list, priority and due date like
+// "Call client tomorrow 9am !high #Work". Flags take precedence over the title.
+func runAdd(args []string) error {
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	var list, due, on string
+	var prio int
+	var raw bool
+	flags.StringVar(&list, "l", "", "list to add the task to, defaults to #List in the title or $GOTASKS_LIST")
+	flags.IntVar(&prio, "p", core.PrioNormal, "priority from -2 (lowest) to 2 (highest)")
+	flags.StringVar(&due, "due", "", "date the task is due by, e.g. 2026-10-20, \"2026-10-20 14:00\", friday or \"tomorrow 9am\"")
+	flags.StringVar(&on, "on", "", "date the task is due on, same format as --due")
+	flags.BoolVar(&raw, "raw", false, "use the title as it is, without parsing it")
+	text := strings.Join(parseArgs(flags, args), " ")
+
+	task := api.TaskAdd{Title: text}
+	var err error
+	if !raw {
+		if task, err = quickadd.Parse(text, time.Now()); err != nil {
+			return err
+		}
+	}
+	if task.Title == "" {
+		return errors.New("missing task title")
+	}
+
+	set := make(map[string]bool)
+	flags.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["l"] {
+		task.List = list
+	} else if task.List == "" {
+		task.List = os.Getenv("GOTASKS_LIST")
+	}
+	if task.List == "" {
+		return errors.New("missing list, use -l, #List or set $GOTASKS_LIST")
+	}
+	if set["p"] {
+		task.Priority = prio
+	}
+	if task.Priority < core.PrioLowest || task.Priority > core.PrioHighest {
+		return errors.New("priority outside of bounds")
+	}
+	switch {
+	case due != "" && on != "":
+		return errors.New("--due and --on can't be used together")
+	case due != "":
+		task.DueType = core.DueBy
+		task.Due, task.AllDay, err = quickadd.ParseDate(due, time.Now())
+	case on != "":
+		task.DueType = core.DueOn
+		task.Due, task.AllDay, err = quickadd.ParseDate(on, time.Now())
+	}
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	lists, _ := orga.Lists()
+	task.List = quickadd.ResolveList(task.List, lists)
+	t, err := orga.AddItem(task.List, task)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Added task %d to %s.\n", t.ID, t.List)
+	return nil
+}
+
+// runList implements the ls command. Without arguments it shows all lists, otherwise the tasks of the given list or
+// filtered list.
+func runList(args []string) error {
+	flags := flag.NewFlagSet("ls", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	var all bool
+	flags.BoolVar(&all, "a", false, "show done and snoozed tasks, too")
+	names := parseArgs(flags, args)
+	if len(names) > 1 {
+		return errors.New("ls takes at most one list")
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	if len(names) == 0 {
+		lists, filtered := orga.Lists()
+		if err = lastErr(orga); err != nil {
+			return err
+		}
+		for _, l := range lists {
+			fmt.Fprintf(w, "%s\t%d open\n", l.Name, countOpen(l.Items))
+		}
+		for _, fl := range filtered {
+			tasks, err := orga.GetFilteredTasks(fl.Name)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(w, "%s\t%d open\t(filtered)\n", fl.Name, countOpen(tasks))
+		}
+		return nil
+	}
+
+	tasks, showList, err := listTasks(orga, names[0])
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		if (t.Done || t.IsHidden()) && !all {
+			continue
+		}
+		printTask(w, *t, showList)
+	}
+	return nil
+}
+
+// listTasks returns the tasks of a list or filtered list and whether it's a filtered list.
+func listTasks(orga rest.Organiser, name string) ([]*core.Task, bool, error) {
+	l, err := orga.GetList(name)
+	if err == nil {
+		return l.Items, false, nil
+	}
+	if !errors.Is(err, repository.ErrListNotFound) {
+		return nil, false, err
+	}
+	tasks, err := orga.GetFilteredTasks(name)
+	return tasks, true, err
+}
+
+func printTask(w *tabwriter.Writer, t core.Task, showList bool) {
+	check := " "
+	if t.Done {
+		check = "x"
+	}
+	var due string
+	if t.HasDueDate() {
+		layout := "2006-01-02 15:04"
+		if t.AllDay {
+			layout = "2006-01-02"
+		}
+		due = "due " + t.Due.In(time.Local).Format(layout)
+		if t.HasDueOnDate() {
+			due = "on " + t.Due.In(time.Local).Format(layout)
+		}
+		if t.IsOverdue() {
+			due += " (overdue)"
+		}
+	}
+	list := ""
+	if showList {
+		list = t.List
+	}
+	fmt.Fprintf(w, "%d\t[%s]\t%s\t%s\t%s\t%s\n", t.ID, check, t.Title, due, priorityLabel(t.Priority), list)
+}
+
+func priorityLabel(prio int) string {
+	switch prio {
+	case core.PrioLowest:
+		return "!lowest"
+	case core.PrioLow:
+		return "!low"
+	case core.PrioHigh:
+		return "!high"
+	case core.PrioHighest:
+		return "!highest"
+	}
+	return ""
+}
+
+func countOpen(tasks []*core.Task) int {
+	n := 0
+	for _, t := range tasks {
+		if !t.Done {
+			n++
+		}
+	}
+	return n
+}
+
+// runDone implements the done command.
+func runDone(args []string) error {
+	flags := flag.NewFlagSet("done", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	var undo bool
+	flags.BoolVar(&undo, "undo", false, "mark the tasks as not done")
+	ids, err := parseIDs(parseArgs(flags, args))
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		t, err := orga.MarkDone(id, !undo)
+		if err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+		if undo {
+			fmt.Printf("Marked %q as not done.\n", t.Title)
+		} else {
+			fmt.Printf("Marked %q as done.\n", t.Title)
+		}
+	}
+	return nil
+}
+
+// runMove implements the mv command.
+func runMove(args []string) error {
+	flags := flag.NewFlagSet("mv", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	pos := parseArgs(flags, args)
+	if len(pos) != 2 {
+		return errors.New("usage: gotasks mv <id> <list>")
+	}
+	ids, err := parseIDs(pos[:1])
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	t, err := orga.GetTask(ids[0])
+	if err != nil {
+		return err
+	}
+	change := api.NewTaskChange(t)
+	change.List = pos[1]
+	if _, err = orga.UpdateTask(t.ID, change); err != nil {
+		return err
+	}
+	fmt.Printf("Moved %q to %s.\n", t.Title, pos[1])
+	return nil
+}
+
+// runSnooze implements the snooze command.
+func runSnooze(args []string) error {
+	flags := flag.NewFlagSet("snooze", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	pos := parseArgs(flags, args)
+	if len(pos) < 2 {
+		return errors.New("usage: gotasks snooze <id> <until>")
+	}
+	ids, err := parseIDs(pos[:1])
+	if err != nil {
+		return err
+	}
+	until, err := quickadd.ParseSnooze(strings.Join(pos[1:], " "), time.Now())
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	t, err := orga.SnoozeTask(ids[0], until)
+	if err != nil {
+		return err
+	}
+	if until.IsZero() {
+		fmt.Printf("Woke up %q.\n", t.Title)
+	} else {
+		fmt.Printf("Snoozed %q until %s.\n", t.Title, until.Format("2006-01-02 15:04"))
+	}
+	return nil
+}
+
+// runTimer implements the timer command.
+func runTimer(args []string) error {
+	flags := flag.NewFlagSet("timer", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	pos := parseArgs(flags, args)
+	if len(pos) != 2 || (pos[0] != "start" && pos[0] != "stop") {
+		return errors.New("usage: gotasks timer start|stop <id>")
+	}
+	ids, err := parseIDs(pos[1:])
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	if pos[0] == "start" {
+		if _, err = orga.StartTimer(ids[0]); err != nil {
+			return err
+		}
+		fmt.Printf("Started timer on task %d.\n", ids[0])
+		return nil
+	}
+	e, err := orga.StopTimer(ids[0])
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Stopped timer on task %d after %s.\n", ids[0], e.Duration(time.Now()).Round(time.Second))
+	return nil
+}
+
+// runRemove implements the rm command.
+func runRemove(args []string) error {
+	flags := flag.NewFlagSet("rm", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	ids, err := parseIDs(parseArgs(flags, args))
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if err = orga.DelItem(id); err != nil {
+			return fmt.Errorf("task %d: %w", id, err)
+		}
+	}
+	fmt.Printf("Deleted %d task(s).\n", len(ids))
+	return nil
+}
+
+func parseIDs(args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, errors.New("missing task ID")
+	}
+	ids := make([]int, 0, len(args))
+	for _, a := range args {
+		id, err := strconv.Atoi(a)
+		if err != nil {
+			return nil, fmt.Errorf("invalid task ID %q", a)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/importer"
+)
+
+// runImport implements the import command, which reads a dump of another task manager.
+func runImport(args []string) error {
+	flags := flag.NewFlagSet("import", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	var format, list, strategyName string
+	var dryRun bool
+	flags.StringVar(&format, "format", "", "format of the dump: taskwarrior (task export) or todoist (JSON backup)")
+	flags.StringVar(&list, "list", "Inbox", "list for tasks without a project")
+	flags.StringVar(&strategyName, "strategy", "skip", "how to handle existing lists: skip (merge tasks into them), overwrite or duplicate")
+	flags.BoolVar(&dryRun, "dry-run", false, "print a summary without importing anything")
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: gotasks import -format taskwarrior|todoist [flags] <file>\n")
+		flags.PrintDefaults()
+	}
+	files := parseArgs(flags, args)
+
+	if len(files) != 1 {
+		flags.Usage()
+		return fmt.Errorf("missing file to import")
+	}
+	strategy, err := api.ParseMergeStrategy(strategyName)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Open(files[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var read func(io.Reader, string) (importer.Result, error)
+	switch format {
+	case "taskwarrior":
+		read = importer.Taskwarrior
+	case "todoist":
+		read = importer.Todoist
+	default:
+		return fmt.Errorf("invalid format %q, must be taskwarrior or todoist", format)
+	}
+	res, err := read(f, list)
+	if err != nil {
+		return err
+	}
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+
+	var report api.ImportReport
+	if dryRun {
+		report = orga.PreviewImport(res.Lists, nil, strategy)
+		err = lastErr(orga)
+	} else {
+		report, err = orga.Import(res.Lists, nil, strategy)
+	}
+	if err != nil {
+		return err
+	}
+
+	printImportSummary(os.Stdout, res, report, dryRun)
+	return nil
+}
+
+func printImportSummary(w io.Writer, res importer.Result, report api.ImportReport, dryRun bool) {
+	existing := make(map[string]api.ImportConflict)
+	for _, c := range report.Conflicts {
+		if c.Kind == "list" {
+			existing[c.Name] = c
+		}
+	}
+
+	verb := "Imported"
+	if dryRun {
+		verb = "Would import"
+	}
+	fmt.Fprintf(w, "%s %d tasks into %d lists (%d new), skipped %d entries.\n",
+		verb, report.TasksImported, len(res.Lists), report.ListsCreated, res.Skipped)
+
+	for _, l := range res.Lists {
+		done, due := 0, 0
+		for _, t := range l.Items {
+			if t.Done {
+				done++
+			}
+			if t.HasDueDate() {
+				due++
+			}
+		}
+		note := "new list"
+		if c, ok := existing[l.Name]; ok {
+			switch c.Resolution {
+			case api.MergeDuplicate:
+				note = fmt.Sprintf("exists, importing as %q", c.NewName)
+			case api.MergeOverwrite:
+				note = "exists, overwriting colour"
+			default:
+				note = "exists, merging"
+			}
+		}
+		fmt.Fprintf(w, "  %-20s %4d tasks (%d done, %d with due date) - %s\n", l.Name, len(l.Items), done, due, note)
+	}
+}
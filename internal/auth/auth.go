@@ -0,0 +1,501 @@
+// Package auth stores users, their API tokens and login sessions.
+//
+// Passwords are stored as bcrypt hashes, API tokens and session IDs only as SHA-256 hashes, so the secrets handed out
+// to clients can't be recovered from the auth file or from memory. Users and tokens are kept in a YAML file, sessions
+// only in memory, so a restart of the server logs out all browsers.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v3"
+)
+
+// SessionTTL is how long a login session is valid.
+const SessionTTL = 7 * 24 * time.Hour
+
+// tokenPrefix makes API tokens recognisable, e.g. for secret scanners.
+const tokenPrefix = "gt_"
+
+var (
+	// ErrUnauthenticated is returned for unknown or expired credentials.
+	ErrUnauthenticated = errors.New("invalid credentials")
+	ErrUserExists      = errors.New("user already exists")
+	ErrUserNotFound    = errors.New("user not found")
+	ErrTokenNotFound   = errors.New("token not found")
+	// ErrWeakPassword is returned for passwords that are too short.
+	ErrWeakPassword = errors.New("password must have at least 8 characters")
+)
+
+type User struct {
+	Name         string
+	PasswordHash string `yaml:"password_hash"`
+	// Admin users can manage other users.
+	Admin bool `yaml:",omitempty"`
+}
+
+// Token is an API token. The token itself is only returned once by CreateToken, afterwards only its hash is known.
+type Token struct {
+	ID       int
+	User     string
+	Name     string
+	Hash     string
+	Created  time.Time
+	LastUsed time.Time `yaml:"last_used,omitempty"`
+}
+
+type session struct {
+	user    string
+	expires time.Time
+}
+
+type fileStore struct {
+	Users  []*User
+	Tokens []*Token
+}
+
+// Store keeps users and tokens in a YAML file and sessions in memory. It is safe for concurrent use.
+type Store struct {
+	path string
+
+	mu sync.Mutex
+	// mem is used instead of the file if path is empty
+	mem      *fileStore
+	sessions map[string]session
+	// cache is the content of the file as described by info, so that not every request reads it
+	cache     *fileStore
+	cacheInfo os.FileInfo
+
+	now func() time.Time
+}
+
+// NewStore returns a store using the YAML file at path, which is created if it doesn't exist. An empty path keeps
+// everything in memory.
+func NewStore(path string) (*Store, error) {
+	s := &Store{path: path, sessions: make(map[string]session), now: time.Now}
+	if path == "" {
+		s.mem = &fileStore{}
+		return s, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err = os.WriteFile(path, []byte{}, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Users returns all users.
+func (s *Store) Users() ([]User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	users := make([]User, 0, len(store.Users))
+	for _, u := range store.Users {
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+// GetUser returns a user.
+func (s *Store) GetUser(name string) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return User{}, err
+	}
+	u := findUser(store, name)
+	if u == nil {
+		return User{}, ErrUserNotFound
+	}
+	return *u, nil
+}
+
+// AddUser adds a user with the given password.
+func (s *Store) AddUser(name, password string) error {
+	if name == "" {
+		return errors.New("missing user name")
+	}
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	if findUser(store, name) != nil {
+		return ErrUserExists
+	}
+	store.Users = append(store.Users, &User{Name: name, PasswordHash: hash})
+	return s.save(store)
+}
+
+// SetPassword changes the password of a user.
+func (s *Store) SetPassword(name, password string) error {
+	hash, err := hashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	u := findUser(store, name)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.PasswordHash = hash
+	return s.save(store)
+}
+
+// SetAdmin grants or revokes the admin role of a user.
+func (s *Store) SetAdmin(name string, admin bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	u := findUser(store, name)
+	if u == nil {
+		return ErrUserNotFound
+	}
+	u.Admin = admin
+	return s.save(store)
+}
+
+// DeleteUser deletes a user together with their API tokens and sessions.
+func (s *Store) DeleteUser(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	if findUser(store, name) == nil {
+		return ErrUserNotFound
+	}
+	users := store.Users[:0]
+	for _, u := range store.Users {
+		if u.Name != name {
+			users = append(users, u)
+		}
+	}
+	store.Users = users
+	tokens := store.Tokens[:0]
+	for _, t := range store.Tokens {
+		if t.User != name {
+			tokens = append(tokens, t)
+		}
+	}
+	store.Tokens = tokens
+	if err = s.save(store); err != nil {
+		return err
+	}
+
+	for key, sess := range s.sessions {
+		if sess.user == name {
+			delete(s.sessions, key)
+		}
+	}
+	return nil
+}
+
+// Login checks the password of a user and returns the ID of a new session.
+func (s *Store) Login(name, password string) (string, error) {
+	// bcrypt is slow on purpose, so compare outside the lock
+	hash, err := s.passwordHash(name)
+	if errors.Is(err, ErrUserNotFound) {
+		// compare anyway, so that the response time doesn't reveal which users exist
+		_ = bcrypt.CompareHashAndPassword(dummyHash(), []byte(password))
+		return "", ErrUnauthenticated
+	}
+	if err != nil {
+		return "", err
+	}
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return "", ErrUnauthenticated
+	}
+
+	id, err := randomSecret()
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[hashSecret(id)] = session{user: name, expires: s.now().Add(SessionTTL)}
+	return id, nil
+}
+
+func (s *Store) passwordHash(name string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	u := findUser(store, name)
+	if u == nil {
+		return "", ErrUserNotFound
+	}
+	return u.PasswordHash, nil
+}
+
+// Logout ends a session.
+func (s *Store) Logout(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, hashSecret(sessionID))
+}
+
+// SessionUser returns the user of a session.
+func (s *Store) SessionUser(sessionID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := hashSecret(sessionID)
+	sess, ok := s.sessions[key]
+	if !ok {
+		return "", ErrUnauthenticated
+	}
+	if s.now().After(sess.expires) {
+		delete(s.sessions, key)
+		return "", ErrUnauthenticated
+	}
+	return sess.user, nil
+}
+
+// CreateToken creates an API token for a user. The returned secret is the token to be sent by clients, it can't be
+// retrieved again.
+func (s *Store) CreateToken(user, name string) (Token, string, error) {
+	if name == "" {
+		return Token{}, "", errors.New("missing token name")
+	}
+	secret, err := randomSecret()
+	if err != nil {
+		return Token{}, "", err
+	}
+	secret = tokenPrefix + secret
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return Token{}, "", err
+	}
+	if findUser(store, user) == nil {
+		return Token{}, "", ErrUserNotFound
+	}
+	id := 1
+	for _, t := range store.Tokens {
+		id = max(id, t.ID+1)
+	}
+	t := &Token{ID: id, User: user, Name: name, Hash: hashSecret(secret), Created: s.now()}
+	store.Tokens = append(store.Tokens, t)
+	if err = s.save(store); err != nil {
+		return Token{}, "", err
+	}
+	return *t, secret, nil
+}
+
+// Tokens returns the API tokens of a user.
+func (s *Store) Tokens(user string) ([]Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]Token, 0)
+	for _, t := range store.Tokens {
+		if t.User == user {
+			tokens = append(tokens, *t)
+		}
+	}
+	return tokens, nil
+}
+
+// RevokeToken deletes an API token of a user.
+func (s *Store) RevokeToken(user string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return err
+	}
+	for i, t := range store.Tokens {
+		if t.ID == id && t.User == user {
+			store.Tokens = append(store.Tokens[:i], store.Tokens[i+1:]...)
+			return s.save(store)
+		}
+	}
+	return ErrTokenNotFound
+}
+
+// TokenUser returns the user of an API token and records its use.
+func (s *Store) TokenUser(secret string) (string, error) {
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		return "", ErrUnauthenticated
+	}
+	hash := hashSecret(secret)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	store, err := s.load()
+	if err != nil {
+		return "", err
+	}
+	for _, t := range store.Tokens {
+		if t.Hash != hash {
+			continue
+		}
+		// only record the day of the last use, so that not every request writes the file
+		now := s.now()
+		if now.Sub(t.LastUsed) > 24*time.Hour {
+			t.LastUsed = now
+			if err = s.save(store); err != nil {
+				return "", err
+			}
+		}
+		return t.User, nil
+	}
+	return "", ErrUnauthenticated
+}
+
+// load returns the content of the auth file. The file is only read again if it was modified since, e.g. by the
+// command line tool.
+func (s *Store) load() (*fileStore, error) {
+	if s.mem != nil {
+		return s.mem, nil
+	}
+	info, err := os.Stat(s.path)
+	if err != nil {
+		return nil, err
+	}
+	if s.cache != nil && os.SameFile(info, s.cacheInfo) && info.ModTime().Equal(s.cacheInfo.ModTime()) &&
+		info.Size() == s.cacheInfo.Size() {
+		return s.cache, nil
+	}
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return nil, err
+	}
+	store := &fileStore{}
+	if err = yaml.Unmarshal(data, store); err != nil {
+		return nil, err
+	}
+	s.cache, s.cacheInfo = store, info
+	return store, nil
+}
+
+// save writes the store to a temporary file and renames it over the auth file.
+func (s *Store) save(store *fileStore) error {
+	if s.mem != nil {
+		s.mem = store
+		return nil
+	}
+	// callers modify the cached store, drop it unless it was written
+	s.cache = nil
+	data, err := yaml.Marshal(store)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		s.cache, s.cacheInfo = store, info
+	}
+	return nil
+}
+
+func findUser(store *fileStore, name string) *User {
+	for _, u := range store.Users {
+		if u.Name == name {
+			return u
+		}
+	}
+	return nil
+}
+
+// dummyHash is compared against for unknown users.
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("dummy password"), bcrypt.DefaultCost)
+	return hash
+})
+
+func hashPassword(password string) (string, error) {
+	if len(password) < 8 {
+		return "", ErrWeakPassword
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+	return string(hash), nil
+}
+
+// RandomPassword returns a random password, e.g. for the initial user.
+func RandomPassword() (string, error) {
+	secret, err := randomSecret()
+	if err != nil {
+		return "", err
+	}
+	return secret[:20], nil
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStore_Login(t *testing.T) {
+	s, _ := NewStore("")
+	if err := s.AddUser("alice", "short"); err == nil {
+		t.Error("AddUser() with short password expected error")
+	}
+	if err := s.AddUser("alice", "password123"); err != nil {
+		t.Fatalf("AddUser() error = %v", err)
+	}
+	if err := s.AddUser("alice", "password123"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("AddUser() twice error = %v, want %v", err, ErrUserExists)
+	}
+
+	tests := []struct {
+		name     string
+		user     string
+		password string
+		wantErr  bool
+	}{
+		{"Valid", "alice", "password123", false},
+		{"Wrong password", "alice", "password124", true},
+		{"Unknown user", "bob", "password123", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, err := s.Login(tt.user, tt.password)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Login() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if user, err := s.SessionUser(id); err != nil || user != tt.user {
+				t.Errorf("SessionUser() = %q, %v, want %q", user, err, tt.user)
+			}
+		})
+	}
+}
+
+func TestStore_Session(t *testing.T) {
+	s, _ := NewStore("")
+	_ = s.AddUser("alice", "password123")
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+
+	id, err := s.Login("alice", "password123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.SessionUser("other"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("SessionUser() with unknown session error = %v", err)
+	}
+
+	now = now.Add(SessionTTL + time.Minute)
+	if _, err = s.SessionUser(id); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("SessionUser() after expiry error = %v, want %v", err, ErrUnauthenticated)
+	}
+
+	id, _ = s.Login("alice", "password123")
+	s.Logout(id)
+	if _, err = s.SessionUser(id); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("SessionUser() after logout error = %v, want %v", err, ErrUnauthenticated)
+	}
+}
+
+func TestStore_Tokens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "auth.yml")
+	s, err := NewStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = s.AddUser("alice", "password123")
+	_ = s.AddUser("bob", "password123")
+
+	tok, secret, err := s.CreateToken("alice", "backup script")
+	if err != nil {
+		t.Fatalf("CreateToken() error = %v", err)
+	}
+	if !strings.HasPrefix(secret, tokenPrefix) {
+		t.Errorf("secret = %q, want prefix %q", secret, tokenPrefix)
+	}
+
+	// the file must contain the hash but not the token itself
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), secret) || !strings.Contains(string(data), tok.Hash) {
+		t.Error("auth file should contain only the hash of the token")
+	}
+
+	// a second store on the same file sees the token
+	s2, _ := NewStore(path)
+	if user, err := s2.TokenUser(secret); err != nil || user != "alice" {
+		t.Errorf("TokenUser() = %q, %v, want alice", user, err)
+	}
+	if _, err = s.TokenUser(secret + "x"); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("TokenUser() with wrong token error = %v", err)
+	}
+
+	if err = s.RevokeToken("bob", tok.ID); !errors.Is(err, ErrTokenNotFound) {
+		t.Errorf("RevokeToken() of other user error = %v, want %v", err, ErrTokenNotFound)
+	}
+	if err = s.RevokeToken("alice", tok.ID); err != nil {
+		t.Fatalf("RevokeToken() error = %v", err)
+	}
+	if _, err = s.TokenUser(secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("TokenUser() after revoke error = %v", err)
+	}
+	// the second store reloads the modified file
+	if _, err = s2.TokenUser(secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("TokenUser() of second store after revoke error = %v", err)
+	}
+}
+
+func TestStore_DeleteUser(t *testing.T) {
+	s, _ := NewStore("")
+	_ = s.AddUser("alice", "password123")
+	_ = s.AddUser("bob", "password123")
+
+	if err := s.SetAdmin("alice", true); err != nil {
+		t.Fatalf("SetAdmin() error = %v", err)
+	}
+	if u, err := s.GetUser("alice"); err != nil || !u.Admin {
+		t.Errorf("GetUser() = %+v, %v, want admin", u, err)
+	}
+
+	session, _ := s.Login("bob", "password123")
+	_, secret, _ := s.CreateToken("bob", "script")
+	if err := s.DeleteUser("bob"); err != nil {
+		t.Fatalf("DeleteUser() error = %v", err)
+	}
+	if _, err := s.SessionUser(session); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("SessionUser() after delete error = %v", err)
+	}
+	if _, err := s.TokenUser(secret); !errors.Is(err, ErrUnauthenticated) {
+		t.Errorf("TokenUser() after delete error = %v", err)
+	}
+	if err := s.DeleteUser("bob"); !errors.Is(err, ErrUserNotFound) {
+		t.Errorf("DeleteUser() twice error = %v, want %v", err, ErrUserNotFound)
+	}
+	if users, _ := s.Users(); len(users) != 1 || users[0].Name != "alice" {
+		t.Errorf("Users() = %+v, want only alice", users)
+	}
+}
@@ -1,13 +1,118 @@
 package core
 
 import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 )
 
 type List struct {
+	Name string
+	// Owner is the user the list belongs to, empty for lists created without user, e.g. by the local CLI.
+	Owner string `yaml:",omitempty"`
+	// Members are the users the list is shared with.
+	Members []Member `yaml:",omitempty"`
+	Colour  RGB
+	// Statuses is the workflow of the tasks, see Workflow. Empty means DefaultStatuses.
+	Statuses []string `yaml:",omitempty"`
+	// ArchiveAfter is the number of days after which done tasks are moved to the archive, zero means never.
+	ArchiveAfter int `yaml:",omitempty"`
+	Items        []*Task
+}
+
+// Clone returns a deep copy of the list and its tasks, which may be changed without affecting the list.
+func (l List) Clone() List {
+	c := l
+	c.Members = slices.Clone(l.Members)
+	c.Statuses = slices.Clone(l.Statuses)
+	c.Items = make([]*Task, 0, len(l.Items))
+	for _, t := range l.Items {
+		task := t.Clone()
+		c.Items = append(c.Items, &task)
+	}
+	return c
+}
+
+// Group is an area of lists of a user, e.g. "Work", to keep many lists apart. Every user has their own groups, so a
+// shared list may be in different groups for its members.
+type Group struct {
 	Name   string
+	Owner  string `yaml:",omitempty"`
 	Colour RGB
-	Items  []*Task
+	// Lists are the names of the lists in the group in order. A list is in at most one group of a user.
+	Lists []string `yaml:",omitempty"`
+}
+
+// DefaultStatuses is the workflow of lists without their own.
+var DefaultStatuses = []string{"todo", "done"}
+
+// Workflow returns the statuses of the tasks of the list in order. New tasks start with the first one, the last one
+// means done.
+func (l List) Workflow() []string {
+	if len(l.Statuses) == 0 {
+		return DefaultStatuses
+	}
+	return l.Statuses
+}
+
+// StatusFor returns the status a task has in the list: the done status if the task is done, otherwise its own status
+// if the list has it, or else the first one.
+func (l List) StatusFor(t Task) string {
+	workflow := l.Workflow()
+	if t.Done {
+		return workflow[len(workflow)-1]
+	}
+	for _, s := range workflow[:len(workflow)-1] {
+		if s == t.Status {
+			return s
+		}
+	}
+	return workflow[0]
+}
+
+// ValidateStatuses checks a workflow, which needs at least two distinct, non-empty statuses.
+func ValidateStatuses(statuses []string) error {
+	if len(statuses) < 2 {
+		return errors.New("a workflow needs at least two statuses")
+	}
+	for i, s := range statuses {
+		if strings.TrimSpace(s) == "" {
+			return errors.New("empty status")
+		}
+		for _, other := range statuses[:i] {
+			if s == other {
+				return fmt.Errorf("duplicate status %q", s)
+			}
+		}
+	}
+	return nil
+}
+
+// Member is a user a list is shared with.
+type Member struct {
+	User string
+	Role Role
+}
+
+// Role returns the role of a user in the list, empty if the user has no access. The owner always has RoleOwner.
+func (l List) Role(user string) Role {
+	if user == l.Owner {
+		return RoleOwner
+	}
+	for _, m := range l.Members {
+		if m.User == user {
+			return m.Role
+		}
+	}
+	return ""
+}
+
+// ToArchive reports whether a task of the list is due to be moved to the archive at now, i.e. it was done more than
+// ArchiveAfter days ago.
+func (l List) ToArchive(t Task, now time.Time) bool {
+	return l.ArchiveAfter > 0 && t.Done && t.DoneOn.Before(now.AddDate(0, 0, -l.ArchiveAfter))
 }
 
 type RGB struct {
@@ -27,22 +132,234 @@ type Task struct {
 	Due      time.Time
 	Created  time.Time
 	DoneOn   time.Time
+	// Assignee is the user responsible for the task, empty if nobody is.
+	Assignee string `yaml:",omitempty"`
+	// History lists the changes of the task, oldest first.
+	History []Event `yaml:",omitempty"`
+	// Comments is the discussion of the task, oldest first.
+	Comments []Comment `yaml:",omitempty"`
+	// LastCommentID is the highest ID given to a comment, so that the IDs of deleted comments aren't given again.
+	LastCommentID int `yaml:",omitempty"`
+	// Reminders are the times the users of the task are reminded of it, see SetReminders.
+	Reminders []Reminder `yaml:",omitempty"`
+	// HiddenUntil is the start of a snoozed task, which is left out of lists until then.
+	HiddenUntil time.Time `yaml:",omitempty"`
+	// BlockedBy are the IDs of the tasks that must be done before this one.
+	BlockedBy []int `yaml:",omitempty"`
+	// Blocked is set while the task isn't done and any task in BlockedBy isn't either. It is kept up to date by the
+	// repository.
+	Blocked bool `yaml:",omitempty"`
+	// Status is the step of the workflow of the list the task is in, see List.StatusFor. Done is kept in sync with it.
+	Status string `yaml:",omitempty"`
+	// Estimate is the expected effort, zero if there is none.
+	Estimate time.Duration `yaml:",omitempty"`
+	// Tags label the task across lists, e.g. with the client to bill, see SetTags.
+	Tags []string `yaml:",omitempty"`
+	// TimeEntries is the time users spent on the task, oldest first, see Tracked.
+	TimeEntries []TimeEntry `yaml:",omitempty"`
+	// LastEntryID is the highest ID given to a time entry, so that the IDs of deleted entries aren't given again.
+	LastEntryID int `yaml:",omitempty"`
+	// Pomodoros are the completed focus sessions on the task, oldest first.
+	Pomodoros []Pomodoro `yaml:",omitempty"`
+	// Archived is the time the task was moved to the archive, zero for tasks in their list.
+	Archived time.Time `yaml:",omitempty"`
+}
+
+// Reminder is a point in time at which a task reminds its users. It fires only once.
+type Reminder struct {
+	At time.Time
+	// Relative reminders are Before the due date, At follows changes of the due date.
+	Relative bool          `yaml:",omitempty"`
+	Before   time.Duration `yaml:",omitempty"`
+	// Fired is set once the reminder has been sent.
+	Fired bool `yaml:",omitempty"`
+}
+
+// ParseReminder parses an absolute reminder, e.g. "2026-10-20T13:30" in local time or in RFC 3339 format, or one
+// relative to the due date, e.g. "30m before" or "0s before" for the due date itself.
+func ParseReminder(s string) (Reminder, error) {
+	if before, ok := strings.CutSuffix(s, " before"); ok {
+		d, err := time.ParseDuration(before)
+		if err != nil || d < 0 {
+			return Reminder{}, fmt.Errorf("invalid reminder %q, must be a duration like 30m or 2h before the due date", s)
+		}
+		return Reminder{Relative: true, Before: d}, nil
+	}
+	at, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		if at, err = time.ParseInLocation("2006-01-02T15:04", s, time.Local); err != nil {
+			return Reminder{}, fmt.Errorf("invalid reminder %q, must be a time or a duration before the due date", s)
+		}
+	}
+	return Reminder{At: at}, nil
+}
+
+// String formats a reminder the way ParseReminder parses it.
+func (r Reminder) String() string {
+	if r.Relative {
+		return r.Before.String() + " before"
+	}
+	return r.At.Format(time.RFC3339)
+}
+
+// SetTags replaces the tags of the task. Tags are trimmed and kept in the given order without duplicates, they must
+// not be empty or contain commas.
+func (t *Task) SetTags(tags []string) error {
+	res := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || strings.Contains(tag, ",") {
+			return fmt.Errorf("invalid tag %q, must not be empty or contain commas", tag)
+		}
+		if !slices.Contains(res, tag) {
+			res = append(res, tag)
+		}
+	}
+	t.Tags = res
+	if len(res) == 0 {
+		t.Tags = nil
+	}
+	return nil
+}
+
+// SetReminders replaces the reminders of the task. Relative reminders are scheduled before the due date, so they need
+// one; call SetReminders again after changing the due date. Reminders at the same time as before keep their fired
+// state, so they don't fire again, moved reminders fire at their new time.
+func (t *Task) SetReminders(reminders []Reminder) error {
+	scheduled := make([]Reminder, 0, len(reminders))
+	for _, r := range reminders {
+		if r.Relative {
+			if !t.HasDueDate() {
+				return errors.New("reminders before the due date need a due date")
+			}
+			r.At = t.Due.Add(-r.Before)
+		}
+		r.Fired = false
+		for _, old := range t.Reminders {
+			if old.At.Equal(r.At) && old.Relative == r.Relative && old.Before == r.Before {
+				r.Fired = old.Fired
+			}
+		}
+		scheduled = append(scheduled, r)
+	}
+	if len(scheduled) == 0 {
+		scheduled = nil
+	}
+	t.Reminders = scheduled
+	return nil
+}
+
+// Comment is a comment on a task. Its ID is unique within the task.
+type Comment struct {
+	ID      int
+	Author  string `yaml:",omitempty"`
+	Text    string
+	Created time.Time
+	Edited  time.Time `yaml:",omitempty"`
+}
+
+// TimeEntry is time a user spent on a task, tracked with a timer or added afterwards. Its ID is unique within the
+// task.
+type TimeEntry struct {
+	ID    int
+	User  string `yaml:",omitempty"`
+	Start time.Time
+	// End is zero while the timer is running.
+	End  time.Time `yaml:",omitempty"`
+	Note string    `yaml:",omitempty"`
+}
+
+// Running returns true while the timer of the entry hasn't been stopped.
+func (e TimeEntry) Running() bool {
+	return e.End.IsZero()
+}
+
+// Duration returns the tracked time, running timers count until now.
+func (e TimeEntry) Duration(now time.Time) time.Duration {
+	if e.Running() {
+		return max(now.Sub(e.Start), 0)
+	}
+	return e.End.Sub(e.Start)
+}
+
+// Pomodoro is a completed focus session of a user on a task.
+type Pomodoro struct {
+	User  string `yaml:",omitempty"`
+	Start time.Time
+	Focus time.Duration
+}
+
+// Event is a change of a task field, e.g. a reassignment.
+type Event struct {
+	Time time.Time
+	// User made the change, it is empty for changes without user, e.g. by the local CLI.
+	User  string `yaml:",omitempty"`
+	Field string
+	From  string `yaml:",omitempty"`
+	To    string `yaml:",omitempty"`
 }
 
 // IsOverdue returns true if the task is overdue. A task is overdue if it is not done and the due date is in the past.
 func (t Task) IsOverdue() bool {
+	return t.IsOverdueAt(time.Now())
+}
+
+// IsOverdueAt returns true if the task is overdue at now, see IsOverdue.
+func (t Task) IsOverdueAt(now time.Time) bool {
 	if t.Done {
 		return false
 	}
 	if !t.HasDueDate() {
 		return false
 	}
-	now := time.Now()
 	if t.AllDay {
 		today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		return t.Due.Before(today)
 	}
-	return t.Due.Before(time.Now())
+	return t.Due.Before(now)
+}
+
+// CurrentStatus returns the status of the task. Tasks from before there were statuses are "todo" or "done".
+func (t Task) CurrentStatus() string {
+	if t.Status != "" {
+		return t.Status
+	}
+	if t.Done {
+		return DefaultStatuses[1]
+	}
+	return DefaultStatuses[0]
+}
+
+// IsArchived returns true if the task has been moved to the archive.
+func (t Task) IsArchived() bool {
+	return !t.Archived.IsZero()
+}
+
+// Clone returns a deep copy of the task, which may be changed without affecting the task.
+func (t Task) Clone() Task {
+	c := t
+	c.History = slices.Clone(t.History)
+	c.Comments = slices.Clone(t.Comments)
+	c.Reminders = slices.Clone(t.Reminders)
+	c.BlockedBy = slices.Clone(t.BlockedBy)
+	c.Tags = slices.Clone(t.Tags)
+	c.TimeEntries = slices.Clone(t.TimeEntries)
+	c.Pomodoros = slices.Clone(t.Pomodoros)
+	return c
+}
+
+// IsHidden returns true if the task is snoozed, i.e. its start time is in the future.
+func (t Task) IsHidden() bool {
+	return t.HiddenUntil.After(time.Now())
+}
+
+// Tracked returns the time spent on the task by all users, running timers count until now.
+func (t Task) Tracked(now time.Time) time.Duration {
+	var d time.Duration
+	for _, e := range t.TimeEntries {
+		d += e.Duration(now)
+	}
+	return d
 }
 
 // HasDueDate returns true if the task has a due date set.
@@ -70,6 +387,33 @@ const (
 	DueNone DueType = ""
 )
 
+// Role is the permission of a user in a shared list. Each role includes the permissions of the roles before it.
+type Role string
+
+const (
+	// RoleViewer can see the list and its tasks.
+	RoleViewer Role = "viewer"
+	// RoleEditor can also add, change, move and delete tasks.
+	RoleEditor Role = "editor"
+	// RoleOwner can also change, share and delete the list.
+	RoleOwner Role = "owner"
+)
+
+// ParseRole parses a role.
+func ParseRole(s string) (Role, error) {
+	switch Role(s) {
+	case RoleViewer, RoleEditor, RoleOwner:
+		return Role(s), nil
+	}
+	return "", fmt.Errorf("invalid role %q, must be viewer, editor or owner", s)
+}
+
+// Includes reports whether r has at least the permissions of other. The empty role includes nothing.
+func (r Role) Includes(other Role) bool {
+	rank := map[Role]int{RoleViewer: 1, RoleEditor: 2, RoleOwner: 3}
+	return r != "" && rank[r] >= rank[other]
+}
+
 // Priorities
 const (
 	PrioLowest  = -2
@@ -1,6 +1,7 @@
 package core
 
 import (
+	"slices"
 	"testing"
 	"time"
 )
@@ -47,4 +48,146 @@ func TestIsOverdue(t *testing.T) {
 			}
 		})
 	}
+
+	// IsOverdueAt checks at another time, all-day tasks are overdue from the next day on
+	allDay := Task{DueType: DueOn, Due: now, AllDay: true}
+	if allDay.IsOverdueAt(now) || !allDay.IsOverdueAt(now.AddDate(0, 0, 1)) {
+		t.Error("Task.IsOverdueAt() of all-day task isn't overdue from the next day on")
+	}
+}
+
+func TestListRole(t *testing.T) {
+	l := List{Owner: "alice", Members: []Member{{User: "bob", Role: RoleEditor}}}
+	var tests = []struct {
+		name string
+		user string
+		min  Role
+		want bool
+	}{
+		{"Owner", "alice", RoleOwner, true},
+		{"Editor can edit", "bob", RoleEditor, true},
+		{"Editor can view", "bob", RoleViewer, true},
+		{"Editor can't share", "bob", RoleOwner, false},
+		{"Stranger can't view", "carol", RoleViewer, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := l.Role(tt.user).Includes(tt.min); got != tt.want {
+				t.Errorf("List.Role(%q).Includes(%q) = %v, want %v", tt.user, tt.min, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestList_StatusFor(t *testing.T) {
+	board := List{Statuses: []string{"todo", "in progress", "review", "done"}}
+	var tests = []struct {
+		name string
+		list List
+		task Task
+		want string
+	}{
+		{"Own status", board, Task{Status: "review"}, "review"},
+		{"Done", board, Task{Status: "review", Done: true}, "done"},
+		{"Done status of undone task", board, Task{Status: "done"}, "todo"},
+		{"Unknown status", board, Task{Status: "doing"}, "todo"},
+		{"Default workflow", List{}, Task{Status: "review"}, "todo"},
+		{"Default workflow done", List{}, Task{Done: true}, "done"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.list.StatusFor(tt.task); got != tt.want {
+				t.Errorf("List.StatusFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestList_Clone(t *testing.T) {
+	l := List{Members: []Member{{User: "bob", Role: RoleViewer}}, Statuses: []string{"todo", "done"}, Items: []*Task{{
+		History:     []Event{{Field: "done"}},
+		Comments:    []Comment{{ID: 1}},
+		Reminders:   []Reminder{{}},
+		BlockedBy:   []int{2},
+		Tags:        []string{"acme"},
+		TimeEntries: []TimeEntry{{ID: 1}},
+		Pomodoros:   []Pomodoro{{User: "bob"}},
+	}}}
+
+	c := l.Clone()
+	c.Members[0].User = "carol"
+	c.Statuses[0] = "open"
+	task := c.Items[0]
+	task.Title = "changed"
+	task.History[0].Field = "title"
+	task.Comments[0].ID = 2
+	task.Reminders[0].Fired = true
+	task.BlockedBy[0] = 3
+	task.Tags[0] = "other"
+	task.TimeEntries[0].ID = 2
+	task.Pomodoros[0].User = "carol"
+
+	orig := l.Items[0]
+	if l.Members[0].User != "bob" || l.Statuses[0] != "todo" || orig.Title != "" || orig.History[0].Field != "done" ||
+		orig.Comments[0].ID != 1 || orig.Reminders[0].Fired || orig.BlockedBy[0] != 2 || orig.TimeEntries[0].ID != 1 ||
+		orig.Pomodoros[0].User != "bob" || orig.Tags[0] != "acme" {
+		t.Errorf("changing the clone changed the list: %+v, %+v", l, *orig)
+	}
+}
+
+func TestTask_SetTags(t *testing.T) {
+	var task Task
+	if err := task.SetTags([]string{" acme ", "billable", "acme"}); err != nil || !slices.Equal(task.Tags, []string{"acme", "billable"}) {
+		t.Errorf("SetTags() = %v, %v, want [acme billable]", task.Tags, err)
+	}
+	for _, tags := range [][]string{{" "}, {"a,b"}} {
+		if err := task.SetTags(tags); err == nil {
+			t.Errorf("SetTags(%q) succeeded", tags)
+		}
+	}
+	if err := task.SetTags(nil); err != nil || task.Tags != nil {
+		t.Errorf("SetTags(nil) = %v, %v, want no tags", task.Tags, err)
+	}
+}
+
+func TestTask_SetReminders(t *testing.T) {
+	due := time.Date(2026, 10, 20, 14, 0, 0, 0, time.Local)
+	task := Task{DueType: DueOn, Due: due}
+	before, _ := ParseReminder("30m before")
+	at, err := ParseReminder("2026-10-20T09:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = task.SetReminders([]Reminder{before, at}); err != nil {
+		t.Fatal(err)
+	}
+	if got := task.Reminders[0].At; !got.Equal(due.Add(-30 * time.Minute)) {
+		t.Errorf("relative reminder at %v", got)
+	}
+	task.Reminders[0].Fired = true
+	task.Reminders[1].Fired = true
+
+	// unchanged reminders stay fired, the relative one moves with the due date
+	task.Due = due.Add(time.Hour)
+	if err = task.SetReminders([]Reminder{before, at}); err != nil {
+		t.Fatal(err)
+	}
+	if task.Reminders[0].Fired || !task.Reminders[1].Fired {
+		t.Errorf("fired = %v, %v, want false, true", task.Reminders[0].Fired, task.Reminders[1].Fired)
+	}
+	if got, err := ParseReminder(task.Reminders[0].String()); err != nil || got.Before != 30*time.Minute {
+		t.Errorf("ParseReminder(%q) = %+v, %v", task.Reminders[0].String(), got, err)
+	}
+
+	if err = (&Task{}).SetReminders([]Reminder{before}); err == nil {
+		t.Error("relative reminder without due date")
+	}
+	for _, s := range []string{"tomorrow", "-5m before", "30 before"} {
+		if _, err = ParseReminder(s); err == nil {
+			t.Errorf("ParseReminder(%q) succeeded", s)
+		}
+	}
 }
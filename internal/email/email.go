@@ -0,0 +1,329 @@
+// Package email sends reminders and a daily digest of the tasks of users by email.
+//
+// The SMTP server, the digest and the recipients are configured in a YAML file, see Config. The digest lists the
+// overdue tasks, the tasks due today and optionally the tasks of a filtered list of each recipient. Digests without
+// tasks aren't sent.
+package email
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// DefaultPort is the SMTP submission port used if the config has none.
+const DefaultPort = 587
+
+// Config configures the SMTP server, the digest and the recipients of emails.
+type Config struct {
+	Host string
+	Port int
+	// StartTLS upgrades the connection to TLS before authenticating, the server must support it.
+	StartTLS bool `yaml:"starttls"`
+	// Username and Password are used for PLAIN authentication if Username is set.
+	Username string
+	Password string
+	// From is the sender address.
+	From       string
+	Digest     Digest
+	Recipients []Recipient
+}
+
+// Digest configures the daily digest.
+type Digest struct {
+	// Time is the local time the digest is sent at, e.g. "07:30". Empty disables the digest.
+	Time string
+	// List is the name of a filtered list of the recipients whose tasks are included, e.g. "Soon". Optional.
+	List string `yaml:",omitempty"`
+}
+
+// Recipient receives the reminders and the digest of a user.
+type Recipient struct {
+	// User whose lists are included, empty for all lists, e.g. if authentication is disabled.
+	User    string
+	Address string
+}
+
+// LoadConfig reads a config from a YAML file. The error wraps os.ErrNotExist if there is no file.
+func LoadConfig(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var cfg Config
+	if err = yaml.Unmarshal(data, &cfg); err != nil {
+		return Config{}, fmt.Errorf("invalid mail config: %w", err)
+	}
+	return cfg, nil
+}
+
+func (cfg Config) validate() error {
+	if cfg.Host == "" {
+		return errors.New("mail config: host missing")
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return fmt.Errorf("mail config: invalid sender %q: %w", cfg.From, err)
+	}
+	if cfg.Digest.Time != "" {
+		if _, err := time.Parse("15:04", cfg.Digest.Time); err != nil {
+			return fmt.Errorf("mail config: invalid digest time %q, want e.g. 07:30", cfg.Digest.Time)
+		}
+	}
+	for _, r := range cfg.Recipients {
+		if _, err := mail.ParseAddress(r.Address); err != nil {
+			return fmt.Errorf("mail config: invalid address %q of %q: %w", r.Address, r.User, err)
+		}
+	}
+	return nil
+}
+
+// Mailer emails reminders and digests to the recipients. It is safe for concurrent use.
+type Mailer struct {
+	cfg  Config
+	repo *repository.Repository
+
+	wg  sync.WaitGroup
+	log *log.Entry
+	now func() time.Time
+}
+
+// NewMailer returns a mailer for the tasks in repo, which must be the repository of all users. Pass it to the reminder
+// scheduler to send reminders and run Run for the digest.
+func NewMailer(cfg Config, repo *repository.Repository, logger *log.Entry) (*Mailer, error) {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultPort
+	}
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	return &Mailer{cfg: cfg, repo: repo, log: logger, now: time.Now}, nil
+}
+
+// Close waits for the emails being sent.
+func (m *Mailer) Close() {
+	m.wg.Wait()
+}
+
+// Notify emails reminders to the recipients who can see the task in the background. Other changes are ignored.
+func (m *Mailer) Notify(c repository.Change) {
+	if c.Type != repository.TaskReminder || c.Task == nil {
+		return
+	}
+	subject, body := reminderMessage(*c.Task)
+	for _, r := range m.cfg.Recipients {
+		if !c.VisibleTo(r.User) {
+			continue
+		}
+		m.wg.Add(1)
+		go func(r Recipient) {
+			defer m.wg.Done()
+			if err := m.send(r.Address, subject, body); err != nil {
+				m.log.WithError(err).WithFields(log.Fields{"task": c.Task.ID, "to": r.Address}).Error("Failed to email reminder.")
+			}
+		}(r)
+	}
+}
+
+// Run sends the digest every day at the configured time until ctx is cancelled. It returns right away if the digest is
+// disabled.
+func (m *Mailer) Run(ctx context.Context) {
+	if m.cfg.Digest.Time == "" {
+		return
+	}
+	for {
+		timer := time.NewTimer(m.nextDigest().Sub(m.now()))
+		select {
+		case <-timer.C:
+			m.sendDigests()
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// nextDigest returns the next time the digest is due.
+func (m *Mailer) nextDigest() time.Time {
+	at, _ := time.Parse("15:04", m.cfg.Digest.Time)
+	now := m.now()
+	next := time.Date(now.Year(), now.Month(), now.Day(), at.Hour(), at.Minute(), 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// sendDigests sends the digest to all recipients.
+func (m *Mailer) sendDigests() {
+	for _, r := range m.cfg.Recipients {
+		subject, body, ok := m.digest(r.User)
+		if !ok {
+			continue
+		}
+		if err := m.send(r.Address, subject, body); err != nil {
+			m.log.WithError(err).WithField("to", r.Address).Error("Failed to email digest.")
+		}
+	}
+}
+
+// section is a part of the digest.
+type section struct {
+	title string
+	tasks []core.Task
+}
+
+// digest returns the digest of a user. ok is false if there are no tasks to report.
+func (m *Mailer) digest(user string) (subject, body string, ok bool) {
+	repo := m.repo.As(user)
+	overdue, _ := filter.NewRule("overdue", "true")
+	pending, _ := filter.NewRule("done", "false")
+	dueOnToday, _ := filter.NewRule("due_on", "0")
+	dueByToday, _ := filter.NewRule("due_by", "0")
+
+	sections := []section{
+		{"Overdue", repo.FindTasks(filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{overdue}}}})},
+		{"Due today", repo.FindTasks(filter.Filter{RuleSets: []filter.RuleSet{
+			{Rules: []filter.Rule{pending, dueOnToday}},
+			{Rules: []filter.Rule{pending, dueByToday}},
+		}})},
+	}
+	if name := m.cfg.Digest.List; name != "" {
+		tasks, err := repo.GetFilteredTasks(name)
+		if err != nil {
+			m.log.WithError(err).WithFields(log.Fields{"user": user, "list": name}).Warn("Digest list not found.")
+		}
+		s := section{title: name}
+		for _, t := range tasks {
+			s.tasks = append(s.tasks, *t)
+		}
+		sections = append(sections, s)
+	}
+
+	var b strings.Builder
+	for _, s := range sections {
+		if len(s.tasks) == 0 {
+			continue
+		}
+		ok = true
+		fmt.Fprintf(&b, "%s\n\n", s.title)
+		for _, t := range s.tasks {
+			fmt.Fprintf(&b, "- %s\n", taskLine(t))
+		}
+		b.WriteString("\n")
+	}
+	return "Tasks for " + m.now().Format("Monday, 2 January"), b.String(), ok
+}
+
+// reminderMessage returns the subject and body of the email reminding of a task.
+func reminderMessage(t core.Task) (subject, body string) {
+	return "Reminder: " + t.Title, taskLine(t) + "\n"
+}
+
+// taskLine describes a task in one line, e.g. "Call client (Work, due by Mon 2 Jan 15:04)".
+func taskLine(t core.Task) string {
+	details := []string{t.List}
+	if t.HasDueDate() {
+		layout := "Mon 2 Jan 15:04"
+		if t.AllDay {
+			layout = "Mon 2 Jan"
+		}
+		kind := "due by"
+		if t.HasDueOnDate() {
+			kind = "due on"
+		}
+		details = append(details, kind+" "+t.Due.Local().Format(layout))
+	}
+	if t.Assignee != "" {
+		details = append(details, "assigned to "+t.Assignee)
+	}
+	return fmt.Sprintf("%s (%s)", t.Title, strings.Join(details, ", "))
+}
+
+// send emails a plain text message.
+func (m *Mailer) send(to, subject, body string) error {
+	msg, err := m.message(to, subject, body)
+	if err != nil {
+		return err
+	}
+
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(m.cfg.Host, strconv.Itoa(m.cfg.Port)), 10*time.Second)
+	if err != nil {
+		return err
+	}
+	c, err := smtp.NewClient(conn, m.cfg.Host)
+	if err != nil {
+		_ = conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if m.cfg.StartTLS {
+		if err = c.StartTLS(&tls.Config{ServerName: m.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if m.cfg.Username != "" {
+		if err = c.Auth(smtp.PlainAuth("", m.cfg.Username, m.cfg.Password, m.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	from, _ := mail.ParseAddress(m.cfg.From)
+	rcpt, _ := mail.ParseAddress(to)
+	if err = c.Mail(from.Address); err != nil {
+		return err
+	}
+	if err = c.Rcpt(rcpt.Address); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err = w.Write(msg); err != nil {
+		return err
+	}
+	if err = w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
+
+// message returns a plain text email with the given headers.
+func (m *Mailer) message(to, subject, body string) ([]byte, error) {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "From: %s\r\n", m.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", to)
+	fmt.Fprintf(&b, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	fmt.Fprintf(&b, "Date: %s\r\n", m.now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+
+	w := quotedprintable.NewWriter(&b)
+	if _, err := w.Write([]byte(body)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
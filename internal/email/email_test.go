@@ -0,0 +1,226 @@
+package email
+
+import (
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+// smtpServer is an in-process SMTP stand-in that accepts all messages and authentications.
+type smtpServer struct {
+	ln net.Listener
+
+	mu       sync.Mutex
+	auth     []string
+	messages []received
+}
+
+type received struct {
+	to   string
+	msg  *mail.Message
+	body string
+}
+
+func newSMTPServer(t *testing.T) *smtpServer {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	s := &smtpServer{ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.handle(conn)
+		}
+	}()
+	return s
+}
+
+func (s *smtpServer) handle(conn net.Conn) {
+	defer conn.Close()
+	c := textproto.NewConn(conn)
+	_ = c.PrintfLine("220 localhost ESMTP")
+	var to string
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO":
+			_ = c.PrintfLine("250-localhost\r\n250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = append(s.auth, arg)
+			s.mu.Unlock()
+			_ = c.PrintfLine("235 OK")
+		case "RCPT":
+			to = strings.Trim(strings.TrimPrefix(arg, "TO:"), "<>")
+			_ = c.PrintfLine("250 OK")
+		case "DATA":
+			_ = c.PrintfLine("354 Go ahead")
+			data, err := c.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			msg, err := mail.ReadMessage(strings.NewReader(string(data)))
+			if err != nil {
+				return
+			}
+			body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+			s.mu.Lock()
+			s.messages = append(s.messages, received{to: to, msg: msg, body: string(body)})
+			s.mu.Unlock()
+			_ = c.PrintfLine("250 OK")
+		case "QUIT":
+			_ = c.PrintfLine("221 Bye")
+			return
+		default:
+			_ = c.PrintfLine("250 OK")
+		}
+	}
+}
+
+func newTestMailer(t *testing.T, srv *smtpServer, repo *repository.Repository, digest Digest) *Mailer {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	host, port, _ := net.SplitHostPort(srv.ln.Addr().String())
+	cfg := Config{
+		Host:     host,
+		Username: "gotasks",
+		Password: "secret",
+		From:     "Gotasks <gotasks@example.com>",
+		Digest:   digest,
+		Recipients: []Recipient{
+			{User: "alice", Address: "alice@example.com"},
+			{User: "bob", Address: "Bob <bob@example.com>"},
+		},
+	}
+	cfg.Port, _ = strconv.Atoi(port)
+	m, err := NewMailer(cfg, repo, log.NewEntry(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMailer_Notify(t *testing.T) {
+	srv := newSMTPServer(t)
+	m := newTestMailer(t, srv, repository.NewRepository(&storage.Fake{}), Digest{})
+	task := &core.Task{ID: 1, Title: "Standup ☕", List: "Work"}
+	list := core.List{Name: "Work", Owner: "alice"}
+
+	m.Notify(repository.Change{Type: repository.TaskReminder, List: list, Task: task})
+	m.Notify(repository.Change{Type: repository.TaskUpdated, List: list, Task: task})
+	m.Close()
+
+	if len(srv.messages) != 1 {
+		t.Fatalf("got %d emails, want one reminder for alice", len(srv.messages))
+	}
+	got := srv.messages[0]
+	subject, _ := new(mime.WordDecoder).DecodeHeader(got.msg.Header.Get("Subject"))
+	if got.to != "alice@example.com" || subject != "Reminder: Standup ☕" || got.body != "Standup ☕ (Work)\n" {
+		t.Errorf("email to %s: %q, %q", got.to, subject, got.body)
+	}
+	if len(srv.auth) != 1 || !strings.HasPrefix(srv.auth[0], "PLAIN ") {
+		t.Errorf("auth = %v, want PLAIN", srv.auth)
+	}
+}
+
+func TestMailer_Digest(t *testing.T) {
+	srv := newSMTPServer(t)
+	repo := repository.NewRepository(&storage.Fake{})
+	alice := repo.As("alice")
+	if _, err := alice.AddList("Work", core.RGB{}); err != nil {
+		t.Fatal(err)
+	}
+	today := time.Now()
+	tasks := []api.TaskAdd{
+		{Title: "Overdue", AllDay: true, DueType: core.DueBy, Due: today.AddDate(0, 0, -1)},
+		{Title: "Today", AllDay: true, DueType: core.DueOn, Due: today},
+		{Title: "Tomorrow", Priority: core.PrioHigh, AllDay: true, DueType: core.DueBy, Due: today.AddDate(0, 0, 1)},
+		{Title: "Someday"},
+	}
+	for _, task := range tasks {
+		if _, err := alice.AddItem("Work", task); err != nil {
+			t.Fatal(err)
+		}
+	}
+	important, _ := filter.NewRule("prio_min", strconv.Itoa(core.PrioHigh))
+	if _, err := alice.AddFilteredList("Important", filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{important}}}}); err != nil {
+		t.Fatal(err)
+	}
+	m := newTestMailer(t, srv, repo, Digest{Time: "07:30", List: "Important"})
+
+	m.sendDigests()
+	if len(srv.messages) != 1 || srv.messages[0].to != "alice@example.com" {
+		t.Fatalf("emails = %+v, want only alice's digest as bob has no tasks", srv.messages)
+	}
+	body := srv.messages[0].body
+	for _, want := range []string{"Overdue\n\n- Overdue (Work, due by", "Due today\n\n- Today (Work, due on", "Important\n\n- Tomorrow"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("digest doesn't contain %q:\n%s", want, body)
+		}
+	}
+	if strings.Contains(body, "Someday") {
+		t.Errorf("digest contains task without due date:\n%s", body)
+	}
+}
+
+func TestMailer_nextDigest(t *testing.T) {
+	m := &Mailer{cfg: Config{Digest: Digest{Time: "07:30"}}}
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2024, 5, 1, 6, 0, 0, 0, time.Local), time.Date(2024, 5, 1, 7, 30, 0, 0, time.Local)},
+		{time.Date(2024, 5, 1, 7, 30, 0, 0, time.Local), time.Date(2024, 5, 2, 7, 30, 0, 0, time.Local)},
+		{time.Date(2024, 5, 31, 20, 0, 0, 0, time.Local), time.Date(2024, 6, 1, 7, 30, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		m.now = func() time.Time { return tt.now }
+		if got := m.nextDigest(); !got.Equal(tt.want) {
+			t.Errorf("nextDigest() at %v = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
+
+func TestNewMailer(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  Config
+	}{
+		{"Missing host", Config{From: "gotasks@example.com"}},
+		{"Invalid sender", Config{Host: "localhost", From: "gotasks"}},
+		{"Invalid digest time", Config{Host: "localhost", From: "gotasks@example.com", Digest: Digest{Time: "7am"}}},
+		{"Invalid recipient", Config{Host: "localhost", From: "gotasks@example.com", Recipients: []Recipient{{User: "alice"}}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewMailer(tt.cfg, nil, nil); err == nil {
+				t.Error("no error")
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+// Package events passes changes of tasks and lists to the connected clients of the users who can see them, e.g. to
+// the web UI via server-sent events.
+package events
+
+import (
+	"sync"
+
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// buffer is the number of changes kept for a slow subscriber before further changes are dropped.
+const buffer = 16
+
+type subscription struct {
+	user    string
+	changes chan repository.Change
+}
+
+// Broker passes changes to subscribers. It is safe for concurrent use.
+type Broker struct {
+	mu   sync.Mutex
+	subs map[*subscription]struct{}
+}
+
+func NewBroker() *Broker {
+	return &Broker{subs: make(map[*subscription]struct{})}
+}
+
+// Subscribe returns the channel of the changes visible to a user, until cancel is called. Changes are dropped if the
+// subscriber doesn't keep up.
+func (b *Broker) Subscribe(user string) (changes <-chan repository.Change, cancel func()) {
+	sub := &subscription{user: user, changes: make(chan repository.Change, buffer)}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.subs[sub] = struct{}{}
+
+	return sub.changes, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, sub)
+	}
+}
+
+// Notify passes a change to all subscribers who can see it, without blocking.
+func (b *Broker) Notify(c repository.Change) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subs {
+		if !c.VisibleTo(sub.user) {
+			continue
+		}
+		select {
+		case sub.changes <- c:
+		default:
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+func TestBroker(t *testing.T) {
+	b := NewBroker()
+	alice, cancelAlice := b.Subscribe("alice")
+	bob, cancelBob := b.Subscribe("bob")
+	defer cancelBob()
+
+	b.Notify(repository.Change{Type: repository.ListUpdated, List: core.List{Name: "Home", Owner: "alice"}})
+	if c := <-alice; c.List.Name != "Home" {
+		t.Errorf("alice got %+v", c)
+	}
+	select {
+	case c := <-bob:
+		t.Errorf("bob got alice's change %+v", c)
+	default:
+	}
+
+	// slow subscribers don't block
+	cancelAlice()
+	for range buffer + 1 {
+		b.Notify(repository.Change{Type: repository.ListUpdated, List: core.List{Name: "Work", Owner: "bob"}})
+	}
+	if len(bob) != buffer {
+		t.Errorf("bob has %d changes, want %d", len(bob), buffer)
+	}
+}
@@ -2,6 +2,7 @@ package filter
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -10,7 +11,9 @@ import (
 )
 
 type List struct {
-	Name   string
+	Name string
+	// Owner is the user the filtered list belongs to, see core.List.
+	Owner  string `yaml:",omitempty"`
 	Filter Filter
 }
 
@@ -28,6 +31,61 @@ func (f Filter) Evaluate(task core.Task) bool {
 	return false
 }
 
+// Uses reports whether any rule of the filter is on the given field.
+func (f Filter) Uses(field string) bool {
+	for _, set := range f.RuleSets {
+		for _, rule := range set.Rules {
+			if rule.Field == field {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ForUser returns a copy of the filter with the value "me" of assignee rules replaced by the given user, so that one
+// filtered list can show every user their own tasks. Without user the filter is returned as it is.
+func (f Filter) ForUser(user string) Filter {
+	if user == "" {
+		return f
+	}
+	resolved := Filter{RuleSets: make([]RuleSet, len(f.RuleSets))}
+	for i, set := range f.RuleSets {
+		rules := make([]Rule, len(set.Rules))
+		for j, rule := range set.Rules {
+			rules[j] = rule
+			if rule.Field == "assignee" && rule.Value == assigneeMe {
+				rules[j], _ = NewRule("assignee", user)
+			}
+		}
+		resolved.RuleSets[i] = RuleSet{Rules: rules}
+	}
+	return resolved
+}
+
+// ForGroups returns a copy of the filter whose group rules match the tasks in the lists of their group, as returned by
+// lists. Unresolved group rules match nothing, as groups belong to users, see core.Group.
+func (f Filter) ForGroups(lists func(group string) []string) Filter {
+	if !f.Uses("group") {
+		return f
+	}
+	resolved := Filter{RuleSets: make([]RuleSet, len(f.RuleSets))}
+	for i, set := range f.RuleSets {
+		rules := make([]Rule, len(set.Rules))
+		for j, rule := range set.Rules {
+			rules[j] = rule
+			if rule.Field == "group" {
+				names := lists(fmt.Sprint(rule.Value))
+				rules[j].compare = func(task core.Task) bool {
+					return slices.Contains(names, task.List)
+				}
+			}
+		}
+		resolved.RuleSets[i] = RuleSet{Rules: rules}
+	}
+	return resolved
+}
+
 // RuleSet is a set of rules. All rules in the set must be true for the set to be true.
 type RuleSet struct {
 	Rules []Rule
@@ -83,6 +141,9 @@ func (r *Rule) UnmarshalYAML(unmarshal func(interface{}) error) error {
 
 type comparisonFunc func(core.Task) bool
 
+// assigneeMe is the value of assignee rules matching the tasks of the requesting user.
+const assigneeMe = "me"
+
 func newComparison(field, value string) (comparisonFunc, error) {
 
 	switch field {
@@ -106,6 +167,37 @@ func newComparison(field, value string) (comparisonFunc, error) {
 		return func(task core.Task) bool {
 			return task.IsOverdue() == (value == "true")
 		}, nil
+	case "status": // value is comma separated string of statuses, see core.Task.CurrentStatus
+		statuses := strings.Split(value, ",")
+		return func(task core.Task) bool {
+			return slices.Contains(statuses, task.CurrentStatus())
+		}, nil
+	case "blocked": // value is boolean, true matches tasks waiting for other tasks
+		return func(task core.Task) bool {
+			return task.Blocked == (value == "true")
+		}, nil
+	case "available": // value is boolean, false matches snoozed tasks
+		return func(task core.Task) bool {
+			return !task.IsHidden() == (value == "true")
+		}, nil
+	case "archived": // value is boolean, true matches archived tasks, which are only searched by filters with this rule
+		return func(task core.Task) bool {
+			return task.IsArchived() == (value == "true")
+		}, nil
+	case "group": // value is the name of a group of lists, only matches once resolved, see Filter.ForGroups
+		return func(_ core.Task) bool {
+			return false
+		}, nil
+	case "assignee": // value is a user name, "me" for the requesting user, see Filter.ForUser, or empty for unassigned
+		if value == assigneeMe {
+			// only matches once resolved for a user
+			return func(_ core.Task) bool {
+				return false
+			}, nil
+		}
+		return func(task core.Task) bool {
+			return task.Assignee == value
+		}, nil
 	case "prio_min": // value is integer, means this priority or higher
 		priority, err := strconv.Atoi(value)
 		if err != nil {
@@ -35,6 +35,26 @@ func TestNewComparison(t *testing.T) {
 			task:  core.Task{List: "Work"},
 			want:  true,
 		},
+		{
+			field: "assignee",
+			value: "alice",
+			task:  core.Task{Assignee: "alice"},
+			want:  true,
+		},
+		{
+			name:  "assignee unassigned",
+			field: "assignee",
+			value: "",
+			task:  core.Task{Assignee: "alice"},
+			want:  false,
+		},
+		{
+			name:  "assignee me unresolved",
+			field: "assignee",
+			value: "me",
+			task:  core.Task{Assignee: "me"},
+			want:  false,
+		},
 		{
 			name:  "list not in",
 			field: "list",
@@ -48,6 +68,51 @@ func TestNewComparison(t *testing.T) {
 			task:  core.Task{Done: true},
 			want:  true,
 		},
+		{
+			field: "status",
+			value: "in progress,review",
+			task:  core.Task{Status: "review"},
+			want:  true,
+		},
+		{
+			name:  "status without status",
+			field: "status",
+			value: "done",
+			task:  core.Task{Done: true},
+			want:  true,
+		},
+		{
+			field: "blocked",
+			value: "false",
+			task:  core.Task{Blocked: true},
+			want:  false,
+		},
+		{
+			field: "available",
+			value: "true",
+			task:  core.Task{HiddenUntil: time.Now().Add(-time.Hour)},
+			want:  true,
+		},
+		{
+			name:  "available snoozed",
+			field: "available",
+			value: "true",
+			task:  core.Task{HiddenUntil: time.Now().Add(time.Hour)},
+			want:  false,
+		},
+		{
+			field: "archived",
+			value: "true",
+			task:  core.Task{Done: true, Archived: time.Now()},
+			want:  true,
+		},
+		{
+			name:  "archived active",
+			field: "archived",
+			value: "true",
+			task:  core.Task{Done: true},
+			want:  false,
+		},
 		{
 			field: "done_on",
 			value: "0",
@@ -355,3 +420,48 @@ func TestFilter_UnmarshalYAML(t *testing.T) {
 
 	t.Log(in)
 }
+
+func TestFilter_ForUser(t *testing.T) {
+	me, _ := NewRule("assignee", "me")
+	f := Filter{RuleSets: []RuleSet{{Rules: []Rule{me}}}}
+	task := core.Task{Assignee: "alice"}
+
+	if f.Evaluate(task) {
+		t.Error("unresolved filter should not match")
+	}
+	if !f.ForUser("alice").Evaluate(task) {
+		t.Error("filter for alice should match her task")
+	}
+	if f.ForUser("bob").Evaluate(task) {
+		t.Error("filter for bob should not match alice's task")
+	}
+	if f.RuleSets[0].Rules[0].Value != "me" {
+		t.Error("ForUser should not change the original filter")
+	}
+}
+
+func TestFilter_ForGroups(t *testing.T) {
+	group, _ := NewRule("group", "Work")
+	open, _ := NewRule("done", "false")
+	f := Filter{RuleSets: []RuleSet{{Rules: []Rule{group, open}}}}
+	lists := func(name string) []string {
+		if name == "Work" {
+			return []string{"Clients", "Admin"}
+		}
+		return nil
+	}
+
+	if f.Evaluate(core.Task{List: "Clients"}) {
+		t.Error("unresolved filter should not match")
+	}
+	resolved := f.ForGroups(lists)
+	if !resolved.Evaluate(core.Task{List: "Clients"}) || !resolved.Evaluate(core.Task{List: "Admin"}) {
+		t.Error("resolved filter should match the tasks of the lists of the group")
+	}
+	if resolved.Evaluate(core.Task{List: "Home"}) || resolved.Evaluate(core.Task{List: "Admin", Done: true}) {
+		t.Error("resolved filter should only match open tasks of the group")
+	}
+	if f.Evaluate(core.Task{List: "Clients"}) {
+		t.Error("ForGroups should not change the original filter")
+	}
+}
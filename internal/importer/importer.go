@@ -0,0 +1,60 @@
+// Package importer reads task dumps of other task managers and maps them onto lists and tasks.
+package importer
+
+import (
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// Result is the outcome of reading a dump. Tasks have no IDs, they are assigned on import.
+type Result struct {
+	Lists []*core.List
+	// Skipped counts entries that have no equivalent here, e.g. deleted tasks or recurrence templates.
+	Skipped int
+}
+
+// Tasks returns the number of tasks in all lists.
+func (r Result) Tasks() int {
+	n := 0
+	for _, l := range r.Lists {
+		n += len(l.Items)
+	}
+	return n
+}
+
+// lists collects tasks into lists, keeping lists in the order they were first seen.
+type lists struct {
+	order  []*core.List
+	byName map[string]*core.List
+}
+
+func newLists() *lists {
+	return &lists{byName: make(map[string]*core.List)}
+}
+
+func (l *lists) add(name string, colour core.RGB, task core.Task) {
+	list := l.get(name, colour)
+	task.List = name
+	list.Items = append(list.Items, &task)
+}
+
+func (l *lists) get(name string, colour core.RGB) *core.List {
+	list, ok := l.byName[name]
+	if !ok {
+		list = &core.List{Name: name, Colour: colour}
+		l.byName[name] = list
+		l.order = append(l.order, list)
+	}
+	return list
+}
+
+// isMidnight reports whether t is at the start of a day in the local time zone, which is how other tools store
+// dates without a time of day.
+func isMidnight(t time.Time) bool {
+	t = t.In(time.Local)
+	return t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0
+}
+
+// defaultColour is used for lists when the source has no colour information.
+var defaultColour = core.RGB{R: 128, G: 128, B: 128}
@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+func TestTaskwarrior(t *testing.T) {
+	dump := `[
+		{"id":1,"description":"Buy milk","project":"Home","status":"pending","priority":"H","entry":"20261001T080000Z","due":"20261020T120000Z"},
+		{"id":0,"description":"File taxes","status":"completed","entry":"20261001T080000Z","end":"20261005T100000Z"},
+		{"id":2,"description":"Old","status":"deleted","entry":"20261001T080000Z"},
+		{"id":3,"description":"Call mum","project":"Home","status":"waiting","priority":"L","entry":"20261001T080000Z","scheduled":"20261021T150000Z"}
+	]`
+
+	res, err := Taskwarrior(strings.NewReader(dump), "Inbox")
+	if err != nil {
+		t.Fatalf("Taskwarrior() error = %v", err)
+	}
+	if res.Skipped != 1 || res.Tasks() != 3 || len(res.Lists) != 2 {
+		t.Fatalf("got %d lists, %d tasks, %d skipped, want 2, 3, 1", len(res.Lists), res.Tasks(), res.Skipped)
+	}
+
+	home, inbox := res.Lists[0], res.Lists[1]
+	if home.Name != "Home" || inbox.Name != "Inbox" {
+		t.Fatalf("lists = %q, %q, want Home, Inbox", home.Name, inbox.Name)
+	}
+
+	milk := home.Items[0]
+	if milk.List != "Home" || milk.Priority != core.PrioHighest || milk.DueType != core.DueBy ||
+		!milk.Due.Equal(time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("milk = %+v", milk)
+	}
+	if call := home.Items[1]; call.DueType != core.DueOn || call.Priority != core.PrioLow || call.Done {
+		t.Errorf("call = %+v", call)
+	}
+	if taxes := inbox.Items[0]; !taxes.Done || !taxes.DoneOn.Equal(time.Date(2026, 10, 5, 10, 0, 0, 0, time.UTC)) {
+		t.Errorf("taxes = %+v", taxes)
+	}
+}
+
+func TestTaskwarrior_Invalid(t *testing.T) {
+	for _, dump := range []string{
+		`{}`,
+		`[{"description":"x","status":"pending","priority":"X"}]`,
+		`[{"description":"x","status":"pending","due":"2026-10-20"}]`,
+	} {
+		if _, err := Taskwarrior(strings.NewReader(dump), "Inbox"); err == nil {
+			t.Errorf("Taskwarrior(%s) expected error", dump)
+		}
+	}
+}
+
+func TestTodoist(t *testing.T) {
+	dump := `{
+		"projects": [
+			{"id":"100","name":"Work","color":"blue"},
+			{"id":200,"name":"Empty","color":"unknown"},
+			{"id":"300","name":"Gone","is_deleted":true}
+		],
+		"items": [
+			{"content":"Write report","project_id":"100","priority":4,"due":{"date":"2026-10-20"},"added_at":"2026-10-01T08:00:00Z"},
+			{"content":"Ship release","project_id":"100","priority":1,"due":{"date":"2026-10-21T09:00:00"},"deadline":{"date":"2026-10-22"}},
+			{"content":"Standup","project_id":"100","due":{"date":"2026-10-19T07:00:00Z"},"checked":true,"completed_at":"2026-10-19T07:15:00Z"},
+			{"content":"Orphan","project_id":"999","priority":3},
+			{"content":"Deleted","project_id":"100","is_deleted":true}
+		]
+	}`
+
+	res, err := Todoist(strings.NewReader(dump), "Inbox")
+	if err != nil {
+		t.Fatalf("Todoist() error = %v", err)
+	}
+	if res.Skipped != 1 || res.Tasks() != 4 || len(res.Lists) != 3 {
+		t.Fatalf("got %d lists, %d tasks, %d skipped, want 3, 4, 1", len(res.Lists), res.Tasks(), res.Skipped)
+	}
+
+	work := res.Lists[0]
+	if work.Name != "Work" || work.Colour != todoistColour("blue") || len(work.Items) != 3 {
+		t.Fatalf("work = %+v", work)
+	}
+	if empty := res.Lists[1]; empty.Name != "Empty" || empty.Colour != defaultColour || len(empty.Items) != 0 {
+		t.Errorf("empty = %+v", empty)
+	}
+
+	report := work.Items[0]
+	if report.Priority != core.PrioHighest || report.DueType != core.DueOn || !report.AllDay ||
+		!report.Due.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("report = %+v", report)
+	}
+	if release := work.Items[1]; release.DueType != core.DueBy || !release.AllDay || release.Priority != core.PrioNormal {
+		t.Errorf("release = %+v", release)
+	}
+	if standup := work.Items[2]; !standup.Done || standup.AllDay || !standup.DoneOn.Equal(time.Date(2026, 10, 19, 7, 15, 0, 0, time.UTC)) {
+		t.Errorf("standup = %+v", standup)
+	}
+	if orphan := res.Lists[2].Items[0]; orphan.List != "Inbox" || orphan.Priority != core.PrioHigh {
+		t.Errorf("orphan = %+v", orphan)
+	}
+}
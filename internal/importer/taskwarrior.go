@@ -0,0 +1,107 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// taskwarriorTime is the date format used by `task export`.
+const taskwarriorTime = "20060102T150405Z"
+
+type taskwarriorTask struct {
+	Description string `json:"description"`
+	Project     string `json:"project"`
+	Status      string `json:"status"`
+	Priority    string `json:"priority"`
+	Entry       string `json:"entry"`
+	Due         string `json:"due"`
+	Scheduled   string `json:"scheduled"`
+	End         string `json:"end"`
+}
+
+// Taskwarrior reads the JSON array written by `task export`. Projects become lists, tasks without a project go to
+// defaultList. The due date maps to DueBy, a scheduled date without due date to DueOn. Priorities H, M and L map to
+// PrioHighest, PrioHigh and PrioLow. Deleted tasks and recurrence templates are skipped.
+func Taskwarrior(r io.Reader, defaultList string) (Result, error) {
+	var dump []taskwarriorTask
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return Result{}, fmt.Errorf("failed to decode Taskwarrior export: %w", err)
+	}
+
+	res := Result{}
+	l := newLists()
+	for i, tw := range dump {
+		if tw.Status == "deleted" || tw.Status == "recurring" {
+			res.Skipped++
+			continue
+		}
+		t, err := tw.task()
+		if err != nil {
+			return Result{}, fmt.Errorf("task %d (%q): %w", i+1, tw.Description, err)
+		}
+		list := tw.Project
+		if list == "" {
+			list = defaultList
+		}
+		l.add(list, defaultColour, t)
+	}
+	res.Lists = l.order
+
+	return res, nil
+}
+
+func (tw taskwarriorTask) task() (core.Task, error) {
+	if tw.Description == "" {
+		return core.Task{}, fmt.Errorf("missing description")
+	}
+	t := core.Task{Title: tw.Description, Created: time.Now()}
+
+	switch tw.Priority {
+	case "H":
+		t.Priority = core.PrioHighest
+	case "M":
+		t.Priority = core.PrioHigh
+	case "L":
+		t.Priority = core.PrioLow
+	case "":
+		t.Priority = core.PrioNormal
+	default:
+		return core.Task{}, fmt.Errorf("unknown priority %q", tw.Priority)
+	}
+
+	var err error
+	if tw.Entry != "" {
+		if t.Created, err = time.Parse(taskwarriorTime, tw.Entry); err != nil {
+			return core.Task{}, err
+		}
+	}
+
+	due, dueType := tw.Due, core.DueBy
+	if due == "" && tw.Scheduled != "" {
+		due, dueType = tw.Scheduled, core.DueOn
+	}
+	if due != "" {
+		if t.Due, err = time.Parse(taskwarriorTime, due); err != nil {
+			return core.Task{}, err
+		}
+		t.Due = t.Due.In(time.Local)
+		t.DueType = dueType
+		t.AllDay = isMidnight(t.Due)
+	}
+
+	if tw.Status == "completed" {
+		t.Done = true
+		t.DoneOn = time.Now()
+		if tw.End != "" {
+			if t.DoneOn, err = time.Parse(taskwarriorTime, tw.End); err != nil {
+				return core.Task{}, err
+			}
+		}
+	}
+
+	return t, nil
+}
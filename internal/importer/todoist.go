@@ -0,0 +1,180 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+type todoistBackup struct {
+	Projects []todoistProject `json:"projects"`
+	Items    []todoistItem    `json:"items"`
+}
+
+type todoistProject struct {
+	ID        todoistID `json:"id"`
+	Name      string    `json:"name"`
+	Color     string    `json:"color"`
+	IsDeleted bool      `json:"is_deleted"`
+}
+
+type todoistItem struct {
+	Content     string       `json:"content"`
+	ProjectID   todoistID    `json:"project_id"`
+	Priority    int          `json:"priority"`
+	Due         *todoistDate `json:"due"`
+	Deadline    *todoistDate `json:"deadline"`
+	Checked     bool         `json:"checked"`
+	IsDeleted   bool         `json:"is_deleted"`
+	AddedAt     string       `json:"added_at"`
+	CompletedAt string       `json:"completed_at"`
+}
+
+type todoistDate struct {
+	Date string `json:"date"`
+}
+
+// todoistID accepts both the numeric IDs of older backups and the string IDs of newer ones.
+type todoistID string
+
+func (id *todoistID) UnmarshalJSON(data []byte) error {
+	*id = todoistID(bytes.Trim(data, `"`))
+	return nil
+}
+
+// Todoist reads a Todoist backup in the JSON format of the sync API. Projects become lists, items without a known
+// project go to defaultList. Deadlines map to DueBy, due dates to DueOn. Todoist priorities 4 (p1) and 3 (p2) map to
+// PrioHighest and PrioHigh, the rest to PrioNormal. Deleted items are skipped.
+func Todoist(r io.Reader, defaultList string) (Result, error) {
+	var dump todoistBackup
+	if err := json.NewDecoder(r).Decode(&dump); err != nil {
+		return Result{}, fmt.Errorf("failed to decode Todoist backup: %w", err)
+	}
+
+	res := Result{}
+	l := newLists()
+	projects := make(map[todoistID]todoistProject)
+	for _, p := range dump.Projects {
+		if p.IsDeleted {
+			continue
+		}
+		projects[p.ID] = p
+		// keep empty projects as lists, too
+		l.get(p.Name, todoistColour(p.Color))
+	}
+
+	for i, item := range dump.Items {
+		if item.IsDeleted {
+			res.Skipped++
+			continue
+		}
+		t, err := item.task()
+		if err != nil {
+			return Result{}, fmt.Errorf("item %d (%q): %w", i+1, item.Content, err)
+		}
+		p, ok := projects[item.ProjectID]
+		if !ok {
+			p = todoistProject{Name: defaultList}
+		}
+		l.add(p.Name, todoistColour(p.Color), t)
+	}
+	res.Lists = l.order
+
+	return res, nil
+}
+
+func (item todoistItem) task() (core.Task, error) {
+	if item.Content == "" {
+		return core.Task{}, fmt.Errorf("missing content")
+	}
+	t := core.Task{Title: item.Content, Created: time.Now()}
+
+	switch item.Priority {
+	case 4:
+		t.Priority = core.PrioHighest
+	case 3:
+		t.Priority = core.PrioHigh
+	default:
+		t.Priority = core.PrioNormal
+	}
+
+	var err error
+	if item.AddedAt != "" {
+		if t.Created, err = time.Parse(time.RFC3339, item.AddedAt); err != nil {
+			return core.Task{}, err
+		}
+	}
+
+	due, dueType := item.Deadline, core.DueBy
+	if due == nil {
+		due, dueType = item.Due, core.DueOn
+	}
+	if due != nil && due.Date != "" {
+		if t.Due, t.AllDay, err = parseTodoistDate(due.Date); err != nil {
+			return core.Task{}, err
+		}
+		t.DueType = dueType
+	}
+
+	if item.Checked {
+		t.Done = true
+		t.DoneOn = time.Now()
+		if item.CompletedAt != "" {
+			if t.DoneOn, err = time.Parse(time.RFC3339, item.CompletedAt); err != nil {
+				return core.Task{}, err
+			}
+		}
+	}
+
+	return t, nil
+}
+
+// parseTodoistDate parses the three forms Todoist uses: a full-day date, a floating date and time (local time) and a
+// fixed date and time in UTC.
+func parseTodoistDate(s string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, true, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05", s, time.Local); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", s)
+	}
+	return t.In(time.Local), false, nil
+}
+
+// todoistColour maps Todoist's named colours to RGB.
+func todoistColour(name string) core.RGB {
+	colours := map[string]core.RGB{
+		"berry_red":   {R: 184, G: 37, B: 95},
+		"red":         {R: 219, G: 64, B: 53},
+		"orange":      {R: 255, G: 153, B: 51},
+		"yellow":      {R: 250, G: 208, B: 0},
+		"olive_green": {R: 175, G: 184, B: 59},
+		"lime_green":  {R: 126, G: 204, B: 73},
+		"green":       {R: 41, G: 148, B: 56},
+		"mint_green":  {R: 106, G: 204, B: 188},
+		"teal":        {R: 21, G: 143, B: 173},
+		"sky_blue":    {R: 20, G: 170, B: 245},
+		"light_blue":  {R: 150, G: 195, B: 235},
+		"blue":        {R: 64, G: 115, B: 255},
+		"grape":       {R: 136, G: 77, B: 255},
+		"violet":      {R: 175, G: 56, B: 235},
+		"lavender":    {R: 235, G: 150, B: 235},
+		"magenta":     {R: 224, G: 81, B: 148},
+		"salmon":      {R: 255, G: 141, B: 133},
+		"charcoal":    {R: 128, G: 128, B: 128},
+		"grey":        {R: 184, G: 184, B: 184},
+		"taupe":       {R: 204, G: 172, B: 147},
+	}
+	if c, ok := colours[name]; ok {
+		return c
+	}
+	return defaultColour
+}
@@ -0,0 +1,162 @@
+// Package markdown renders lists as GitHub-flavoured Markdown checklists and parses such checklists back into tasks.
+//
+// A task is rendered as a checklist item with its due date and priority in trailing parentheses, e.g.
+// "- [ ] Buy milk (due 2026-10-20, !high)" or "- [x] Call client (on 2026-10-21 09:00)". "due" marks a DueBy and "on"
+// a DueOn date, normal priority is omitted.
+package markdown
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+const (
+	dateLayout     = "2006-01-02"
+	dateTimeLayout = "2006-01-02 15:04"
+)
+
+var priorities = map[int]string{
+	core.PrioLowest:  "!lowest",
+	core.PrioLow:     "!low",
+	core.PrioHigh:    "!high",
+	core.PrioHighest: "!highest",
+}
+
+// Render writes the list as a Markdown document with the list name as heading.
+func Render(w io.Writer, l core.List) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", l.Name)
+	for _, t := range l.Items {
+		b.WriteString(renderTask(*t))
+		b.WriteByte('\n')
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func renderTask(t core.Task) string {
+	check := " "
+	if t.Done {
+		check = "x"
+	}
+
+	var meta []string
+	if t.HasDueDate() {
+		keyword := "due"
+		if t.HasDueOnDate() {
+			keyword = "on"
+		}
+		layout := dateTimeLayout
+		if t.AllDay {
+			layout = dateLayout
+		}
+		meta = append(meta, keyword+" "+t.Due.In(time.Local).Format(layout))
+	}
+	if p, ok := priorities[t.Priority]; ok {
+		meta = append(meta, p)
+	}
+
+	line := fmt.Sprintf("- [%s] %s", check, t.Title)
+	if len(meta) > 0 {
+		line += " (" + strings.Join(meta, ", ") + ")"
+	}
+	return line
+}
+
+// Item is a task parsed from a checklist.
+type Item struct {
+	Task api.TaskAdd
+	Done bool
+}
+
+var (
+	checklistItem = regexp.MustCompile(`^\s*[-*+]\s+\[([ xX])\]\s+(.+)$`)
+	trailingMeta  = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)\s*$`)
+	metaPart      = regexp.MustCompile(`^(![a-z]+|(due|on) \d{4}-\d{2}-\d{2}( \d{2}:\d{2})?)$`)
+)
+
+// Parse reads all checklist items of a Markdown document. Everything else, e.g. headings, text and plain bullet points,
+// is ignored. Nested items are treated like top-level ones.
+func Parse(r io.Reader) ([]Item, error) {
+	var items []Item
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		m := checklistItem.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		task, err := parseTask(strings.TrimSpace(m[2]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		items = append(items, Item{Task: task, Done: m[1] != " "})
+	}
+	return items, scanner.Err()
+}
+
+func parseTask(s string) (api.TaskAdd, error) {
+	task := api.TaskAdd{Title: s, Priority: core.PrioNormal}
+
+	m := trailingMeta.FindStringSubmatch(s)
+	if m == nil || m[1] == "" {
+		return task, nil
+	}
+	parts := strings.Split(m[2], ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		// parentheses that don't only contain metadata are part of the title
+		if !isMeta(parts[i]) {
+			return task, nil
+		}
+	}
+
+	task.Title = m[1]
+	for _, part := range parts {
+		if strings.HasPrefix(part, "!") {
+			prio, err := parsePriority(part)
+			if err != nil {
+				return api.TaskAdd{}, err
+			}
+			task.Priority = prio
+			continue
+		}
+		keyword, value, _ := strings.Cut(part, " ")
+		task.DueType = core.DueBy
+		if keyword == "on" {
+			task.DueType = core.DueOn
+		}
+		if due, err := time.ParseInLocation(dateTimeLayout, value, time.Local); err == nil {
+			task.Due = due
+		} else if due, err = time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+			task.Due = due
+			task.AllDay = true
+		} else {
+			return api.TaskAdd{}, fmt.Errorf("invalid date %q", value)
+		}
+	}
+
+	return task, nil
+}
+
+func isMeta(s string) bool {
+	return metaPart.MatchString(s)
+}
+
+func parsePriority(s string) (int, error) {
+	for prio, label := range priorities {
+		if s == label {
+			return prio, nil
+		}
+	}
+	if s == "!normal" {
+		return core.PrioNormal, nil
+	}
+	return 0, fmt.Errorf("invalid priority %q", s)
+}
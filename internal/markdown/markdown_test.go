@@ -0,0 +1,99 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+func TestRender(t *testing.T) {
+	l := core.List{Name: "Home", Items: []*core.Task{
+		{Title: "Buy milk", DueType: core.DueBy, AllDay: true, Due: time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local), Priority: core.PrioHigh},
+		{Title: "Call client", Done: true, DueType: core.DueOn, Due: time.Date(2026, 10, 21, 9, 0, 0, 0, time.Local)},
+		{Title: "Learn JS", Priority: core.PrioLowest},
+		{Title: "Relax"},
+	}}
+
+	var b strings.Builder
+	if err := Render(&b, l); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	want := `# Home
+
+- [ ] Buy milk (due 2026-10-20, !high)
+- [x] Call client (on 2026-10-21 09:00)
+- [ ] Learn JS (!lowest)
+- [ ] Relax
+`
+	if got := b.String(); got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+}
+
+func TestParse(t *testing.T) {
+	doc := `# Release checklist
+
+Some notes that are not tasks.
+
+- [ ] Tag release (due 2026-10-20, !highest)
+- [X] Write changelog
+  * [ ] Announce (on 2026-10-21 09:30)
+- [ ] Put project (on hold)
+- plain bullet
+1. [ ] numbered lists are not checklists
+`
+	items, err := Parse(strings.NewReader(doc))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(items) != 4 {
+		t.Fatalf("Parse() returned %d items, want 4", len(items))
+	}
+
+	tag := items[0]
+	if tag.Done || tag.Task.Title != "Tag release" || tag.Task.Priority != core.PrioHighest ||
+		tag.Task.DueType != core.DueBy || !tag.Task.AllDay || !tag.Task.Due.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("items[0] = %+v", tag)
+	}
+	if cl := items[1]; !cl.Done || cl.Task.Title != "Write changelog" || cl.Task.DueType != core.DueNone {
+		t.Errorf("items[1] = %+v", cl)
+	}
+	if an := items[2]; an.Task.DueType != core.DueOn || an.Task.AllDay || !an.Task.Due.Equal(time.Date(2026, 10, 21, 9, 30, 0, 0, time.Local)) {
+		t.Errorf("items[2] = %+v", an)
+	}
+	if hold := items[3]; hold.Task.Title != "Put project (on hold)" {
+		t.Errorf("items[3] = %+v", hold)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, doc := range []string{
+		"- [ ] Task (due 2026-13-01)",
+		"- [ ] Task (!urgent)",
+	} {
+		if _, err := Parse(strings.NewReader(doc)); err == nil {
+			t.Errorf("Parse(%q) expected error", doc)
+		}
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	l := core.List{Name: "Work", Items: []*core.Task{
+		{Title: "Write report (draft)", DueType: core.DueOn, Due: time.Date(2026, 10, 20, 14, 0, 0, 0, time.Local), Priority: core.PrioLow},
+	}}
+	var b strings.Builder
+	if err := Render(&b, l); err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	items, err := Parse(strings.NewReader(b.String()))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	got, want := items[0].Task, l.Items[0]
+	if len(items) != 1 || got.Title != want.Title || got.Priority != want.Priority || got.DueType != want.DueType ||
+		!got.Due.Equal(want.Due) || got.AllDay != want.AllDay {
+		t.Errorf("round trip = %+v, want %+v", got, want)
+	}
+}
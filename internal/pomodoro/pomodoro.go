@@ -0,0 +1,173 @@
+// Package pomodoro runs Pomodoro sessions on tasks: a focus phase, which is recorded on the task once it is completed,
+// followed by a break. The phase changes are passed to notifiers, e.g. the event stream. Sessions are only kept in
+// memory, they end when the server stops.
+package pomodoro
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// Default lengths of the phases of a session.
+const (
+	DefaultFocus = 25 * time.Minute
+	DefaultBreak = 5 * time.Minute
+)
+
+var (
+	ErrRunning    = errors.New("a Pomodoro session is already running")
+	ErrNotRunning = errors.New("no Pomodoro session is running")
+)
+
+// Notifier delivers the phase changes of sessions, which are changes of the Pomodoro types of the repository. Notify
+// must not block.
+type Notifier interface {
+	Notify(c repository.Change)
+}
+
+// Timer runs the Pomodoro sessions of all users, one per user. It is safe for concurrent use.
+type Timer struct {
+	repo      *repository.Repository
+	notifiers []Notifier
+
+	mu       sync.Mutex
+	sessions map[string]*session
+
+	log *log.Entry
+}
+
+type session struct {
+	api.PomodoroSession
+	focus, brk time.Duration
+	timer      *time.Timer
+}
+
+// NewTimer returns a timer for sessions on the tasks in repo, which must be the repository of all users.
+func NewTimer(repo *repository.Repository, logger *log.Entry, notifiers ...Notifier) *Timer {
+	return &Timer{repo: repo, notifiers: notifiers, sessions: make(map[string]*session), log: logger}
+}
+
+// Start starts a session of a user on a task with the given lengths of the phases, zero lengths use the defaults.
+func (t *Timer) Start(user string, taskID int, focus, brk time.Duration) (api.PomodoroSession, error) {
+	if focus < 0 {
+		return api.PomodoroSession{}, &api.FieldError{Field: "focus", Message: "focus must be a positive duration"}
+	}
+	if brk < 0 {
+		return api.PomodoroSession{}, &api.FieldError{Field: "break", Message: "break must be a positive duration"}
+	}
+	if focus == 0 {
+		focus = DefaultFocus
+	}
+	if brk == 0 {
+		brk = DefaultBreak
+	}
+	if err := t.repo.As(user).CheckPomodoro(taskID); err != nil {
+		return api.PomodoroSession{}, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, ok := t.sessions[user]; ok {
+		return api.PomodoroSession{}, ErrRunning
+	}
+
+	now := time.Now()
+	s := &session{
+		PomodoroSession: api.PomodoroSession{
+			Task:     taskID,
+			User:     user,
+			Phase:    api.PhaseFocus,
+			Started:  now,
+			PhaseEnd: now.Add(focus),
+			Focus:    focus.String(),
+			Break:    brk.String(),
+		},
+		focus: focus,
+		brk:   brk,
+	}
+	s.timer = time.AfterFunc(focus, func() { t.next(s) })
+	t.sessions[user] = s
+	t.notify(repository.PomodoroStarted, s)
+	return s.PomodoroSession, nil
+}
+
+// Session returns the running session of a user.
+func (t *Timer) Session(user string) (api.PomodoroSession, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[user]
+	if !ok {
+		return api.PomodoroSession{}, false
+	}
+	return s.PomodoroSession, true
+}
+
+// Stop ends the running session of a user. A focus phase that is stopped isn't recorded.
+func (t *Timer) Stop(user string) (api.PomodoroSession, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s, ok := t.sessions[user]
+	if !ok {
+		return api.PomodoroSession{}, ErrNotRunning
+	}
+	s.timer.Stop()
+	delete(t.sessions, user)
+	t.notify(repository.PomodoroStopped, s)
+	return s.PomodoroSession, nil
+}
+
+// Close stops all sessions without notifying anybody.
+func (t *Timer) Close() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for user, s := range t.sessions {
+		s.timer.Stop()
+		delete(t.sessions, user)
+	}
+}
+
+// next moves a session to its next phase when the current one is over: the completed focus phase is recorded and the
+// break starts, after the break the session is finished.
+func (t *Timer) next(s *session) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the session may have been stopped while the timer fired
+	if t.sessions[s.User] != s {
+		return
+	}
+	if s.Phase == api.PhaseBreak {
+		delete(t.sessions, s.User)
+		t.notify(repository.PomodoroFinished, s)
+		return
+	}
+
+	if err := t.repo.As(s.User).RecordPomodoro(s.Task, s.Started, s.focus); err != nil {
+		t.log.WithError(err).WithFields(log.Fields{"user": s.User, "task": s.Task}).Warn("Failed to record Pomodoro.")
+	}
+	s.Phase = api.PhaseBreak
+	s.PhaseEnd = time.Now().Add(s.brk)
+	s.timer = time.AfterFunc(s.brk, func() { t.next(s) })
+	t.notify(repository.PomodoroBreak, s)
+}
+
+// notify passes a phase change of a session to the notifiers. Changes of tasks that have been deleted are dropped.
+func (t *Timer) notify(typ repository.ChangeType, s *session) {
+	c, err := t.repo.As(s.User).PomodoroChange(typ, s.PomodoroSession)
+	if err != nil {
+		t.log.WithError(err).WithField("task", s.Task).Debug("Dropped Pomodoro change.")
+		return
+	}
+	for _, n := range t.notifiers {
+		n.Notify(c)
+	}
+}
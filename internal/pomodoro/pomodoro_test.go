@@ -0,0 +1,96 @@
+package pomodoro
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+// recorder passes the changes to a channel, so that tests can wait for phase changes.
+type recorder chan repository.Change
+
+func (r recorder) Notify(c repository.Change) {
+	r <- c
+}
+
+func (r recorder) next(t *testing.T) repository.Change {
+	t.Helper()
+	select {
+	case c := <-r:
+		return c
+	case <-time.After(time.Second):
+		t.Fatal("no change within a second")
+		return repository.Change{}
+	}
+}
+
+func TestTimer(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	repo := repository.NewRepository(&storage.Fake{})
+	alice := repo.As("alice")
+	if _, err := alice.AddList("Work", core.RGB{}); err != nil {
+		t.Fatal(err)
+	}
+	task, err := alice.AddItem("Work", api.TaskAdd{Title: "Write report"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := make(recorder, 10)
+	timer := NewTimer(repo, log.NewEntry(logger), got)
+	defer timer.Close()
+
+	if _, err = timer.Start("bob", task.ID, 0, 0); !errors.Is(err, repository.ErrTaskNotFound) {
+		t.Errorf("bob started session on alice's task: %v", err)
+	}
+	s, err := timer.Start("alice", task.ID, 20*time.Millisecond, 20*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Phase != api.PhaseFocus || s.Focus != "20ms" {
+		t.Errorf("session = %+v, want 20ms focus", s)
+	}
+	if _, err = timer.Start("alice", task.ID, 0, 0); !errors.Is(err, ErrRunning) {
+		t.Errorf("second session: %v, want %v", err, ErrRunning)
+	}
+
+	for _, want := range []repository.ChangeType{repository.PomodoroStarted, repository.PomodoroBreak, repository.PomodoroFinished} {
+		c := got.next(t)
+		if c.Type != want || c.Pomodoro == nil || c.User != "alice" || !c.VisibleTo("alice") || c.VisibleTo("bob") {
+			t.Errorf("change = %+v, want %s of alice", c, want)
+		}
+	}
+	if task, _ = alice.GetTask(task.ID); len(task.Pomodoros) != 1 || task.Pomodoros[0].User != "alice" {
+		t.Errorf("pomodoros = %+v, want one of alice", task.Pomodoros)
+	}
+	if _, ok := timer.Session("alice"); ok {
+		t.Error("session is still running after the break")
+	}
+
+	// stopped focus phases aren't recorded
+	if _, err = timer.Start("alice", task.ID, time.Hour, 0); err != nil {
+		t.Fatal(err)
+	}
+	got.next(t)
+	if _, err = timer.Stop("alice"); err != nil {
+		t.Fatal(err)
+	}
+	if c := got.next(t); c.Type != repository.PomodoroStopped {
+		t.Errorf("change = %s, want %s", c.Type, repository.PomodoroStopped)
+	}
+	if _, err = timer.Stop("alice"); !errors.Is(err, ErrNotRunning) {
+		t.Errorf("stop without session: %v, want %v", err, ErrNotRunning)
+	}
+	if task, _ = alice.GetTask(task.ID); len(task.Pomodoros) != 1 {
+		t.Errorf("got %d pomodoros after stopping, want 1", len(task.Pomodoros))
+	}
+}
@@ -0,0 +1,382 @@
+// Package quickadd parses one-line task descriptions like "Call client tomorrow 9am !high #Work" or "Pay rent by
+// friday" into tasks.
+//
+// Recognised parts are removed from the title, everything else is kept as is:
+//   - "#Name" sets the list.
+//   - "!lowest", "!low", "!normal", "!high", "!highest" or "!-2" to "!2" set the priority.
+//   - A date and/or time sets the due date. Dates are "today", "tomorrow", weekdays ("fri", "friday", "next friday"),
+//     "next week" (Monday), "next month" (the 1st), "in 3 days", "in 2 weeks", ISO dates (2026-10-20) and month and
+//     day ("oct 20", "20 october"). A weekday is the next one from today on, "next" adds a week to it: on a Thursday,
+//     "friday" is tomorrow and "next friday" 8 days later. Month and day are in the next year if the date has passed,
+//     days the month doesn't have like "feb 31" aren't dates. Times are "9am", "9:30pm", "14:00" and "noon",
+//     optionally preceded by "at".
+//     "in 2h" and "in 30 minutes" set both date and time relative to now.
+//   - "by" before a date makes it a DueBy date, otherwise, or with "on", it's a DueOn date. Dates without time are
+//     all-day.
+package quickadd
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// Parse parses a task description. Relative dates are resolved against now, including its time zone.
+func Parse(text string, now time.Time) (api.TaskAdd, error) {
+	task := api.TaskAdd{Priority: core.PrioNormal}
+	var w when
+	var title []string
+
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		lower := strings.ToLower(tok)
+
+		if strings.HasPrefix(tok, "#") && len(tok) > 1 {
+			task.List = tok[1:]
+			continue
+		}
+		if strings.HasPrefix(tok, "!") {
+			if prio, ok := parsePriority(lower[1:]); ok {
+				task.Priority = prio
+				continue
+			}
+		}
+
+		dueType := core.DueOn
+		start := i
+		if lower == "by" || lower == "on" || lower == "at" {
+			if lower == "by" {
+				dueType = core.DueBy
+			}
+			start = i + 1
+		}
+		if n := w.parse(tokens[start:], now); n > 0 {
+			if start > i && lower != "at" {
+				w.dueType = dueType
+			}
+			i = start + n - 1
+			continue
+		}
+
+		title = append(title, tok)
+	}
+
+	task.Title = strings.Join(title, " ")
+	if task.Title == "" {
+		return api.TaskAdd{}, errors.New("missing task title")
+	}
+
+	if w.hasDate || w.hasTime {
+		task.DueType = core.DueOn
+		if w.dueType != "" {
+			task.DueType = w.dueType
+		}
+		task.Due, task.AllDay = w.resolve(now)
+	}
+
+	return task, nil
+}
+
+// ParseDate parses a date and/or time expression as accepted by Parse, e.g. "friday", "tomorrow 9am" or "2026-10-20".
+// It returns the date and whether it's an all-day date.
+func ParseDate(text string, now time.Time) (time.Time, bool, error) {
+	var w when
+	tokens := strings.Fields(text)
+	for i := 0; i < len(tokens); {
+		if strings.EqualFold(tokens[i], "at") {
+			i++
+			continue
+		}
+		n := w.parse(tokens[i:], now)
+		if n == 0 {
+			return time.Time{}, false, fmt.Errorf("invalid date %q", text)
+		}
+		i += n
+	}
+	if !w.hasDate && !w.hasTime {
+		return time.Time{}, false, fmt.Errorf("invalid date %q", text)
+	}
+	due, allDay := w.resolve(now)
+	return due, allDay, nil
+}
+
+var snoozeDays = regexp.MustCompile(`^(\d+)(d|w)$`)
+
+// ParseSnooze parses the time a task is snoozed until: a duration from now like "1h", "30m", "3d" or "2w", a date as
+// accepted by ParseDate like "tomorrow" or "next week", which starts at midnight unless it has a time, or a time in
+// RFC 3339 format. An empty text returns the zero time, which ends the snooze.
+func ParseSnooze(text string, now time.Time) (time.Time, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(text); err == nil && d > 0 {
+		return now.Add(d), nil
+	}
+	if m := snoozeDays.FindStringSubmatch(text); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		return now.AddDate(0, 0, n), nil
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return t, nil
+	}
+	until, _, err := ParseDate(text, now)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid snooze time %q, use e.g. 1h, tomorrow or next week", text)
+	}
+	return until, nil
+}
+
+// when collects the date and time parts of a description.
+type when struct {
+	date    time.Time
+	hasDate bool
+	hour    int
+	minute  int
+	hasTime bool
+	dueType core.DueType
+}
+
+// parse tries to read a date or time at the start of tokens and returns the number of tokens consumed. Parts that
+// were already set are not parsed again, so they end up in the title.
+func (w *when) parse(tokens []string, now time.Time) int {
+	if len(tokens) == 0 {
+		return 0
+	}
+	if !w.hasDate && !w.hasTime {
+		if d, n := parseRelativeTime(tokens); n > 0 {
+			t := now.Add(d)
+			w.date, w.hasDate = t, true
+			w.hour, w.minute, w.hasTime = t.Hour(), t.Minute(), true
+			return n
+		}
+	}
+	if !w.hasDate {
+		if d, n := parseDay(tokens, now); n > 0 {
+			w.date, w.hasDate = d, true
+			return n
+		}
+	}
+	if !w.hasTime {
+		if h, m, ok := parseTime(tokens[0]); ok {
+			w.hour, w.minute, w.hasTime = h, m, true
+			return 1
+		}
+	}
+	return 0
+}
+
+// resolve returns the due date and whether it's an all-day date. A time without date is today.
+func (w *when) resolve(now time.Time) (time.Time, bool) {
+	d := now
+	if w.hasDate {
+		d = w.date
+	}
+	if !w.hasTime {
+		return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, now.Location()), true
+	}
+	return time.Date(d.Year(), d.Month(), d.Day(), w.hour, w.minute, 0, 0, now.Location()), false
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tues": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thurs": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+var months = map[string]time.Month{
+	"jan": time.January, "january": time.January,
+	"feb": time.February, "february": time.February,
+	"mar": time.March, "march": time.March,
+	"apr": time.April, "april": time.April,
+	"may": time.May,
+	"jun": time.June, "june": time.June,
+	"jul": time.July, "july": time.July,
+	"aug": time.August, "august": time.August,
+	"sep": time.September, "sept": time.September, "september": time.September,
+	"oct": time.October, "october": time.October,
+	"nov": time.November, "november": time.November,
+	"dec": time.December, "december": time.December,
+}
+
+// parseDay parses a date at the start of tokens and returns it with the number of tokens consumed.
+func parseDay(tokens []string, now time.Time) (time.Time, int) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	first := strings.ToLower(tokens[0])
+	second := ""
+	if len(tokens) > 1 {
+		second = strings.ToLower(tokens[1])
+	}
+
+	switch first {
+	case "today":
+		return today, 1
+	case "tomorrow", "tmr":
+		return today.AddDate(0, 0, 1), 1
+	case "next":
+		if wd, ok := weekdays[second]; ok {
+			return nextWeekday(today, wd).AddDate(0, 0, 7), 2
+		}
+		switch second {
+		case "week":
+			return nextWeekday(today.AddDate(0, 0, 1), time.Monday), 2
+		case "month":
+			return time.Date(today.Year(), today.Month()+1, 1, 0, 0, 0, 0, now.Location()), 2
+		}
+		return time.Time{}, 0
+	case "in":
+		if len(tokens) < 3 {
+			return time.Time{}, 0
+		}
+		n, err := strconv.Atoi(second)
+		if err != nil || n < 0 {
+			return time.Time{}, 0
+		}
+		switch strings.TrimSuffix(strings.ToLower(tokens[2]), "s") {
+		case "day":
+			return today.AddDate(0, 0, n), 3
+		case "week":
+			return today.AddDate(0, 0, 7*n), 3
+		case "month":
+			return today.AddDate(0, n, 0), 3
+		}
+		return time.Time{}, 0
+	}
+
+	if wd, ok := weekdays[first]; ok {
+		return nextWeekday(today, wd), 1
+	}
+	if d, err := time.ParseInLocation("2006-01-02", first, now.Location()); err == nil {
+		return d, 1
+	}
+	// "oct 20" or "20 oct", in the next year if the date has passed already. time.Date normalises days the month doesn't
+	// have, e.g. "feb 31" to 3 March, so they are rejected.
+	month, okMonth := months[first]
+	day, err := strconv.Atoi(second)
+	if !okMonth {
+		month, okMonth = months[second]
+		day, err = strconv.Atoi(first)
+	}
+	if okMonth && err == nil && day >= 1 && day <= 31 {
+		d := time.Date(today.Year(), month, day, 0, 0, 0, 0, now.Location())
+		if d.Before(today) {
+			d = time.Date(today.Year()+1, month, day, 0, 0, 0, 0, now.Location())
+		}
+		if d.Day() != day {
+			return time.Time{}, 0
+		}
+		return d, 2
+	}
+
+	return time.Time{}, 0
+}
+
+// nextWeekday returns the first day on or after from that is the given weekday.
+func nextWeekday(from time.Time, wd time.Weekday) time.Time {
+	return from.AddDate(0, 0, (int(wd)-int(from.Weekday())+7)%7)
+}
+
+var relativeTime = regexp.MustCompile(`^(\d+)(m|min|mins|minutes?|h|hrs?|hours?)$`)
+
+// parseRelativeTime parses "in 2h", "in 2 hours" or "in 30 min".
+func parseRelativeTime(tokens []string) (time.Duration, int) {
+	if len(tokens) < 2 || !strings.EqualFold(tokens[0], "in") {
+		return 0, 0
+	}
+	expr, n := strings.ToLower(tokens[1]), 2
+	if len(tokens) > 2 && !relativeTime.MatchString(expr) {
+		expr, n = expr+strings.ToLower(tokens[2]), 3
+	}
+	m := relativeTime.FindStringSubmatch(expr)
+	if m == nil {
+		return 0, 0
+	}
+	v, _ := strconv.Atoi(m[1])
+	if strings.HasPrefix(m[2], "h") {
+		return time.Duration(v) * time.Hour, n
+	}
+	return time.Duration(v) * time.Minute, n
+}
+
+var clockTime = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// parseTime parses "9am", "9:30pm", "14:00" and "noon". Plain numbers are not times.
+func parseTime(tok string) (int, int, bool) {
+	tok = strings.ToLower(tok)
+	if tok == "noon" {
+		return 12, 0, true
+	}
+	m := clockTime.FindStringSubmatch(tok)
+	if m == nil || (m[2] == "" && m[3] == "") {
+		return 0, 0, false
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute := 0
+	if m[2] != "" {
+		minute, _ = strconv.Atoi(m[2])
+	}
+	switch m[3] {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, 0, false
+		}
+		hour %= 12
+		if m[3] == "pm" {
+			hour += 12
+		}
+	}
+	if hour > 23 || minute > 59 {
+		return 0, 0, false
+	}
+	return hour, minute, true
+}
+
+func parsePriority(s string) (int, bool) {
+	switch s {
+	case "lowest":
+		return core.PrioLowest, true
+	case "low":
+		return core.PrioLow, true
+	case "normal":
+		return core.PrioNormal, true
+	case "high":
+		return core.PrioHigh, true
+	case "highest":
+		return core.PrioHighest, true
+	}
+	prio, err := strconv.Atoi(s)
+	if err != nil || prio < core.PrioLowest || prio > core.PrioHighest {
+		return 0, false
+	}
+	return prio, true
+}
+
+// ResolveList returns the name of the list matching name, ignoring case if there is no exact match, so that "#work"
+// finds the list "Work". If no list matches, name is returned.
+func ResolveList(name string, lists []*core.List) string {
+	for _, l := range lists {
+		if l.Name == name {
+			return name
+		}
+	}
+	for _, l := range lists {
+		if strings.EqualFold(l.Name, name) {
+			return l.Name
+		}
+	}
+	return name
+}
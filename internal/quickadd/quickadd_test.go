@@ -0,0 +1,161 @@
+package quickadd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+func TestParse(t *testing.T) {
+	// Sunday, 18 October 2026, 15:04
+	now := time.Date(2026, 10, 18, 15, 4, 0, 0, time.UTC)
+	day := func(d int) time.Time {
+		return time.Date(2026, 10, d, 0, 0, 0, 0, time.UTC)
+	}
+	at := func(d, hh, mm int) time.Time {
+		return time.Date(2026, 10, d, hh, mm, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		text string
+		want api.TaskAdd
+	}{
+		{"Call client tomorrow 9am !high #Work",
+			api.TaskAdd{Title: "Call client", List: "Work", Priority: core.PrioHigh, DueType: core.DueOn, Due: at(19, 9, 0)}},
+		{"Pay rent by friday",
+			api.TaskAdd{Title: "Pay rent", DueType: core.DueBy, AllDay: true, Due: day(23)}},
+		{"Buy milk",
+			api.TaskAdd{Title: "Buy milk"}},
+		{"Work on report on monday at 14:30",
+			api.TaskAdd{Title: "Work on report", DueType: core.DueOn, Due: at(19, 14, 30)}},
+		{"#Home Water plants today !-2",
+			api.TaskAdd{Title: "Water plants", List: "Home", Priority: core.PrioLowest, DueType: core.DueOn, AllDay: true, Due: day(18)}},
+		{"Submit taxes by 2026-10-31 !highest",
+			api.TaskAdd{Title: "Submit taxes", Priority: core.PrioHighest, DueType: core.DueBy, AllDay: true, Due: day(31)}},
+		{"Standup 9:30am next week",
+			api.TaskAdd{Title: "Standup", DueType: core.DueOn, Due: at(19, 9, 30)}},
+		{"Renew passport by next sunday",
+			api.TaskAdd{Title: "Renew passport", DueType: core.DueBy, AllDay: true, Due: day(25)}},
+		{"Check oven in 30 min",
+			api.TaskAdd{Title: "Check oven", DueType: core.DueOn, Due: at(18, 15, 34)}},
+		{"Follow up in 3 days",
+			api.TaskAdd{Title: "Follow up", DueType: core.DueOn, AllDay: true, Due: day(21)}},
+		{"Dentist oct 20 noon",
+			api.TaskAdd{Title: "Dentist", DueType: core.DueOn, Due: at(20, 12, 0)}},
+		{"Party 1 jan",
+			api.TaskAdd{Title: "Party", DueType: core.DueOn, AllDay: true, Due: time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{"Read chapter 3 at home !urgent",
+			api.TaskAdd{Title: "Read chapter 3 at home !urgent"}},
+		{"Lunch at noon today tomorrow",
+			api.TaskAdd{Title: "Lunch tomorrow", DueType: core.DueOn, Due: at(18, 12, 0)}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := Parse(tt.text, now)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got.Title != tt.want.Title || got.List != tt.want.List || got.Priority != tt.want.Priority ||
+				got.DueType != tt.want.DueType || got.AllDay != tt.want.AllDay || !got.Due.Equal(tt.want.Due) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_MissingTitle(t *testing.T) {
+	if _, err := Parse("tomorrow #Work !high", time.Now()); err == nil {
+		t.Error("Parse() expected error for missing title")
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		text       string
+		want       time.Time
+		wantAllDay bool
+		wantErr    bool
+	}{
+		{"tomorrow", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), true, false},
+		{"fri at 5pm", time.Date(2026, 10, 23, 17, 0, 0, 0, time.UTC), false, false},
+		{"2026-10-20", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC), true, false},
+		{"in 2h", time.Date(2026, 10, 18, 17, 4, 0, 0, time.UTC), false, false},
+		{"oct 31", time.Date(2026, 10, 31, 0, 0, 0, 0, time.UTC), true, false},
+		{"feb 28", time.Date(2027, 2, 28, 0, 0, 0, 0, time.UTC), true, false},
+		{"feb 31", time.Time{}, false, true},
+		{"31 april", time.Time{}, false, true},
+		// 2027 isn't a leap year
+		{"feb 29", time.Time{}, false, true},
+		{"someday", time.Time{}, false, true},
+		{"", time.Time{}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, allDay, err := ParseDate(tt.text, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) || allDay != tt.wantAllDay {
+				t.Errorf("ParseDate() = %v, %v, want %v, %v", got, allDay, tt.want, tt.wantAllDay)
+			}
+		})
+	}
+}
+
+func TestParseDate_Weekdays(t *testing.T) {
+	// a Thursday
+	now := time.Date(2026, 10, 22, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		text string
+		want time.Time
+	}{
+		{"thursday", time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC)},
+		{"friday", time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC)},
+		{"next thursday", time.Date(2026, 10, 29, 0, 0, 0, 0, time.UTC)},
+		{"next friday", time.Date(2026, 10, 30, 0, 0, 0, 0, time.UTC)},
+		{"next wednesday", time.Date(2026, 11, 4, 0, 0, 0, 0, time.UTC)},
+		{"next week", time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, _, err := ParseDate(tt.text, now)
+			if err != nil || !got.Equal(tt.want) {
+				t.Errorf("ParseDate() = %v, %v, want %v", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSnooze(t *testing.T) {
+	now := time.Date(2026, 10, 18, 15, 4, 0, 0, time.UTC)
+	tests := []struct {
+		text    string
+		want    time.Time
+		wantErr bool
+	}{
+		{"1h", time.Date(2026, 10, 18, 16, 4, 0, 0, time.UTC), false},
+		{"3d", time.Date(2026, 10, 21, 15, 4, 0, 0, time.UTC), false},
+		{"tomorrow", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"next week", time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), false},
+		{"fri 9am", time.Date(2026, 10, 23, 9, 0, 0, 0, time.UTC), false},
+		{"2026-11-01T08:00:00Z", time.Date(2026, 11, 1, 8, 0, 0, 0, time.UTC), false},
+		{"", time.Time{}, false},
+		{"-1h", time.Time{}, true},
+		{"someday", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, err := ParseSnooze(tt.text, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSnooze() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseSnooze() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package reminder fires the reminders of tasks when they are due.
+package reminder
+
+import (
+	"context"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// Notifier delivers reminders, which are changes of type repository.TaskReminder, e.g. to the web UI, webhooks or by
+// email. Notify must not block.
+type Notifier interface {
+	Notify(c repository.Change)
+}
+
+// Scheduler periodically fires the due reminders of all tasks and passes them to its notifiers.
+type Scheduler struct {
+	repo      *repository.Repository
+	notifiers []Notifier
+
+	// Interval is how often the scheduler checks for due reminders.
+	Interval time.Duration
+
+	log *log.Entry
+	now func() time.Time
+}
+
+// NewScheduler returns a scheduler for the reminders in repo, which must be the repository of all users.
+func NewScheduler(repo *repository.Repository, logger *log.Entry, notifiers ...Notifier) *Scheduler {
+	return &Scheduler{
+		repo:      repo,
+		notifiers: notifiers,
+		Interval:  15 * time.Second,
+		log:       logger,
+		now:       time.Now,
+	}
+}
+
+// Run fires reminders until ctx is cancelled. Reminders that were due while the server was stopped fire right away.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.fire()
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// fire passes the due reminders to the notifiers.
+func (s *Scheduler) fire() {
+	// the reminders returned with an error have been saved as fired and are delivered anyway
+	reminders, err := s.repo.FireReminders(s.now())
+	if err != nil {
+		s.log.WithError(err).Error("Failed to fire reminders.")
+	}
+	for _, c := range reminders {
+		s.log.WithFields(log.Fields{"task": c.Task.ID, "list": c.List.Name}).Debug("Reminder fired.")
+		for _, n := range s.notifiers {
+			n.Notify(c)
+		}
+	}
+}
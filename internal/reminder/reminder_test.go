@@ -0,0 +1,64 @@
+package reminder
+
+import (
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+type recorder []repository.Change
+
+func (r *recorder) Notify(c repository.Change) {
+	*r = append(*r, c)
+}
+
+func TestScheduler(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	path := filepath.Join(t.TempDir(), "db.yml")
+	repo := repository.NewRepository(storage.NewFile(path))
+	now := time.Now().Truncate(time.Minute)
+
+	if _, err := repo.AddList("Work", core.RGB{}); err != nil {
+		t.Fatal(err)
+	}
+	due := api.TaskAdd{Title: "Standup", DueType: core.DueOn, Due: now.Add(time.Hour), Reminders: []string{"2h before", "30m before"}}
+	standup, err := repo.AddItem("Work", due)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done, _ := repo.AddItem("Work", api.TaskAdd{Title: "Done", Reminders: []string{now.Add(-time.Minute).Format(time.RFC3339)}})
+	if _, err = repo.MarkDone(done.ID, true); err != nil {
+		t.Fatal(err)
+	}
+
+	var got recorder
+	s := NewScheduler(repo, log.NewEntry(logger), &got)
+	s.now = func() time.Time { return now }
+	s.fire()
+	if len(got) != 1 || got[0].Type != repository.TaskReminder || got[0].Task.ID != standup.ID {
+		t.Fatalf("reminders = %+v, want the first reminder of the standup", got)
+	}
+
+	// after a restart the fired reminder stays fired and the other one fires once it is due
+	repo = repository.NewRepository(storage.NewFile(path))
+	s = NewScheduler(repo, log.NewEntry(logger), &got)
+	s.now = func() time.Time { return now.Add(30 * time.Minute) }
+	s.fire()
+	s.fire()
+	if len(got) != 2 {
+		t.Fatalf("got %d reminders, want 2", len(got))
+	}
+	task, _ := repo.GetTask(standup.ID)
+	if !task.Reminders[0].Fired || !task.Reminders[1].Fired {
+		t.Errorf("reminders = %+v, want both fired", task.Reminders)
+	}
+}
@@ -0,0 +1,379 @@
+// Package remote implements the organiser interface on top of the REST API of a running server.
+package remote
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/client"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// Organiser talks to a gotodo server. It has the same methods as repository.Repository, so commands can work either
+// on a local store or a running server.
+type Organiser struct {
+	c *client.Client
+
+	// err is the last error of a method that can't return one, see Err.
+	err error
+}
+
+// New returns an organiser for the server at the given base URL, e.g. "http://localhost:8080", authenticating with the
+// given API token.
+func New(base, token string) *Organiser {
+	c := client.New(base)
+	c.Token = token
+	return &Organiser{c: c}
+}
+
+// Ping checks whether the server is reachable. It doesn't retry, so it fails fast if there is no server.
+func (o *Organiser) Ping(ctx context.Context) error {
+	probe := *o.c
+	probe.MaxRetries = 0
+	_, err := probe.ListAll(ctx)
+	return err
+}
+
+// Err returns the error of the last call to Lists or PreviewImport, which can't return errors themselves.
+func (o *Organiser) Err() error {
+	return o.err
+}
+
+func (o *Organiser) Lists() ([]*core.List, []*filter.List) {
+	resp, err := o.c.ListAll(context.Background())
+	if o.err = mapError(err); o.err != nil {
+		return nil, nil
+	}
+
+	lists := make([]*core.List, 0, len(resp.Lists))
+	for _, l := range resp.Lists {
+		cl := fromList(l)
+		lists = append(lists, &cl)
+	}
+	// the API doesn't expose filter definitions, only the names are known
+	filtered := make([]*filter.List, 0, len(resp.FilteredLists))
+	for _, l := range resp.FilteredLists {
+		filtered = append(filtered, &filter.List{Name: l.Name})
+	}
+	return lists, filtered
+}
+
+func (o *Organiser) Groups() []core.Group {
+	resp, err := o.c.Groups(context.Background())
+	if o.err = mapError(err); o.err != nil {
+		return nil
+	}
+	groups := make([]core.Group, 0, len(resp))
+	for _, g := range resp {
+		groups = append(groups, fromGroup(g))
+	}
+	return groups
+}
+
+func (o *Organiser) AddGroup(group api.GroupAdd) (core.Group, error) {
+	g, err := o.c.AddGroup(context.Background(), group)
+	if err != nil {
+		return core.Group{}, mapError(err)
+	}
+	return fromGroup(g), nil
+}
+
+func (o *Organiser) EditGroup(name string, group api.GroupAdd) (core.Group, error) {
+	g, err := o.c.EditGroup(context.Background(), name, group)
+	if err != nil {
+		return core.Group{}, mapError(err)
+	}
+	return fromGroup(g), nil
+}
+
+func (o *Organiser) DelGroup(name string) error {
+	return mapError(o.c.DeleteGroup(context.Background(), name))
+}
+
+func (o *Organiser) OrderGroups(names []string) ([]core.Group, error) {
+	resp, err := o.c.OrderGroups(context.Background(), names)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	groups := make([]core.Group, 0, len(resp))
+	for _, g := range resp {
+		groups = append(groups, fromGroup(g))
+	}
+	return groups, nil
+}
+
+func (o *Organiser) GetList(name string) (core.List, error) {
+	l, filtered, err := o.c.GetList(context.Background(), name)
+	if err != nil {
+		return core.List{}, mapError(err)
+	}
+	if filtered {
+		return core.List{}, repository.ErrListNotFound
+	}
+	return fromList(l), nil
+}
+
+func (o *Organiser) AddList(name string, col core.RGB) (core.List, error) {
+	l, err := o.c.AddList(context.Background(), listAdd(name, col))
+	return fromList(l), mapError(err)
+}
+
+func (o *Organiser) EditList(name string, col core.RGB) (core.List, error) {
+	l, err := o.c.EditList(context.Background(), name, listAdd(name, col))
+	return fromList(l), mapError(err)
+}
+
+func (o *Organiser) DelList(name string) error {
+	return mapError(o.c.DeleteList(context.Background(), name))
+}
+
+func (o *Organiser) ShareList(name, user string, role core.Role) (core.List, error) {
+	l, err := o.c.ShareList(context.Background(), name, user, string(role))
+	return fromList(l), mapError(err)
+}
+
+func (o *Organiser) UnshareList(name, user string) (core.List, error) {
+	if err := o.c.UnshareList(context.Background(), name, user); err != nil {
+		return core.List{}, mapError(err)
+	}
+	return o.GetList(name)
+}
+
+func (o *Organiser) SetStatuses(name string, statuses []string) (core.List, error) {
+	l, err := o.c.SetStatuses(context.Background(), name, statuses)
+	if err != nil {
+		return core.List{}, mapError(err)
+	}
+	return fromList(l), nil
+}
+
+func (o *Organiser) SetArchiveAfter(name string, days int) (core.List, error) {
+	l, err := o.c.SetArchiveAfter(context.Background(), name, days)
+	if err != nil {
+		return core.List{}, mapError(err)
+	}
+	return fromList(l), nil
+}
+
+func (o *Organiser) Archived(list string, from, to time.Time) ([]core.Task, error) {
+	opts := client.ArchiveOptions{List: list, From: from}
+	if !to.IsZero() {
+		// the client's To is the last day
+		opts.To = to.AddDate(0, 0, -1)
+	}
+	tasks, err := o.c.Archive(context.Background(), opts)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	res := make([]core.Task, 0, len(tasks))
+	for _, t := range tasks {
+		res = append(res, fromTask(t))
+	}
+	return res, nil
+}
+
+func (o *Organiser) RestoreTask(id int) (core.Task, error) {
+	t, err := o.c.RestoreTask(context.Background(), id)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) AddItem(list string, item api.TaskAdd) (core.Task, error) {
+	t, err := o.c.AddTask(context.Background(), list, item)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) DelItem(id int) error {
+	return mapError(o.c.DeleteTask(context.Background(), id))
+}
+
+func (o *Organiser) MarkDone(id int, done bool) (core.Task, error) {
+	t, err := o.c.SetDone(context.Background(), id, done)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) GetTask(id int) (core.Task, error) {
+	t, err := o.c.GetTask(context.Background(), id)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) GetFilteredTasks(name string) ([]*core.Task, error) {
+	l, filtered, err := o.c.GetList(context.Background(), name)
+	if err != nil {
+		return nil, mapError(err)
+	}
+	if !filtered {
+		return nil, repository.ErrListNotFound
+	}
+	return fromList(l).Items, nil
+}
+
+func (o *Organiser) UpdateTask(id int, request api.TaskChange) (core.Task, error) {
+	t, err := o.c.UpdateTask(context.Background(), id, request)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) SnoozeTask(id int, until time.Time) (core.Task, error) {
+	var value string
+	if !until.IsZero() {
+		value = until.Format(time.RFC3339)
+	}
+	t, err := o.c.SnoozeTask(context.Background(), id, value)
+	return fromTask(t), mapError(err)
+}
+
+func (o *Organiser) AddComment(taskID int, text string) (core.Comment, error) {
+	c, err := o.c.AddComment(context.Background(), taskID, text)
+	return fromComment(c), mapError(err)
+}
+
+func (o *Organiser) EditComment(taskID, commentID int, text string) (core.Comment, error) {
+	c, err := o.c.EditComment(context.Background(), taskID, commentID, text)
+	return fromComment(c), mapError(err)
+}
+
+func (o *Organiser) DelComment(taskID, commentID int) error {
+	return mapError(o.c.DeleteComment(context.Background(), taskID, commentID))
+}
+
+func (o *Organiser) StartTimer(taskID int) (core.TimeEntry, error) {
+	e, err := o.c.StartTimer(context.Background(), taskID)
+	return fromTimeEntry(e), mapError(err)
+}
+
+func (o *Organiser) StopTimer(taskID int) (core.TimeEntry, error) {
+	e, err := o.c.StopTimer(context.Background(), taskID)
+	return fromTimeEntry(e), mapError(err)
+}
+
+func (o *Organiser) AddTimeEntry(taskID int, entry api.TimeEntryAdd) (core.TimeEntry, error) {
+	e, err := o.c.AddTimeEntry(context.Background(), taskID, entry)
+	return fromTimeEntry(e), mapError(err)
+}
+
+func (o *Organiser) DelTimeEntry(taskID, entryID int) error {
+	return mapError(o.c.DeleteTimeEntry(context.Background(), taskID, entryID))
+}
+
+func (o *Organiser) Import(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) (api.ImportReport, error) {
+	opts := client.ImportOptions{Strategy: strategy}
+	report, err := o.c.Import(context.Background(), api.NewExport(lists, filtered), opts)
+	return report, mapError(err)
+}
+
+// PreviewImport asks the server for an import report without importing. Errors are available via Err.
+func (o *Organiser) PreviewImport(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) api.ImportReport {
+	opts := client.ImportOptions{Strategy: strategy, DryRun: true}
+	report, err := o.c.Import(context.Background(), api.NewExport(lists, filtered), opts)
+	o.err = mapError(err)
+	return report
+}
+
+// mapError maps API errors back to the repository's sentinel errors, so callers can handle them the same way as with
+// a local repository.
+func mapError(err error) error {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+	switch apiErr.Code {
+	case api.CodeListNotFound:
+		return repository.ErrListNotFound
+	case api.CodeListExists:
+		return repository.ErrListExists
+	case api.CodeGroupExists:
+		return repository.ErrGroupExists
+	case api.CodeTaskNotFound:
+		return repository.ErrTaskNotFound
+	case api.CodeForbidden:
+		return repository.ErrForbidden
+	case api.CodeTimerRunning:
+		return repository.ErrTimerRunning
+	case api.CodeTimerNotRunning:
+		return repository.ErrTimerNotRunning
+	case api.CodeValidationFailed:
+		if len(apiErr.Details) == 1 {
+			return &apiErr.Details[0]
+		}
+	}
+	return errors.New(apiErr.Message)
+}
+
+func listAdd(name string, col core.RGB) api.ListAdd {
+	return api.ListAdd{Name: name, Colour: api.RGB{R: col.R, G: col.G, B: col.B}}
+}
+
+func fromGroup(g api.GroupResponse) core.Group {
+	return core.Group{Name: g.Name, Colour: core.RGB{R: g.Colour.R, G: g.Colour.G, B: g.Colour.B}, Lists: g.Lists}
+}
+
+func fromList(l api.ListResponse) core.List {
+	list := core.List{
+		Name:         l.Name,
+		Owner:        l.Owner,
+		Colour:       core.RGB{R: l.Colour.R, G: l.Colour.G, B: l.Colour.B},
+		Statuses:     l.Statuses,
+		ArchiveAfter: l.ArchiveAfter,
+		Items:        make([]*core.Task, 0, len(l.Items)),
+	}
+	for _, m := range l.Members {
+		list.Members = append(list.Members, core.Member{User: m.User, Role: core.Role(m.Role)})
+	}
+	for _, t := range l.Items {
+		task := fromTask(*t)
+		list.Items = append(list.Items, &task)
+	}
+	return list
+}
+
+func fromComment(c api.CommentResponse) core.Comment {
+	comment := core.Comment{ID: c.ID, Author: c.Author, Text: c.Text, Created: c.Created}
+	if c.Edited != nil {
+		comment.Edited = *c.Edited
+	}
+	return comment
+}
+
+func fromTimeEntry(e api.TimeEntryResponse) core.TimeEntry {
+	entry := core.TimeEntry{ID: e.ID, User: e.User, Start: e.Start, Note: e.Note}
+	if e.End != nil {
+		entry.End = *e.End
+	}
+	return entry
+}
+
+func fromTask(t api.TaskResponse) core.Task {
+	task := core.Task{
+		ID:       t.ID,
+		Title:    t.Title,
+		List:     t.List,
+		Done:     t.Done,
+		Priority: t.Priority,
+		AllDay:   t.AllDay,
+		DueType:  core.DueType(t.DueType),
+		Due:      t.Due,
+		Created:  t.Created,
+		DoneOn:   t.DoneOn,
+		Assignee: t.Assignee,
+		// only set while the task is snoozed
+		HiddenUntil: t.HiddenUntil,
+		BlockedBy:   t.BlockedBy,
+		Blocked:     t.Blocked,
+		Tags:        t.Tags,
+		Status:      t.Status,
+		Archived:    t.Archived,
+	}
+	task.Estimate, _ = time.ParseDuration(t.Estimate)
+	for _, r := range t.Reminders {
+		reminder := core.Reminder{At: r.At, Fired: r.Fired}
+		if r.Before != "" {
+			reminder.Relative = true
+			reminder.Before, _ = time.ParseDuration(r.Before)
+		}
+		task.Reminders = append(task.Reminders, reminder)
+	}
+	return task
+}
@@ -0,0 +1,219 @@
+package repository
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// SetArchiveAfter sets the number of days after which done tasks of a list are archived, zero turns archiving off.
+// Only owners may change it.
+func (r *Repository) SetArchiveAfter(name string, days int) (core.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(name, core.RoleOwner)
+	if err != nil {
+		return core.List{}, err
+	}
+	if days < 0 {
+		return core.List{}, &api.FieldError{Field: "archive_after", Message: "archive_after must be a number of days or 0"}
+	}
+
+	l.ArchiveAfter = days
+	return r.saveListChange(l)
+}
+
+// ArchiveDone moves the tasks that are due to be archived at now, see core.List.ToArchive, from their lists to the
+// archive. It returns the number of archived tasks. The archive is written before the lists, so tasks aren't lost if
+// writing the lists fails; they are archived again then, replacing their copy in the archive.
+func (r *Repository) ArchiveDone(now time.Time) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	archive := cloneLists(r.archive)
+	var changed []*core.List
+	// archived are the archived tasks with the list they were in, for notifying subscribers
+	var archived []struct {
+		l *core.List
+		t *core.Task
+	}
+	for _, l := range r.visibleLists() {
+		keep := make([]*core.Task, 0, len(l.Items))
+		var tasks []*core.Task
+		for _, t := range l.Items {
+			if !l.ToArchive(*t, now) {
+				keep = append(keep, t)
+				continue
+			}
+			task := t.Clone()
+			task.Archived = now
+			tasks = append(tasks, &task)
+			archived = append(archived, struct {
+				l *core.List
+				t *core.Task
+			}{l, &task})
+		}
+		if len(tasks) == 0 {
+			continue
+		}
+		al := findList(archive, l.Owner, l.Name)
+		if al == nil {
+			al = &core.List{Name: l.Name, Owner: l.Owner}
+			archive = append(archive, al)
+		}
+		for _, t := range tasks {
+			removeTask(al, t.ID)
+			al.Items = append(al.Items, t)
+		}
+		l.Items = keep
+		changed = append(changed, l)
+	}
+	if len(changed) == 0 {
+		return 0, nil
+	}
+
+	if err := r.store.ReplaceArchive(archive); err != nil {
+		return 0, r.reload(err)
+	}
+	for _, l := range changed {
+		if err := r.store.UpdateList(l.Owner, l.Name, l); err != nil {
+			return 0, r.reload(err)
+		}
+	}
+	if err := r.updateListCache(); err != nil {
+		return 0, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	if err := r.updateArchiveCache(); err != nil {
+		return 0, fmt.Errorf("failed to update archive cache: %w", err)
+	}
+	// archived tasks are done, so they don't block anybody
+	if _, err := r.updateBlocked(); err != nil {
+		return 0, err
+	}
+	for _, a := range archived {
+		r.notify(TaskArchived, a.l, a.t)
+	}
+	return len(archived), nil
+}
+
+// Archived returns the archived tasks the user can see, most recently done first. The tasks may be limited to a list,
+// which may have been deleted since, and to those done from from until to, excluding to. Zero times don't limit the
+// range.
+func (r *Repository) Archived(list string, from, to time.Time) ([]core.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tasks := make([]core.Task, 0)
+	found := false
+	if _, err := r.getList(list); err == nil {
+		found = true
+	}
+	for _, l := range r.visibleArchive() {
+		if list != "" && l.Name != list {
+			continue
+		}
+		found = true
+		for _, t := range l.Items {
+			if !from.IsZero() && t.DoneOn.Before(from) || !to.IsZero() && !t.DoneOn.Before(to) {
+				continue
+			}
+			tasks = append(tasks, t.Clone())
+		}
+	}
+	if list != "" && !found {
+		return nil, ErrListNotFound
+	}
+	slices.SortFunc(tasks, func(a, b core.Task) int { return cmp.Compare(b.DoneOn.UnixNano(), a.DoneOn.UnixNano()) })
+	return tasks, nil
+}
+
+// RestoreTask moves an archived task back to its list, which requires the editor role. The task is open again, as it
+// would otherwise be archived right away. The list is written before the archive, if writing the archive fails the
+// task is restored again, replacing its copy in the list.
+func (r *Repository) RestoreTask(id int) (core.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var task *core.Task
+	var al *core.List
+	for _, l := range r.visibleArchive() {
+		for _, t := range l.Items {
+			if t.ID == id {
+				task, al = t, l
+			}
+		}
+	}
+	if task == nil {
+		return core.Task{}, ErrTaskNotFound
+	}
+	l := findList(r.lists, al.Owner, al.Name)
+	if l == nil {
+		return core.Task{}, fmt.Errorf("%w: %q was deleted", ErrListNotFound, al.Name)
+	}
+	if err := r.checkRole(l, core.RoleEditor); err != nil {
+		return core.Task{}, err
+	}
+
+	restored := task.Clone()
+	restored.Archived = time.Time{}
+	if restored.Done {
+		r.record(&restored, "done", strconv.FormatBool(true), strconv.FormatBool(false))
+		restored.Done, restored.DoneOn = false, time.Time{}
+	}
+	restored.Status = l.StatusFor(restored)
+	list := l.Clone()
+	removeTask(&list, id)
+	list.Items = append(list.Items, &restored)
+	archive := cloneLists(r.archive)
+	removeTask(findList(archive, al.Owner, al.Name), id)
+
+	if err := r.store.UpdateList(list.Owner, list.Name, &list); err != nil {
+		return core.Task{}, r.reload(err)
+	}
+	if err := r.store.ReplaceArchive(archive); err != nil {
+		return core.Task{}, r.reload(err)
+	}
+	if err := r.updateListCache(); err != nil {
+		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	if err := r.updateArchiveCache(); err != nil {
+		return core.Task{}, fmt.Errorf("failed to update archive cache: %w", err)
+	}
+	if _, err := r.updateBlocked(); err != nil {
+		return core.Task{}, err
+	}
+	t, l, err := r.getTask(id)
+	if err != nil {
+		return core.Task{}, err
+	}
+	r.notify(TaskRestored, l, t)
+	return t.Clone(), nil
+}
+
+// visibleArchive returns the archived lists of the lists the user can see. The archive of a deleted list is only
+// visible to its owner.
+func (r *Repository) visibleArchive() []*core.List {
+	lists := make([]*core.List, 0, len(r.archive))
+	for _, al := range r.archive {
+		if l := findList(r.lists, al.Owner, al.Name); l != nil && r.role(l) != "" ||
+			l == nil && (r.user == "" || al.Owner == r.user) {
+			lists = append(lists, al)
+		}
+	}
+	return lists
+}
+
+func (r *Repository) updateArchiveCache() error {
+	archive, err := r.store.GetArchive()
+	if err != nil {
+		return err
+	}
+	r.archive = archive
+	return nil
+}
@@ -0,0 +1,139 @@
+package repository
+
+import (
+	"slices"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// ChangeType is the kind of a change passed to subscribers.
+type ChangeType string
+
+const (
+	TaskCreated   ChangeType = "task.created"
+	TaskUpdated   ChangeType = "task.updated"
+	TaskCompleted ChangeType = "task.completed"
+	TaskDeleted   ChangeType = "task.deleted"
+	ListCreated   ChangeType = "list.created"
+	ListUpdated   ChangeType = "list.updated"
+	ListDeleted   ChangeType = "list.deleted"
+	// TaskArchived and TaskRestored report tasks moved to and from the archive, see ArchiveDone.
+	TaskArchived ChangeType = "task.archived"
+	TaskRestored ChangeType = "task.restored"
+	// TaskReminder is a reminder of a task that is due, see FireReminders.
+	TaskReminder ChangeType = "task.reminder"
+	// Pomodoro changes report the phases of a Pomodoro session on a task, see PomodoroChange. Only the user of the
+	// session sees them.
+	PomodoroStarted  ChangeType = "pomodoro.started"
+	PomodoroBreak    ChangeType = "pomodoro.break"
+	PomodoroFinished ChangeType = "pomodoro.finished"
+	PomodoroStopped  ChangeType = "pomodoro.stopped"
+)
+
+// ChangeTypes are all kinds of changes.
+var ChangeTypes = []ChangeType{
+	TaskCreated, TaskUpdated, TaskCompleted, TaskDeleted, ListCreated, ListUpdated, ListDeleted, TaskArchived,
+	TaskRestored, TaskReminder, PomodoroStarted, PomodoroBreak, PomodoroFinished, PomodoroStopped,
+}
+
+// Change describes a change of a task or list. It only holds copies, so subscribers may keep it.
+type Change struct {
+	Type ChangeType
+	Time time.Time
+	// User made the change, it is empty for changes of the repository of all users.
+	User string
+	// List is the changed list or the list of the changed task, without its tasks.
+	List core.List
+	// Task is the changed task, nil for list changes.
+	Task *core.Task
+	// Pomodoro is the session of Pomodoro changes, nil for other changes.
+	Pomodoro *api.PomodoroSession
+	// Groups maps the users who put the list in one of their groups to the name of that group, see GroupLists.
+	Groups map[string]string
+}
+
+// GroupLists returns the lists of the groups of a user as far as the change knows them, i.e. the list of the change
+// for the group it is in, see filter.Filter.ForGroups.
+func (c Change) GroupLists(user string) func(string) []string {
+	return func(group string) []string {
+		if name, ok := c.Groups[user]; ok && name == group {
+			return []string{c.List.Name}
+		}
+		return nil
+	}
+}
+
+// VisibleTo reports whether a user can see the list of the change, Pomodoro changes only the user of the session.
+// Without user, e.g. if authentication is disabled, all changes are visible, like in the repository of all users.
+func (c Change) VisibleTo(user string) bool {
+	if c.Pomodoro != nil {
+		return user == "" || user == c.User
+	}
+	return user == "" || c.List.Role(user) != ""
+}
+
+// Event returns the change as sent to clients.
+func (c Change) Event() api.ChangeEvent {
+	event := api.ChangeEvent{Event: string(c.Type), Time: c.Time, User: c.User, List: c.List.Name}
+	if c.Task != nil {
+		t := api.FromTask(*c.Task)
+		event.Task = &t
+	}
+	if c.Pomodoro != nil {
+		p := *c.Pomodoro
+		event.Pomodoro = &p
+	}
+	return event
+}
+
+// Subscribe registers a function that is called after every change of a task or list. Imports are not reported. The
+// function is called while the repository is locked, so it must return quickly and must not use the repository.
+func (r *Repository) Subscribe(fn func(Change)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.subscribers = append(r.subscribers, fn)
+}
+
+// notify passes a change of a list, and of a task if t isn't nil, to all subscribers.
+func (r *Repository) notify(typ ChangeType, l *core.List, t *core.Task) {
+	if len(r.subscribers) == 0 {
+		return
+	}
+	c := r.change(typ, l, t)
+	for _, fn := range r.subscribers {
+		fn(c)
+	}
+}
+
+// change returns a change of a list, and of a task if t isn't nil, with copies of both.
+func (r *Repository) change(typ ChangeType, l *core.List, t *core.Task) Change {
+	c := Change{
+		Type: typ,
+		Time: time.Now(),
+		User: r.user,
+		List: core.List{Name: l.Name, Owner: l.Owner, Members: slices.Clone(l.Members), Colour: l.Colour},
+	}
+	for _, g := range r.groups {
+		if (g.Owner == l.Owner || l.Role(g.Owner) != "") && slices.Contains(g.Lists, l.Name) {
+			if c.Groups == nil {
+				c.Groups = make(map[string]string)
+			}
+			c.Groups[g.Owner] = g.Name
+		}
+	}
+	if t != nil {
+		task := t.Clone()
+		c.Task = &task
+	}
+	return c
+}
+
+// notifyTask passes a change of the task with the given ID to all subscribers.
+func (r *Repository) notifyTask(typ ChangeType, id int) {
+	if t, l, err := r.getTask(id); err == nil {
+		r.notify(typ, l, t)
+	}
+}
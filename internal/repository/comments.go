@@ -0,0 +1,126 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+var ErrCommentNotFound = fmt.Errorf("comment not found")
+
+// AddComment adds a comment by the user to a task. Viewers of the list can't comment.
+func (r *Repository) AddComment(taskID int, text string) (core.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return core.Comment{}, err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.Comment{}, err
+	}
+	if err = validateComment(text); err != nil {
+		return core.Comment{}, err
+	}
+
+	c := core.Comment{ID: nextCommentID(t), Author: r.user, Text: text, Created: time.Now()}
+	t.Comments = append(t.Comments, c)
+
+	if _, err = r.saveList(list); err != nil {
+		return core.Comment{}, err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return c, nil
+}
+
+// EditComment changes the text of a comment, editors may only edit their own comments.
+func (r *Repository) EditComment(taskID, commentID int, text string) (core.Comment, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return core.Comment{}, err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.Comment{}, err
+	}
+	if err = validateComment(text); err != nil {
+		return core.Comment{}, err
+	}
+	i, err := r.ownComment(t, commentID)
+	if err != nil {
+		return core.Comment{}, err
+	}
+
+	t.Comments[i].Text = text
+	t.Comments[i].Edited = time.Now()
+	c := t.Comments[i]
+
+	if _, err = r.saveList(list); err != nil {
+		return core.Comment{}, err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return c, nil
+}
+
+// DelComment deletes a comment, editors may only delete their own comments.
+func (r *Repository) DelComment(taskID, commentID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return err
+	}
+	i, err := r.ownComment(t, commentID)
+	if err != nil {
+		return err
+	}
+
+	t.Comments = append(t.Comments[:i], t.Comments[i+1:]...)
+
+	if _, err = r.saveList(list); err != nil {
+		return err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return nil
+}
+
+// nextCommentID returns the ID of a new comment on the task. IDs only increase, also after the newest comment was
+// deleted. Tasks saved before LastCommentID start after their highest comment ID.
+func nextCommentID(t *core.Task) int {
+	for _, c := range t.Comments {
+		t.LastCommentID = max(t.LastCommentID, c.ID)
+	}
+	t.LastCommentID++
+	return t.LastCommentID
+}
+
+// ownComment returns the index of a comment of the user.
+func (r *Repository) ownComment(t *core.Task, commentID int) (int, error) {
+	for i, c := range t.Comments {
+		if c.ID != commentID {
+			continue
+		}
+		if r.user != "" && c.Author != r.user {
+			return 0, fmt.Errorf("%w: comment %d was written by %s", ErrForbidden, commentID, c.Author)
+		}
+		return i, nil
+	}
+	return 0, ErrCommentNotFound
+}
+
+func validateComment(text string) error {
+	if strings.TrimSpace(text) == "" {
+		return &api.FieldError{Field: "text", Message: "missing comment text"}
+	}
+	return nil
+}
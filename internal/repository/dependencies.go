@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// setBlockedBy checks the tasks a task depends on and sets them. They must be visible to the user and must not depend
+// on the task themselves, directly or through other tasks.
+func (r *Repository) setBlockedBy(t *core.Task, ids []int) error {
+	tasks := r.taskIndex()
+	blockedBy := make([]int, 0, len(ids))
+	for _, id := range ids {
+		if slices.Contains(blockedBy, id) {
+			continue
+		}
+		if id == t.ID {
+			return &api.FieldError{Field: "blocked_by", Message: "a task can't block itself"}
+		}
+		// tasks the task already depends on may be in lists the user can't see anymore
+		if _, _, err := r.getTask(id); (err != nil && !slices.Contains(t.BlockedBy, id)) || tasks[id] == nil {
+			return &api.FieldError{Field: "blocked_by", Message: fmt.Sprintf("task %d not found", id)}
+		}
+		if dependsOn(tasks, id, t.ID) {
+			return &api.FieldError{Field: "blocked_by", Message: fmt.Sprintf("task %d is blocked by this task", id)}
+		}
+		blockedBy = append(blockedBy, id)
+	}
+	t.BlockedBy = blockedBy
+	return nil
+}
+
+// dependsOn reports whether the task with ID from is blocked by the task with ID to, directly or through other tasks.
+func dependsOn(tasks map[int]*core.Task, from, to int) bool {
+	seen := make(map[int]bool)
+	stack := []int{from}
+	for len(stack) > 0 {
+		id := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if id == to {
+			return true
+		}
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		if t, ok := tasks[id]; ok {
+			stack = append(stack, t.BlockedBy...)
+		}
+	}
+	return false
+}
+
+// updateBlocked removes references to deleted tasks and updates the blocked flag of the tasks of all users, e.g.
+// after a task was done or deleted. It saves the changed lists and returns the IDs of the tasks whose flag changed. If
+// saving fails, the lists are reloaded, so that the flags are updated again next time.
+func (r *Repository) updateBlocked() ([]int, error) {
+	tasks := r.taskIndex()
+	var changed []int
+	var lists []*core.List
+	for _, l := range r.lists {
+		dirty := false
+		for _, t := range l.Items {
+			blockedBy := slices.DeleteFunc(slices.Clone(t.BlockedBy), func(id int) bool { return tasks[id] == nil })
+			if len(blockedBy) != len(t.BlockedBy) {
+				t.BlockedBy = blockedBy
+				dirty = true
+			}
+			blocked := !t.Done && slices.ContainsFunc(t.BlockedBy, func(id int) bool { return !tasks[id].Done })
+			if blocked != t.Blocked {
+				t.Blocked = blocked
+				changed = append(changed, t.ID)
+				dirty = true
+			}
+		}
+		if dirty {
+			lists = append(lists, l)
+		}
+	}
+	if len(lists) == 0 {
+		return nil, nil
+	}
+
+	for _, l := range lists {
+		if err := r.store.UpdateList(l.Owner, l.Name, l); err != nil {
+			return nil, r.reload(err)
+		}
+	}
+	if err := r.updateListCache(); err != nil {
+		return nil, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	return changed, nil
+}
+
+// notifyBlocked reports the tasks whose blocked flag changed as updated, except the given task, which is reported by
+// the caller. The tasks may be in lists the user can't see.
+func (r *Repository) notifyBlocked(ids []int, except int) {
+	for _, l := range r.lists {
+		for _, t := range l.Items {
+			if t.ID != except && slices.Contains(ids, t.ID) {
+				r.notify(TaskUpdated, l, t)
+			}
+		}
+	}
+}
+
+// taskIndex maps the IDs of the tasks of all users to the tasks. It includes archived tasks, which are done, so that
+// tasks blocked by them keep the dependency and aren't blocked anymore.
+func (r *Repository) taskIndex() map[int]*core.Task {
+	tasks := make(map[int]*core.Task)
+	for _, l := range slices.Concat(r.lists, r.archive) {
+		for _, t := range l.Items {
+			tasks[t.ID] = t
+		}
+	}
+	return tasks
+}
+
+// formatIDs formats task IDs for the history of a task.
+func formatIDs(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = strconv.Itoa(id)
+	}
+	return strings.Join(s, ",")
+}
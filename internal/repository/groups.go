@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+var (
+	ErrGroupNotFound = fmt.Errorf("group not found")
+	ErrGroupExists   = fmt.Errorf("group already exists")
+)
+
+// Groups returns the groups of lists of the user in order. Lists the user can't see anymore are left out.
+func (r *Repository) Groups() []core.Group {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.userGroups()
+}
+
+// userGroups returns copies of the groups of the user without the lists the user can't see.
+func (r *Repository) userGroups() []core.Group {
+	groups := make([]core.Group, 0, len(r.groups))
+	for _, g := range r.visibleGroups() {
+		c := cloneGroup(g)
+		c.Lists = slices.DeleteFunc(c.Lists, func(name string) bool {
+			_, err := r.getList(name)
+			return err != nil
+		})
+		groups = append(groups, c)
+	}
+	return groups
+}
+
+// AddGroup adds a group of lists after the other groups of the user.
+func (r *Repository) AddGroup(group api.GroupAdd) (core.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	name := strings.TrimSpace(group.Name)
+	if name == "" {
+		return core.Group{}, &api.FieldError{Field: "name", Message: "missing group name"}
+	}
+	if _, err := r.getGroup(name); err == nil {
+		return core.Group{}, ErrGroupExists
+	}
+	g := &core.Group{Name: name, Owner: r.user, Colour: core.RGB{R: group.Colour.R, G: group.Colour.G, B: group.Colour.B}}
+	if err := r.setGroupLists(g, group.Lists); err != nil {
+		return core.Group{}, err
+	}
+
+	if err := r.saveGroups(append(slices.Clone(r.groups), g)); err != nil {
+		return core.Group{}, err
+	}
+	return cloneGroup(g), nil
+}
+
+// EditGroup changes the colour and the lists of a group.
+func (r *Repository) EditGroup(name string, group api.GroupAdd) (core.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, err := r.getGroup(name)
+	if err != nil {
+		return core.Group{}, err
+	}
+	g.Colour = core.RGB{R: group.Colour.R, G: group.Colour.G, B: group.Colour.B}
+	if err = r.setGroupLists(g, group.Lists); err != nil {
+		return core.Group{}, err
+	}
+
+	if err = r.saveGroups(r.groups); err != nil {
+		return core.Group{}, err
+	}
+	return cloneGroup(g), nil
+}
+
+// DelGroup deletes a group, its lists aren't in any group afterwards.
+func (r *Repository) DelGroup(name string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	g, err := r.getGroup(name)
+	if err != nil {
+		return err
+	}
+	return r.saveGroups(slices.DeleteFunc(slices.Clone(r.groups), func(other *core.Group) bool { return other == g }))
+}
+
+// OrderGroups moves the given groups of the user to the front in the given order, the other groups follow them.
+func (r *Repository) OrderGroups(names []string) ([]core.Group, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	ordered := make([]*core.Group, 0, len(r.groups))
+	for _, name := range names {
+		g, err := r.getGroup(name)
+		if err != nil {
+			return nil, &api.FieldError{Field: "groups", Message: fmt.Sprintf("unknown group %q", name)}
+		}
+		if slices.Contains(ordered, g) {
+			return nil, &api.FieldError{Field: "groups", Message: fmt.Sprintf("group %q given twice", name)}
+		}
+		ordered = append(ordered, g)
+	}
+	for _, g := range r.visibleGroups() {
+		if !slices.Contains(ordered, g) {
+			ordered = append(ordered, g)
+		}
+	}
+	// the groups of other users keep their places
+	res := make([]*core.Group, 0, len(r.groups))
+	for _, g := range r.groups {
+		if r.ownsGroup(g) {
+			g, ordered = ordered[0], ordered[1:]
+		}
+		res = append(res, g)
+	}
+
+	if err := r.saveGroups(res); err != nil {
+		return nil, err
+	}
+	return r.userGroups(), nil
+}
+
+// setGroupLists sets the lists of a group, which must be lists the user can see, and removes them from the other
+// groups of the user.
+func (r *Repository) setGroupLists(g *core.Group, lists []string) error {
+	for i, name := range lists {
+		if _, err := r.getList(name); err != nil {
+			return &api.FieldError{Field: "lists", Message: fmt.Sprintf("unknown list %q", name)}
+		}
+		if slices.Contains(lists[:i], name) {
+			return &api.FieldError{Field: "lists", Message: fmt.Sprintf("list %q given twice", name)}
+		}
+	}
+	for _, other := range r.visibleGroups() {
+		if other != g {
+			other.Lists = slices.DeleteFunc(other.Lists, func(name string) bool { return slices.Contains(lists, name) })
+		}
+	}
+	g.Lists = slices.Clone(lists)
+	return nil
+}
+
+// forgetList removes a deleted list from the groups of all users who could see it.
+func (r *Repository) forgetList(l *core.List) error {
+	changed := false
+	for _, g := range r.groups {
+		if g.Owner != l.Owner && l.Role(g.Owner) == "" {
+			continue
+		}
+		if i := slices.Index(g.Lists, l.Name); i >= 0 {
+			g.Lists = slices.Delete(g.Lists, i, i+1)
+			changed = true
+		}
+	}
+	if !changed {
+		return nil
+	}
+	return r.saveGroups(r.groups)
+}
+
+// groupLists returns the names of the lists in a group of a user, see filter.Filter.ForGroups.
+func (r *Repository) groupLists(owner string) func(string) []string {
+	return func(name string) []string {
+		for _, g := range r.groups {
+			if g.Owner == owner && g.Name == name {
+				return g.Lists
+			}
+		}
+		return nil
+	}
+}
+
+// ownsGroup reports whether a group belongs to the user, the repository of all users owns all groups.
+func (r *Repository) ownsGroup(g *core.Group) bool {
+	return r.user == "" || g.Owner == r.user
+}
+
+func (r *Repository) visibleGroups() []*core.Group {
+	groups := make([]*core.Group, 0, len(r.groups))
+	for _, g := range r.groups {
+		if r.ownsGroup(g) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
+}
+
+func cloneGroup(g *core.Group) core.Group {
+	c := *g
+	c.Lists = slices.Clone(g.Lists)
+	return c
+}
+
+func (r *Repository) getGroup(name string) (*core.Group, error) {
+	for _, g := range r.visibleGroups() {
+		if g.Name == name {
+			return g, nil
+		}
+	}
+	return nil, ErrGroupNotFound
+}
+
+// saveGroups writes all groups to the store.
+func (r *Repository) saveGroups(groups []*core.Group) error {
+	if err := r.store.ReplaceGroups(groups); err != nil {
+		return err
+	}
+	if err := r.updateGroupCache(); err != nil {
+		return fmt.Errorf("failed to update group cache: %w", err)
+	}
+	return nil
+}
+
+func (r *Repository) updateGroupCache() error {
+	groups, err := r.store.GetGroups()
+	if err != nil {
+		return err
+	}
+	r.groups = groups
+	return nil
+}
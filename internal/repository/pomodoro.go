@@ -0,0 +1,57 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// CheckPomodoro returns an error if the user can't focus on a task, i.e. if the task doesn't exist or the user is only
+// a viewer of its list.
+func (r *Repository) CheckPomodoro(taskID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, list, err := r.getTask(taskID)
+	if err != nil {
+		return err
+	}
+	return r.checkRole(list, core.RoleEditor)
+}
+
+// RecordPomodoro adds a completed focus session of the user to a task, see CheckPomodoro.
+func (r *Repository) RecordPomodoro(taskID int, start time.Time, focus time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return err
+	}
+	t.Pomodoros = append(t.Pomodoros, core.Pomodoro{User: r.user, Start: start, Focus: focus})
+
+	if _, err = r.saveList(list); err != nil {
+		return err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return nil
+}
+
+// PomodoroChange returns a change of the given Pomodoro type for a session of the user on a task. Subscribers aren't
+// notified, the caller delivers the change.
+func (r *Repository) PomodoroChange(typ ChangeType, session api.PomodoroSession) (Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(session.Task)
+	if err != nil {
+		return Change{}, err
+	}
+	c := r.change(typ, list, t)
+	c.Pomodoro = &session
+	return c, nil
+}
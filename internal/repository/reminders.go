@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// FireReminders marks all reminders due at now as fired and returns them as changes of type TaskReminder, one per
+// reminder. They are saved before they are returned, so every reminder is returned only once, also across restarts.
+// Reminders of done tasks are marked as fired without being returned. Subscribers aren't notified, the caller
+// delivers the reminders. If saving a list fails, the reminders of the lists saved before are returned with the error,
+// the others fire again next time.
+func (r *Repository) FireReminders(now time.Time) ([]Change, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// the reminders are marked on copies of the lists, so the cache only changes once they are saved
+	var fired []*core.List
+	var firedChanges [][]Change
+	for _, l := range r.visibleLists() {
+		var c *core.List
+		var changes []Change
+		for i, t := range l.Items {
+			for j, rem := range t.Reminders {
+				if rem.Fired || rem.At.After(now) {
+					continue
+				}
+				if c == nil {
+					clone := l.Clone()
+					c = &clone
+				}
+				task := c.Items[i]
+				task.Reminders[j].Fired = true
+				if !task.Done {
+					changes = append(changes, r.change(TaskReminder, c, task))
+					changes[len(changes)-1].Time = rem.At
+				}
+			}
+		}
+		if c != nil {
+			fired = append(fired, c)
+			firedChanges = append(firedChanges, changes)
+		}
+	}
+	if len(fired) == 0 {
+		return nil, nil
+	}
+
+	var changes []Change
+	for i, l := range fired {
+		if err := r.store.UpdateList(l.Owner, l.Name, l); err != nil {
+			if cacheErr := r.updateListCache(); cacheErr != nil {
+				err = errors.Join(err, fmt.Errorf("failed to update list cache: %w", cacheErr))
+			}
+			return changes, err
+		}
+		changes = append(changes, firedChanges[i]...)
+	}
+	if err := r.updateListCache(); err != nil {
+		return changes, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	return changes, nil
+}
+
+// setReminders parses reminders as sent in TaskAdd and TaskChange and sets them on a task.
+func setReminders(t *core.Task, values []string) error {
+	reminders := make([]core.Reminder, 0, len(values))
+	for _, v := range values {
+		rem, err := core.ParseReminder(v)
+		if err != nil {
+			return &api.FieldError{Field: "reminders", Message: err.Error()}
+		}
+		reminders = append(reminders, rem)
+	}
+	if err := t.SetReminders(reminders); err != nil {
+		return &api.FieldError{Field: "reminders", Message: err.Error()}
+	}
+	return nil
+}
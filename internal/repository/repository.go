@@ -1,7 +1,12 @@
 package repository
 
 import (
+	"errors"
 	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/jniewt/gotodo/api"
@@ -9,25 +14,53 @@ import (
 	"github.com/jniewt/gotodo/internal/filter"
 )
 
-// Storage defines the interface for task list storage operations.
+// Storage defines the interface for task list storage operations. Lists and filtered lists are identified by their
+// owner and name.
 type Storage interface {
-	GetList(name string) (*core.List, error)
+	GetList(owner, name string) (*core.List, error)
 	GetAllLists() ([]*core.List, error)
 	AddList(list *core.List) error
-	UpdateList(name string, list *core.List) error
-	DeleteList(name string) error
-	GetFiltered(name string) (*filter.List, error)
+	UpdateList(owner, name string, list *core.List) error
+	DeleteList(owner, name string) error
+	GetFiltered(owner, name string) (*filter.List, error)
 	GetAllFiltered() ([]*filter.List, error)
 	AddFiltered(list *filter.List) error
-	DeleteFiltered(name string) error
+	DeleteFiltered(owner, name string) error
+	// Replace atomically replaces all lists and filtered lists.
+	Replace(lists []*core.List, filtered []*filter.List) error
+	// GetArchive returns the archived tasks in lists with the owner and name of the lists they were archived from.
+	GetArchive() ([]*core.List, error)
+	// ReplaceArchive replaces all archived tasks.
+	ReplaceArchive(lists []*core.List) error
+	// GetGroups returns the groups of lists of all users in order.
+	GetGroups() ([]*core.Group, error)
+	// ReplaceGroups replaces all groups of lists.
+	ReplaceGroups(groups []*core.Group) error
 }
 
 // Repository provides access to the task list storage. It keeps a cache of all lists and filtered lists to avoid
 // unnecessary reads.
+//
+// A repository returned by As only sees the filtered lists of one user and the lists the user owns or is a member of,
+// list names are unique among them. Every method checks the role of the user in the lists it touches. The repository
+// returned by NewRepository sees and may change the data of all users, it is meant for local use and maintenance.
 type Repository struct {
+	*state
+	// user restricts the repository to the lists of a user, empty means all lists
+	user string
+}
+
+// state is shared by all views of a repository.
+type state struct {
+	mu       sync.Mutex
 	lists    []*core.List
 	filtered []*filter.List
-	store    Storage
+	// archive holds the archived tasks in lists like lists, see ArchiveDone
+	archive []*core.List
+	groups  []*core.Group
+	store   Storage
+	// subscribers are notified of changes, see Subscribe
+	subscribers []func(Change)
 }
 
 // NewRepository creates a new repository.
@@ -40,36 +73,166 @@ func NewRepository(store Storage) *Repository {
 	if err != nil {
 		panic(fmt.Sprintf("failed to load filtered lists: %v", err))
 	}
-	return &Repository{
+	archive, err := store.GetArchive()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load archive: %v", err))
+	}
+	groups, err := store.GetGroups()
+	if err != nil {
+		panic(fmt.Sprintf("failed to load groups: %v", err))
+	}
+	return &Repository{state: &state{
 		lists:    lists,
 		filtered: filtered,
+		archive:  archive,
+		groups:   groups,
 		store:    store,
+	}}
+
+}
+
+// As returns a view of the repository restricted to the lists and filtered lists of the given user. New lists are
+// owned by the user. The view shares the cache with the repository and is safe for concurrent use.
+func (r *Repository) As(user string) *Repository {
+	return &Repository{state: r.state, user: user}
+}
+
+// ClaimUnowned makes the given user the owner of all lists and filtered lists without owner, e.g. those created before
+// there were users. Lists clashing with a list of the user are renamed. It returns the number of claimed lists and
+// filtered lists.
+func (r *Repository) ClaimUnowned(user string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	lists, filtered := cloneLists(r.lists), cloneFiltered(r.filtered)
+	claimed := 0
+	for _, l := range lists {
+		if l.Owner != "" {
+			continue
+		}
+		if findList(lists, user, l.Name) != nil {
+			l.Name = uniqueName(l.Name, func(n string) bool { return findList(lists, user, n) != nil })
+			for _, t := range l.Items {
+				t.List = l.Name
+			}
+		}
+		l.Owner = user
+		claimed++
+	}
+	for _, fl := range filtered {
+		if fl.Owner != "" {
+			continue
+		}
+		if findFiltered(filtered, user, fl.Name) != nil {
+			fl.Name = uniqueName(fl.Name, func(n string) bool { return findFiltered(filtered, user, n) != nil })
+		}
+		fl.Owner = user
+		claimed++
+	}
+	if claimed == 0 {
+		return 0, nil
+	}
+
+	if err := r.store.Replace(lists, filtered); err != nil {
+		return 0, err
 	}
+	if err := r.updateListCache(); err != nil {
+		return 0, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	if err := r.updateFilteredListCache(); err != nil {
+		return 0, fmt.Errorf("failed to update filtered list cache: %w", err)
+	}
+	return claimed, nil
+}
 
+// DeleteUser removes the data of a deleted user, so a new user with the same name doesn't inherit it: their lists with
+// their archived tasks, filtered lists and groups are deleted, they are removed from the members of shared lists and
+// their tasks in other lists are unassigned. The lists are written last, so that calling DeleteUser again after an
+// error deletes the rest.
+func (r *Repository) DeleteUser(user string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var lists, deleted []*core.List
+	for _, l := range cloneLists(r.lists) {
+		if l.Owner == user {
+			deleted = append(deleted, l)
+			continue
+		}
+		l.Members = slices.DeleteFunc(l.Members, func(m core.Member) bool { return m.User == user })
+		for _, t := range l.Items {
+			if t.Assignee == user {
+				t.Assignee = ""
+			}
+		}
+		lists = append(lists, l)
+	}
+	filtered := slices.DeleteFunc(cloneFiltered(r.filtered), func(fl *filter.List) bool { return fl.Owner == user })
+	archive := slices.DeleteFunc(cloneLists(r.archive), func(l *core.List) bool { return l.Owner == user })
+
+	for _, l := range deleted {
+		if err := r.forgetList(l); err != nil {
+			return r.reload(err)
+		}
+	}
+	if err := r.saveGroups(slices.DeleteFunc(slices.Clone(r.groups), func(g *core.Group) bool { return g.Owner == user })); err != nil {
+		return r.reload(err)
+	}
+	if err := r.store.ReplaceArchive(archive); err != nil {
+		return r.reload(err)
+	}
+	if err := r.store.Replace(lists, filtered); err != nil {
+		return r.reload(err)
+	}
+	if err := r.updateListCache(); err != nil {
+		return fmt.Errorf("failed to update list cache: %w", err)
+	}
+	if err := r.updateFilteredListCache(); err != nil {
+		return fmt.Errorf("failed to update filtered list cache: %w", err)
+	}
+	if err := r.updateArchiveCache(); err != nil {
+		return fmt.Errorf("failed to update archive cache: %w", err)
+	}
+	changed, err := r.updateBlocked()
+	if err != nil {
+		return err
+	}
+	for _, l := range deleted {
+		r.notify(ListDeleted, l, nil)
+	}
+	r.notifyBlocked(changed, 0)
+	return nil
 }
 
-// GetList returns a list by name.
+// GetList returns a copy of a list by name.
 func (r *Repository) GetList(name string) (core.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
 	list, err := r.getList(name)
 	if err != nil {
 		return core.List{}, err
 	}
-	return *list, err
+	return list.Clone(), err
 }
 
-// Lists returns the names of all lists and filtered lists.
+// Lists returns copies of all lists and filtered lists.
 func (r *Repository) Lists() ([]*core.List, []*filter.List) {
-	return r.lists, r.filtered
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return cloneLists(r.visibleLists()), cloneFiltered(r.visibleFiltered())
 }
 
 // AddList adds a new list.
 func (r *Repository) AddList(name string, colour core.RGB) (core.List, error) {
-	for _, list := range r.lists {
-		if list.Name == name {
-			return core.List{}, ErrListExists
-		}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.getList(name); err == nil {
+		return core.List{}, ErrListExists
 	}
-	l := core.List{Name: name, Colour: colour}
+	l := core.List{Name: name, Owner: r.user, Colour: colour}
 	err := r.store.AddList(&l)
 	if err != nil {
 		return core.List{}, err
@@ -79,17 +242,21 @@ func (r *Repository) AddList(name string, colour core.RGB) (core.List, error) {
 	if err != nil {
 		return core.List{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
+	r.notify(ListCreated, &l, nil)
 	return l, nil
 }
 
 // EditList updates a list.
 func (r *Repository) EditList(name string, colour core.RGB) (core.List, error) {
-	l, err := r.getList(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(name, core.RoleOwner)
 	if err != nil {
 		return core.List{}, err
 	}
 	l.Colour = colour
-	err = r.store.UpdateList(name, l)
+	err = r.store.UpdateList(l.Owner, name, l)
 	if err != nil {
 		return core.List{}, err
 	}
@@ -98,13 +265,21 @@ func (r *Repository) EditList(name string, colour core.RGB) (core.List, error) {
 	if err != nil {
 		return core.List{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
-	return *l, nil
+	r.notify(ListUpdated, l, nil)
+	return l.Clone(), nil
 
 }
 
 // DelList deletes a list.
 func (r *Repository) DelList(name string) error {
-	err := r.store.DeleteList(name)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(name, core.RoleOwner)
+	if err != nil {
+		return err
+	}
+	err = r.store.DeleteList(l.Owner, name)
 	if err != nil {
 		return err
 	}
@@ -112,17 +287,24 @@ func (r *Repository) DelList(name string) error {
 	if err != nil {
 		return fmt.Errorf("failed to update list cache: %w", err)
 	}
+	if err = r.forgetList(l); err != nil {
+		return err
+	}
+	r.notify(ListDeleted, l, nil)
 	return nil
 }
 
 // AddFilteredList adds a new virtual list.
 func (r *Repository) AddFilteredList(name string, f filter.Filter) (filter.List, error) {
-	for _, l := range r.filtered {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.visibleFiltered() {
 		if l.Name == name {
 			return filter.List{}, ErrListExists
 		}
 	}
-	fl := &filter.List{Name: name, Filter: f}
+	fl := &filter.List{Name: name, Owner: r.user, Filter: f}
 	err := r.store.AddFiltered(fl)
 	if err != nil {
 		return filter.List{}, err
@@ -136,24 +318,50 @@ func (r *Repository) AddFilteredList(name string, f filter.Filter) (filter.List,
 	return *fl, nil
 }
 
-// GetFilteredTasks returns the tasks for a virtual list.
+// GetFilteredTasks returns copies of the tasks for a virtual list. Snoozed tasks are left out unless the filter has an
+// available rule.
 func (r *Repository) GetFilteredTasks(name string) ([]*core.Task, error) {
-	for _, l := range r.filtered {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, l := range r.visibleFiltered() {
 		if l.Name == name {
-			return r.filterTasks(l.Filter), nil
+			found := r.filterTasks(l.Filter.ForUser(r.user).ForGroups(r.groupLists(l.Owner)))
+			tasks := make([]*core.Task, 0, len(found))
+			for _, t := range found {
+				task := t.Clone()
+				tasks = append(tasks, &task)
+			}
+			return tasks, nil
 		}
 	}
 	return nil, ErrListNotFound
 }
 
+// FindTasks returns copies of the tasks matching a filter.
+func (r *Repository) FindTasks(f filter.Filter) []core.Task {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	found := r.filterTasks(f.ForUser(r.user).ForGroups(r.groupLists(r.user)))
+	tasks := make([]core.Task, 0, len(found))
+	for _, t := range found {
+		tasks = append(tasks, t.Clone())
+	}
+	return tasks
+}
+
 func (r *Repository) AddItem(list string, task api.TaskAdd) (core.Task, error) {
-	l, err := r.getList(list)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(list, core.RoleEditor)
 	if err != nil {
 		return core.Task{}, err
 	}
 
 	if task.Title == "" {
-		return core.Task{}, fmt.Errorf("missing task title")
+		return core.Task{}, &api.FieldError{Field: "title", Message: "missing task title"}
 	}
 
 	item := core.Task{
@@ -165,11 +373,33 @@ func (r *Repository) AddItem(list string, task api.TaskAdd) (core.Task, error) {
 		DueType:  task.DueType,
 		Due:      task.Due,
 		Created:  time.Now(),
+		Status:   task.Status,
+	}
+	if task.Status != "" {
+		if err = checkStatus(l, task.Status); err != nil {
+			return core.Task{}, err
+		}
+		if isDoneStatus(l, task.Status) {
+			item.Done, item.DoneOn = true, item.Created
+		}
+	}
+	item.Status = l.StatusFor(item)
+	if item.Estimate, err = parseEstimate(task.Estimate); err != nil {
+		return core.Task{}, err
+	}
+	if err = setTags(&item, task.Tags); err != nil {
+		return core.Task{}, err
+	}
+	if err = setReminders(&item, task.Reminders); err != nil {
+		return core.Task{}, err
+	}
+	if err = r.setBlockedBy(&item, task.BlockedBy); err != nil {
+		return core.Task{}, err
 	}
 
 	l.Items = append(l.Items, &item)
 
-	err = r.store.UpdateList(list, l)
+	err = r.store.UpdateList(l.Owner, list, l)
 	if err != nil {
 		return core.Task{}, err
 	}
@@ -178,74 +408,175 @@ func (r *Repository) AddItem(list string, task api.TaskAdd) (core.Task, error) {
 	if err != nil {
 		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
+	if _, err = r.updateBlocked(); err != nil {
+		return core.Task{}, err
+	}
+	t, l, err := r.getTask(item.ID)
+	if err != nil {
+		return core.Task{}, err
+	}
+	r.notify(TaskCreated, l, t)
 
-	return item, nil
+	return t.Clone(), nil
 }
 
 func (r *Repository) DelItem(id int) error {
-	for _, list := range r.lists {
-		for i, item := range list.Items {
-			if item.ID != id {
-				continue
-			}
-			list.Items = append(list.Items[:i], list.Items[i+1:]...)
-			err := r.store.UpdateList(list.Name, list)
-			if err != nil {
-				return err
-			}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-			err = r.updateListCache()
-			if err != nil {
-				return fmt.Errorf("failed to update list cache: %w", err)
-			}
-			return nil
-		}
+	t, list, err := r.getTask(id)
+	if err != nil {
+		return err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return err
 	}
 
-	return fmt.Errorf("item not found")
+	removeTask(list, id)
+	err = r.store.UpdateList(list.Owner, list.Name, list)
+	if err != nil {
+		return err
+	}
+
+	err = r.updateListCache()
+	if err != nil {
+		return fmt.Errorf("failed to update list cache: %w", err)
+	}
+	// tasks blocked by the deleted one forget it
+	changed, err := r.updateBlocked()
+	if err != nil {
+		return err
+	}
+	r.notify(TaskDeleted, list, t)
+	r.notifyBlocked(changed, id)
+	return nil
 }
 
 func (r *Repository) GetTask(id int) (core.Task, error) {
-	t, err := r.getTask(id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, _, err := r.getTask(id)
 	if err != nil {
 		return core.Task{}, err
 	}
-	return *t, nil
+	return t.Clone(), nil
 }
 
 func (r *Repository) UpdateTask(id int, change api.TaskChange) (core.Task, error) {
-	t, err := r.getTask(id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(id)
 	if err != nil {
 		return core.Task{}, err
 	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.Task{}, err
+	}
+	if change.Assignee != "" {
+		target, err := r.getList(change.List)
+		if err != nil {
+			return core.Task{}, err
+		}
+		if target.Owner != "" && target.Role(change.Assignee) == "" {
+			msg := fmt.Sprintf("%s can't access list %q", change.Assignee, target.Name)
+			return core.Task{}, &api.FieldError{Field: "assignee", Message: msg}
+		}
+	}
+
+	// check the reminders before the changes below are saved
+	probe := *t
+	probe.DueType, probe.Due = change.DueType, change.Due
+	if err = setReminders(&probe, change.Reminders); err != nil {
+		return core.Task{}, err
+	}
+	if err = r.setBlockedBy(&probe, change.BlockedBy); err != nil {
+		return core.Task{}, err
+	}
+	estimate, err := parseEstimate(change.Estimate)
+	if err != nil {
+		return core.Task{}, err
+	}
+	if err = setTags(&probe, change.Tags); err != nil {
+		return core.Task{}, err
+	}
+
+	// a new status takes precedence over done, the last status of the workflow means done
+	status := ""
+	if change.Status != "" && change.Status != t.CurrentStatus() {
+		target, err := r.getList(change.List)
+		if err != nil {
+			return core.Task{}, err
+		}
+		if err = checkStatus(target, change.Status); err != nil {
+			return core.Task{}, err
+		}
+		status = change.Status
+		change.Done = isDoneStatus(target, status)
+	}
+
+	changeType := TaskUpdated
+	if !t.Done && change.Done {
+		changeType = TaskCompleted
+	}
 
 	if t.Done != change.Done {
-		_, err = r.MarkDone(id, change.Done)
+		_, err = r.markDone(id, change.Done)
 		if err != nil {
 			return core.Task{}, err
 		}
 	}
 	if t.List != change.List {
-		_, err = r.MoveTask(id, change.List)
+		_, err = r.moveTask(id, change.List)
 		if err != nil {
 			return core.Task{}, err
 		}
 	}
+	// the cache is reloaded by the changes above
+	if t, list, err = r.getTask(id); err != nil {
+		return core.Task{}, err
+	}
 
-	if t.DueType != change.DueType || t.Due != change.Due {
+	if t.DueType != change.DueType || !t.Due.Equal(change.Due) {
+		r.record(t, "due", formatDue(t.DueType, t.Due), formatDue(change.DueType, change.Due))
 		t.DueType = change.DueType
 		t.Due = change.Due
 	}
+	if t.Title != change.Title {
+		r.record(t, "title", t.Title, change.Title)
+	}
+	if t.Priority != change.Priority {
+		r.record(t, "priority", strconv.Itoa(t.Priority), strconv.Itoa(change.Priority))
+	}
+	if t.Assignee != change.Assignee {
+		r.record(t, "assignee", t.Assignee, change.Assignee)
+	}
+	if !slices.Equal(t.BlockedBy, probe.BlockedBy) {
+		r.record(t, "blocked_by", formatIDs(t.BlockedBy), formatIDs(probe.BlockedBy))
+	}
+	if t.Estimate != estimate {
+		r.record(t, "estimate", formatEstimate(t.Estimate), formatEstimate(estimate))
+	}
+	if !slices.Equal(t.Tags, probe.Tags) {
+		r.record(t, "tags", strings.Join(t.Tags, ","), strings.Join(probe.Tags, ","))
+	}
 
 	t.Title = change.Title
 	t.Priority = change.Priority
 	t.AllDay = change.AllDay
-
-	list, err := r.getList(t.List)
-	if err != nil {
-		panic(fmt.Sprintf("list %v for task %d not found", t.List, id))
+	t.Assignee = change.Assignee
+	t.BlockedBy = probe.BlockedBy
+	t.Estimate = estimate
+	t.Tags = probe.Tags
+	if status != "" {
+		r.setStatus(t, status)
+	}
+	if err = setReminders(t, change.Reminders); err != nil {
+		return core.Task{}, err
 	}
-	err = r.store.UpdateList(list.Name, list)
+
+	err = r.store.UpdateList(list.Owner, list.Name, list)
 	if err != nil {
 		return core.Task{}, err
 	}
@@ -254,45 +585,60 @@ func (r *Repository) UpdateTask(id int, change api.TaskChange) (core.Task, error
 	if err != nil {
 		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
+	changed, err := r.updateBlocked()
+	if err != nil {
+		return core.Task{}, err
+	}
+	if t, list, err = r.getTask(id); err != nil {
+		return core.Task{}, err
+	}
+	r.notify(changeType, list, t)
+	r.notifyBlocked(changed, id)
 
-	return *t, nil
+	return t.Clone(), nil
 }
 
 func (r *Repository) MoveTask(id int, list string) (core.Task, error) {
-	task, err := r.getTask(id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, err := r.moveTask(id, list)
 	if err != nil {
 		return core.Task{}, err
 	}
+	r.notifyTask(TaskUpdated, id)
+	return t, nil
+}
 
-	listFrom, err := r.getList(task.List)
+func (r *Repository) moveTask(id int, list string) (core.Task, error) {
+	task, listFrom, err := r.getTask(id)
 	if err != nil {
-		panic(fmt.Sprintf("list %v for task %d not found", task.List, id))
+		return core.Task{}, err
+	}
+	if err = r.checkRole(listFrom, core.RoleEditor); err != nil {
+		return core.Task{}, err
 	}
 
-	listTo, err := r.getList(list)
+	listTo, err := r.listWithRole(list, core.RoleEditor)
 	if err != nil {
 		return core.Task{}, err
 	}
 
-	// remove task from listFrom
-	for i, item := range listFrom.Items {
-		if item.ID == id {
-			listFrom.Items = append(listFrom.Items[:i], listFrom.Items[i+1:]...)
-			break
-		}
-	}
+	removeTask(listFrom, id)
 
 	// add task to listTo
 	listTo.Items = append(listTo.Items, task)
 
 	// update task list
+	r.record(task, "list", task.List, list)
 	task.List = list
+	r.setStatus(task, listTo.StatusFor(*task))
 
-	err = r.store.UpdateList(listFrom.Name, listFrom)
+	err = r.store.UpdateList(listFrom.Owner, listFrom.Name, listFrom)
 	if err != nil {
 		return core.Task{}, err
 	}
-	err = r.store.UpdateList(listTo.Name, listTo)
+	err = r.store.UpdateList(listTo.Owner, listTo.Name, listTo)
 	if err != nil {
 		return core.Task{}, err
 	}
@@ -302,26 +648,54 @@ func (r *Repository) MoveTask(id int, list string) (core.Task, error) {
 		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
 
-	return *task, nil
+	return task.Clone(), nil
 }
 
 func (r *Repository) MarkDone(id int, done bool) (core.Task, error) {
-	task, err := r.getTask(id)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, err := r.markDone(id, done)
+	if err != nil {
+		return core.Task{}, err
+	}
+	// the tasks waiting for this one are unblocked or blocked again
+	changed, err := r.updateBlocked()
+	if err != nil {
+		return core.Task{}, err
+	}
+	if updated, _, err := r.getTask(id); err == nil {
+		t = updated.Clone()
+	}
+	if done {
+		r.notifyTask(TaskCompleted, id)
+	} else {
+		r.notifyTask(TaskUpdated, id)
+	}
+	r.notifyBlocked(changed, id)
+	return t, nil
+}
+
+func (r *Repository) markDone(id int, done bool) (core.Task, error) {
+	task, list, err := r.getTask(id)
 	if err != nil {
 		return core.Task{}, err
 	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.Task{}, err
+	}
+	if task.Done != done {
+		r.record(task, "done", strconv.FormatBool(task.Done), strconv.FormatBool(done))
+	}
 	task.Done = done
 	if done {
 		task.DoneOn = time.Now()
 	} else {
 		task.DoneOn = time.Time{}
 	}
+	r.setStatus(task, list.StatusFor(*task))
 
-	list, err := r.getList(task.List)
-	if err != nil {
-		panic(fmt.Sprintf("list %v for task %d not found", task.List, id))
-	}
-	err = r.store.UpdateList(list.Name, list)
+	err = r.store.UpdateList(list.Owner, list.Name, list)
 	if err != nil {
 		return core.Task{}, err
 	}
@@ -331,13 +705,275 @@ func (r *Repository) MarkDone(id int, done bool) (core.Task, error) {
 		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
 	}
 
-	return *task, nil
+	return task.Clone(), nil
+}
+
+// ShareList gives a user a role in a list, only owners of the list may share it. The user must not see another list
+// with the same name.
+func (r *Repository) ShareList(name, user string, role core.Role) (core.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(name, core.RoleOwner)
+	if err != nil {
+		return core.List{}, err
+	}
+	if user == l.Owner {
+		return core.List{}, &api.FieldError{Field: "user", Message: "the role of the list's owner can't be changed"}
+	}
+	if l.Role(user) == "" {
+		if _, err = r.As(user).getList(name); err == nil {
+			return core.List{}, fmt.Errorf("%w: %s already has a list named %q", ErrListExists, user, name)
+		}
+	}
+
+	members := make([]core.Member, 0, len(l.Members)+1)
+	for _, m := range l.Members {
+		if m.User != user {
+			members = append(members, m)
+		}
+	}
+	l.Members = append(members, core.Member{User: user, Role: role})
+	return r.saveListChange(l)
+}
+
+// UnshareList removes a user from the members of a list. Owners of the list may remove anyone, other members only
+// themselves.
+func (r *Repository) UnshareList(name, user string) (core.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.getList(name)
+	if err != nil {
+		return core.List{}, err
+	}
+	if user != r.user {
+		if err = r.checkRole(l, core.RoleOwner); err != nil {
+			return core.List{}, err
+		}
+	}
+
+	members := make([]core.Member, 0, len(l.Members))
+	for _, m := range l.Members {
+		if m.User != user {
+			members = append(members, m)
+		}
+	}
+	if len(members) == len(l.Members) {
+		return core.List{}, fmt.Errorf("%w: %s is no member of %q", ErrMemberNotFound, user, name)
+	}
+	l.Members = members
+	return r.saveListChange(l)
+}
+
+// saveList writes a changed list to the store.
+func (r *Repository) saveList(l *core.List) (core.List, error) {
+	if err := r.store.UpdateList(l.Owner, l.Name, l); err != nil {
+		return core.List{}, err
+	}
+	if err := r.updateListCache(); err != nil {
+		return core.List{}, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	return l.Clone(), nil
+}
+
+// saveListChange writes a changed list to the store and notifies subscribers.
+func (r *Repository) saveListChange(l *core.List) (core.List, error) {
+	saved, err := r.saveList(l)
+	if err != nil {
+		return core.List{}, err
+	}
+	r.notify(ListUpdated, l, nil)
+	return saved, nil
 }
 
-// newID returns a new unique ID. This is a naive implementation that iterates over all items to find the highest ID.
+// Import merges the given lists and filtered lists into the repository using the given strategy to resolve conflicts
+// on list names and task IDs. Tasks with ID 0 get a new ID. The import is all-or-nothing: the merged state is built on
+// a copy and written to the store in a single operation.
+func (r *Repository) Import(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) (api.ImportReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	merged, mergedFiltered, report := r.merge(lists, filtered, strategy)
+	// imported tasks may come from lists with another workflow
+	for _, l := range merged {
+		for _, t := range l.Items {
+			t.Status = l.StatusFor(*t)
+		}
+	}
+
+	if err := r.store.Replace(merged, mergedFiltered); err != nil {
+		return api.ImportReport{}, err
+	}
+
+	if err := r.updateListCache(); err != nil {
+		return api.ImportReport{}, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	if err := r.updateFilteredListCache(); err != nil {
+		return api.ImportReport{}, fmt.Errorf("failed to update filtered list cache: %w", err)
+	}
+	if _, err := r.updateBlocked(); err != nil {
+		return api.ImportReport{}, err
+	}
+
+	return report, nil
+}
+
+// PreviewImport returns the report Import would return without changing anything.
+func (r *Repository) PreviewImport(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) api.ImportReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, _, report := r.merge(lists, filtered, strategy)
+	return report
+}
+
+// merge merges the given lists and filtered lists into copies of the cached ones. Only the lists visible to the user
+// take part in the merge. Lists the user may not edit are never changed, their imported tasks are skipped. Imported
+// tasks with the ID of a task the user may not edit or of an archived task get a new ID.
+func (r *Repository) merge(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) ([]*core.List, []*filter.List, api.ImportReport) {
+	report := api.ImportReport{Strategy: strategy, Conflicts: []api.ImportConflict{}}
+
+	merged := cloneLists(r.lists)
+	mergedFiltered := cloneFiltered(r.filtered)
+	owner := r.user
+	findVisible := func(name string) *core.List {
+		for _, l := range merged {
+			if l.Name == name && r.role(l) != "" {
+				return l
+			}
+		}
+		return nil
+	}
+
+	// taskLists maps the IDs of the editable tasks to their list
+	taskLists := make(map[int]*core.List)
+	foreign := make(map[int]bool)
+	maxID := 0
+	for _, l := range merged {
+		for _, t := range l.Items {
+			if r.role(l).Includes(core.RoleEditor) {
+				taskLists[t.ID] = l
+			} else {
+				foreign[t.ID] = true
+			}
+			maxID = max(maxID, t.ID)
+		}
+	}
+	// archived tasks keep their IDs, so they can be restored
+	for _, l := range r.archive {
+		for _, t := range l.Items {
+			foreign[t.ID] = true
+			maxID = max(maxID, t.ID)
+		}
+	}
+	for _, l := range lists {
+		for _, t := range l.Items {
+			maxID = max(maxID, t.ID)
+		}
+	}
+	nextID := func() int {
+		maxID++
+		return maxID
+	}
+	// newIDs maps the IDs of imported tasks that got a new ID to it
+	newIDs := make(map[int]int)
+	var imported []*core.Task
+
+	for _, l := range lists {
+		target := findVisible(l.Name)
+		switch {
+		case target != nil && !r.role(target).Includes(core.RoleEditor):
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "list", Name: l.Name, Resolution: api.MergeSkip})
+			report.TasksSkipped += len(l.Items)
+			continue
+		case target == nil:
+			target = &core.List{Name: l.Name, Owner: owner, Colour: l.Colour}
+			merged = append(merged, target)
+			report.ListsCreated++
+		case strategy == api.MergeOverwrite:
+			if r.role(target).Includes(core.RoleOwner) {
+				target.Colour = l.Colour
+			}
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "list", Name: l.Name, Resolution: strategy})
+		case strategy == api.MergeDuplicate:
+			name := uniqueName(l.Name, func(n string) bool { return findVisible(n) != nil })
+			target = &core.List{Name: name, Owner: owner, Colour: l.Colour}
+			merged = append(merged, target)
+			report.ListsCreated++
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "list", Name: l.Name, Resolution: strategy, NewName: name})
+		default:
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "list", Name: l.Name, Resolution: strategy})
+		}
+
+		for _, t := range l.Items {
+			item := t.Clone()
+			item.List = target.Name
+			if item.ID == 0 || foreign[item.ID] {
+				item.ID = nextID()
+			} else if existing, ok := taskLists[item.ID]; ok {
+				conflict := api.ImportConflict{Kind: "task", ID: item.ID, Resolution: strategy}
+				switch strategy {
+				case api.MergeOverwrite:
+					if old := findTask(existing, item.ID); old != nil {
+						keepUnset(&item, *old)
+					}
+					removeTask(existing, item.ID)
+				case api.MergeDuplicate:
+					item.ID = nextID()
+					conflict.NewID = item.ID
+				default:
+					report.Conflicts = append(report.Conflicts, conflict)
+					report.TasksSkipped++
+					continue
+				}
+				report.Conflicts = append(report.Conflicts, conflict)
+			}
+			if t.ID != 0 && item.ID != t.ID {
+				newIDs[t.ID] = item.ID
+			}
+			target.Items = append(target.Items, &item)
+			imported = append(imported, &item)
+			taskLists[item.ID] = target
+			report.TasksImported++
+		}
+	}
+	// dependencies among the imported tasks follow them to their new IDs
+	for _, t := range imported {
+		for i, id := range t.BlockedBy {
+			if newID, ok := newIDs[id]; ok {
+				t.BlockedBy[i] = newID
+			}
+		}
+	}
+
+	for _, fl := range filtered {
+		existing := findFiltered(mergedFiltered, owner, fl.Name)
+		switch {
+		case existing == nil:
+			mergedFiltered = append(mergedFiltered, &filter.List{Name: fl.Name, Owner: owner, Filter: fl.Filter})
+			report.FilteredCreated++
+		case strategy == api.MergeOverwrite:
+			existing.Filter = fl.Filter
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "filtered_list", Name: fl.Name, Resolution: strategy})
+		case strategy == api.MergeDuplicate:
+			name := uniqueName(fl.Name, func(n string) bool { return findFiltered(mergedFiltered, owner, n) != nil })
+			mergedFiltered = append(mergedFiltered, &filter.List{Name: name, Owner: owner, Filter: fl.Filter})
+			report.FilteredCreated++
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "filtered_list", Name: fl.Name, Resolution: strategy, NewName: name})
+		default:
+			report.Conflicts = append(report.Conflicts, api.ImportConflict{Kind: "filtered_list", Name: fl.Name, Resolution: strategy})
+		}
+	}
+
+	return merged, mergedFiltered, report
+}
+
+// newID returns a new unique ID, also among archived tasks. This is a naive implementation that iterates over all items
+// to find the highest ID.
 func (r *Repository) newID() int {
 	id := 0
-	for _, list := range r.lists {
+	for _, list := range slices.Concat(r.lists, r.archive) {
 		for _, item := range list.Items {
 			if item.ID > id {
 				id = item.ID
@@ -347,10 +983,20 @@ func (r *Repository) newID() int {
 	return id + 1
 }
 
+// filterTasks returns the tasks matching a filter. Snoozed tasks are left out unless the filter has an available rule,
+// archived tasks are only searched if it has an archived rule.
 func (r *Repository) filterTasks(f filter.Filter) []*core.Task {
+	showHidden := f.Uses("available")
+	lists := r.visibleLists()
+	if f.Uses("archived") {
+		lists = append(lists, r.visibleArchive()...)
+	}
 	tasks := make([]*core.Task, 0)
-	for _, list := range r.lists {
+	for _, list := range lists {
 		for _, task := range list.Items {
+			if !showHidden && task.IsHidden() {
+				continue
+			}
 			if f.Evaluate(*task) {
 				tasks = append(tasks, task)
 			}
@@ -359,8 +1005,67 @@ func (r *Repository) filterTasks(f filter.Filter) []*core.Task {
 	return tasks
 }
 
+// record adds a change of a task field by the user to the task's history.
+func (r *Repository) record(t *core.Task, field, from, to string) {
+	t.History = append(t.History, core.Event{Time: time.Now(), User: r.user, Field: field, From: from, To: to})
+}
+
+// formatDue formats a due date for the task history.
+func formatDue(typ core.DueType, due time.Time) string {
+	if typ == core.DueNone {
+		return ""
+	}
+	return string(typ) + " " + due.Format(time.RFC3339)
+}
+
+// role returns the role of the user in a list, empty if the user can't see it. The repository of all users owns all
+// lists.
+func (r *Repository) role(l *core.List) core.Role {
+	if r.user == "" {
+		return core.RoleOwner
+	}
+	return l.Role(r.user)
+}
+
+// checkRole returns ErrForbidden if the user doesn't have at least the given role in a list.
+func (r *Repository) checkRole(l *core.List, role core.Role) error {
+	if !r.role(l).Includes(role) {
+		return fmt.Errorf("%w: role %s of list %q required", ErrForbidden, role, l.Name)
+	}
+	return nil
+}
+
+// listWithRole returns a list if the user has at least the given role in it.
+func (r *Repository) listWithRole(name string, role core.Role) (*core.List, error) {
+	l, err := r.getList(name)
+	if err != nil {
+		return nil, err
+	}
+	return l, r.checkRole(l, role)
+}
+
+func (r *Repository) visibleLists() []*core.List {
+	lists := make([]*core.List, 0, len(r.lists))
+	for _, l := range r.lists {
+		if r.role(l) != "" {
+			lists = append(lists, l)
+		}
+	}
+	return lists
+}
+
+func (r *Repository) visibleFiltered() []*filter.List {
+	filtered := make([]*filter.List, 0, len(r.filtered))
+	for _, fl := range r.filtered {
+		if r.user == "" || fl.Owner == r.user {
+			filtered = append(filtered, fl)
+		}
+	}
+	return filtered
+}
+
 func (r *Repository) getList(name string) (*core.List, error) {
-	for _, list := range r.lists {
+	for _, list := range r.visibleLists() {
 		if list.Name == name {
 			return list, nil
 		}
@@ -368,15 +1073,26 @@ func (r *Repository) getList(name string) (*core.List, error) {
 	return nil, ErrListNotFound
 }
 
-func (r *Repository) getTask(id int) (*core.Task, error) {
-	for _, list := range r.lists {
+// getTask returns a task and its list.
+func (r *Repository) getTask(id int) (*core.Task, *core.List, error) {
+	for _, list := range r.visibleLists() {
 		for _, item := range list.Items {
 			if item.ID == id {
-				return item, nil
+				return item, list, nil
 			}
 		}
 	}
-	return nil, fmt.Errorf("item not found")
+	return nil, nil, ErrTaskNotFound
+}
+
+// reload reloads the caches from the store after writing to it failed, so that they don't keep changes that weren't
+// saved. It returns err together with the errors of reloading.
+func (r *Repository) reload(err error) error {
+	cacheErr := errors.Join(r.updateListCache(), r.updateFilteredListCache(), r.updateArchiveCache(), r.updateGroupCache())
+	if cacheErr != nil {
+		err = errors.Join(err, fmt.Errorf("failed to reload caches: %w", cacheErr))
+	}
+	return err
 }
 
 func (r *Repository) updateListCache() error {
@@ -397,7 +1113,116 @@ func (r *Repository) updateFilteredListCache() error {
 	return nil
 }
 
+func cloneLists(lists []*core.List) []*core.List {
+	res := make([]*core.List, 0, len(lists))
+	for _, l := range lists {
+		c := l.Clone()
+		res = append(res, &c)
+	}
+	return res
+}
+
+func cloneFiltered(filtered []*filter.List) []*filter.List {
+	res := make([]*filter.List, 0, len(filtered))
+	for _, fl := range filtered {
+		c := *fl
+		res = append(res, &c)
+	}
+	return res
+}
+
+func findList(lists []*core.List, owner, name string) *core.List {
+	for _, l := range lists {
+		if l.Owner == owner && l.Name == name {
+			return l
+		}
+	}
+	return nil
+}
+
+func findFiltered(filtered []*filter.List, owner, name string) *filter.List {
+	for _, fl := range filtered {
+		if fl.Owner == owner && fl.Name == name {
+			return fl
+		}
+	}
+	return nil
+}
+
+func findTask(list *core.List, id int) *core.Task {
+	for _, item := range list.Items {
+		if item.ID == id {
+			return item
+		}
+	}
+	return nil
+}
+
+// keepUnset copies the fields an imported task doesn't set from the task it overwrites, e.g. the comments of a task
+// imported from CSV.
+func keepUnset(t *core.Task, old core.Task) {
+	if t.Assignee == "" {
+		t.Assignee = old.Assignee
+	}
+	if t.Status == "" {
+		t.Status = old.Status
+	}
+	if t.HiddenUntil.IsZero() {
+		t.HiddenUntil = old.HiddenUntil
+	}
+	if t.BlockedBy == nil {
+		t.BlockedBy = old.BlockedBy
+	}
+	if t.Estimate == 0 {
+		t.Estimate = old.Estimate
+	}
+	if t.Tags == nil {
+		t.Tags = old.Tags
+	}
+	if t.Reminders == nil {
+		t.Reminders = old.Reminders
+	}
+	if t.Comments == nil {
+		t.Comments = old.Comments
+	}
+	// IDs of the comments and time entries of the old task mustn't be given again
+	t.LastCommentID = max(t.LastCommentID, old.LastCommentID)
+	if t.History == nil {
+		t.History = old.History
+	}
+	if t.TimeEntries == nil {
+		t.TimeEntries = old.TimeEntries
+	}
+	t.LastEntryID = max(t.LastEntryID, old.LastEntryID)
+	if t.Pomodoros == nil {
+		t.Pomodoros = old.Pomodoros
+	}
+}
+
+func removeTask(list *core.List, id int) {
+	for i, item := range list.Items {
+		if item.ID == id {
+			list.Items = append(list.Items[:i], list.Items[i+1:]...)
+			return
+		}
+	}
+}
+
+// uniqueName returns name with the lowest numeric suffix, e.g. "Home (2)", for which exists returns false.
+func uniqueName(name string, exists func(string) bool) string {
+	for i := 2; ; i++ {
+		n := fmt.Sprintf("%s (%d)", name, i)
+		if !exists(n) {
+			return n
+		}
+	}
+}
+
 var (
 	ErrListNotFound = fmt.Errorf("list not found")
 	ErrListExists   = fmt.Errorf("list already exists")
+	ErrTaskNotFound = fmt.Errorf("task not found")
+	// ErrForbidden is returned if the user's role in a list doesn't allow a change.
+	ErrForbidden      = fmt.Errorf("permission denied")
+	ErrMemberNotFound = fmt.Errorf("member not found")
 )
@@ -0,0 +1,480 @@
+package repository
+
+import (
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+var errWrite = errors.New("disk full")
+
+// failingStore fails to write the list named fail and the archive if failArchive is set. Unlike storage.Fake it keeps
+// copies like the file storage, so that changes of the cache that weren't written don't show up in the store.
+type failingStore struct {
+	storage.Fake
+	fail        string
+	failArchive bool
+	failReplace bool
+}
+
+func (s *failingStore) GetAllLists() ([]*core.List, error) {
+	return cloneLists(s.Lists), nil
+}
+
+func (s *failingStore) UpdateList(owner, name string, list *core.List) error {
+	if name == s.fail {
+		return errWrite
+	}
+	l := list.Clone()
+	return s.Fake.UpdateList(owner, name, &l)
+}
+
+func (s *failingStore) Replace(lists []*core.List, filtered []*filter.List) error {
+	if s.failReplace {
+		return errWrite
+	}
+	return s.Fake.Replace(cloneLists(lists), cloneFiltered(filtered))
+}
+
+func (s *failingStore) GetAllFiltered() ([]*filter.List, error) {
+	return cloneFiltered(s.Filtered), nil
+}
+
+func (s *failingStore) GetGroups() ([]*core.Group, error) {
+	groups := make([]*core.Group, 0, len(s.Groups))
+	for _, g := range s.Groups {
+		c := *g
+		c.Lists = slices.Clone(g.Lists)
+		groups = append(groups, &c)
+	}
+	return groups, nil
+}
+
+func (s *failingStore) GetArchive() ([]*core.List, error) {
+	return cloneLists(s.Archive), nil
+}
+
+func (s *failingStore) ReplaceArchive(lists []*core.List) error {
+	if s.failArchive {
+		return errWrite
+	}
+	return s.Fake.ReplaceArchive(cloneLists(lists))
+}
+
+// checkStored fails if the cache of the repository differs from what the repository reads from the store after a
+// restart.
+func checkStored(t *testing.T, repo *Repository) {
+	t.Helper()
+	lists, _ := repo.Lists()
+	stored, _ := NewRepository(repo.store).As(repo.user).Lists()
+	if len(lists) != len(stored) {
+		t.Fatalf("%d cached lists, %d in the store", len(lists), len(stored))
+	}
+	for i := range lists {
+		if !reflect.DeepEqual(lists[i], stored[i]) {
+			t.Errorf("cached list differs from the store:\n%+v\n%+v", *lists[i], *stored[i])
+		}
+	}
+	archived, _ := repo.Archived("", time.Time{}, time.Time{})
+	storedArchive, _ := NewRepository(repo.store).As(repo.user).Archived("", time.Time{}, time.Time{})
+	if !reflect.DeepEqual(archived, storedArchive) {
+		t.Errorf("cached archive differs from the store:\n%+v\n%+v", archived, storedArchive)
+	}
+}
+
+func TestRepository_FireRemindersWriteFails(t *testing.T) {
+	at := time.Now().Add(-time.Minute)
+	store := &failingStore{fail: "Home"}
+	store.Lists = []*core.List{
+		{Name: "Work", Items: []*core.Task{{ID: 1, Title: "Report", List: "Work", Reminders: []core.Reminder{{At: at}}}}},
+		{Name: "Home", Items: []*core.Task{{ID: 2, Title: "Dishes", List: "Home", Reminders: []core.Reminder{{At: at}}}}},
+	}
+	repo := NewRepository(store)
+
+	changes, err := repo.FireReminders(time.Now())
+	if !errors.Is(err, errWrite) {
+		t.Fatalf("FireReminders() error = %v, want %v", err, errWrite)
+	}
+	if len(changes) != 1 || changes[0].Task.ID != 1 {
+		t.Errorf("FireReminders() = %+v, want the saved reminder of task 1", changes)
+	}
+	if task, _ := repo.GetTask(2); task.Reminders[0].Fired {
+		t.Error("reminder of the unsaved list is fired in the cache")
+	}
+
+	// the unsaved reminder fires once the list can be written, the saved one doesn't fire again
+	store.fail = ""
+	changes, err = repo.FireReminders(time.Now())
+	if err != nil || len(changes) != 1 || changes[0].Task.ID != 2 {
+		t.Errorf("FireReminders() = %+v, %v, want only the reminder of task 2", changes, err)
+	}
+}
+
+// TestRepository_ImportKeepsUnset checks every field added to tasks after the import on its own: overwriting a task
+// with an import that doesn't set the field, e.g. from CSV, keeps it.
+func TestRepository_ImportKeepsUnset(t *testing.T) {
+	at := time.Date(2026, 10, 20, 14, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		set  func(t *core.Task)
+		get  func(t core.Task) any
+	}{
+		{"assignee", func(t *core.Task) { t.Assignee = "bob" }, func(t core.Task) any { return t.Assignee }},
+		{"history", func(t *core.Task) {
+			t.History = []core.Event{{Time: at, User: "alice", Field: "title", From: "a", To: "b"}}
+		},
+			func(t core.Task) any { return t.History }},
+		{"comments", func(t *core.Task) { t.Comments = []core.Comment{{ID: 1, Author: "alice", Text: "Draft?", Created: at}} },
+			func(t core.Task) any { return t.Comments }},
+		{"last comment id", func(t *core.Task) { t.LastCommentID = 4 }, func(t core.Task) any { return t.LastCommentID }},
+		{"reminders", func(t *core.Task) { t.Reminders = []core.Reminder{{At: at, Fired: true}} },
+			func(t core.Task) any { return t.Reminders }},
+		{"hidden until", func(t *core.Task) { t.HiddenUntil = at }, func(t core.Task) any { return t.HiddenUntil }},
+		{"blocked by", func(t *core.Task) { t.BlockedBy = []int{1} }, func(t core.Task) any { return t.BlockedBy }},
+		{"status", func(t *core.Task) { t.Status = "review" }, func(t core.Task) any { return t.Status }},
+		{"estimate", func(t *core.Task) { t.Estimate = time.Hour }, func(t core.Task) any { return t.Estimate }},
+		{"tags", func(t *core.Task) { t.Tags = []string{"acme"} }, func(t core.Task) any { return t.Tags }},
+		{"time entries", func(t *core.Task) {
+			t.TimeEntries = []core.TimeEntry{{ID: 1, User: "bob", Start: at, End: at.Add(time.Hour)}}
+		}, func(t core.Task) any { return t.TimeEntries }},
+		{"last time entry id", func(t *core.Task) { t.LastEntryID = 5 }, func(t core.Task) any { return t.LastEntryID }},
+		{"pomodoros", func(t *core.Task) { t.Pomodoros = []core.Pomodoro{{User: "bob", Start: at, Focus: 25 * time.Minute}} },
+			func(t core.Task) any { return t.Pomodoros }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			task := &core.Task{ID: 2, Title: "Slides", List: "Work", Created: at}
+			tt.set(task)
+			want := tt.get(*task)
+			repo := NewRepository(&storage.Fake{Lists: []*core.List{{
+				Name:     "Work",
+				Statuses: []string{"todo", "review", "done"},
+				Items:    []*core.Task{{ID: 1, Title: "Report", List: "Work", Created: at}, task},
+			}}})
+
+			imported := []*core.List{{Name: "Work", Items: []*core.Task{{ID: 2, Title: "Slides v2", List: "Work", Created: at}}}}
+			if _, err := repo.Import(imported, nil, api.MergeOverwrite); err != nil {
+				t.Fatal(err)
+			}
+			got, err := repo.GetTask(2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Title != "Slides v2" || !reflect.DeepEqual(tt.get(got), want) {
+				t.Errorf("task = %+v, want Slides v2 with %s %v", got, tt.name, want)
+			}
+		})
+	}
+}
+
+func TestRepository_CommentIDs(t *testing.T) {
+	repo := NewRepository(&storage.Fake{Lists: []*core.List{{Name: "Work", Owner: "alice", Items: []*core.Task{
+		// saved before tasks kept the last comment ID
+		{ID: 1, Title: "Report", List: "Work", Comments: []core.Comment{{ID: 2, Author: "alice", Text: "Draft?"}}},
+	}}}}).As("alice")
+
+	c, err := repo.AddComment(1, "Ready")
+	if err != nil || c.ID != 3 {
+		t.Fatalf("AddComment() = %+v, %v, want ID 3", c, err)
+	}
+	if err = repo.DelComment(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if c, err = repo.AddComment(1, "Ready now"); err != nil || c.ID != 4 {
+		t.Errorf("AddComment() after deleting the newest comment = %+v, %v, want ID 4", c, err)
+	}
+}
+
+func TestRepository_TimeEntryIDs(t *testing.T) {
+	start := time.Now().Add(-2 * time.Hour)
+	// saved before tasks kept the last entry ID
+	entries := []core.TimeEntry{{ID: 2, User: "alice", Start: start, End: start.Add(time.Hour)}}
+	repo := NewRepository(&storage.Fake{Lists: []*core.List{{Name: "Work", Owner: "alice", Items: []*core.Task{
+		{ID: 1, Title: "Report", List: "Work", TimeEntries: entries},
+	}}}}).As("alice")
+
+	e, err := repo.AddTimeEntry(1, api.TimeEntryAdd{Duration: "30m"})
+	if err != nil || e.ID != 3 {
+		t.Fatalf("AddTimeEntry() = %+v, %v, want ID 3", e, err)
+	}
+	if err = repo.DelTimeEntry(1, 3); err != nil {
+		t.Fatal(err)
+	}
+	if e, err = repo.StartTimer(1); err != nil || e.ID != 4 {
+		t.Errorf("StartTimer() after deleting the newest entry = %+v, %v, want ID 4", e, err)
+	}
+}
+
+func TestRepository_UpdateBlockedWriteFails(t *testing.T) {
+	store := &failingStore{fail: "Home"}
+	slides := &core.Task{ID: 2, Title: "Slides", List: "Home", BlockedBy: []int{1}, Blocked: true}
+	store.Lists = []*core.List{
+		{Name: "Work", Owner: "alice", Items: []*core.Task{{ID: 1, Title: "Report", List: "Work"}}},
+		{Name: "Home", Owner: "alice", Items: []*core.Task{slides}},
+	}
+	repo := NewRepository(store).As("alice")
+
+	if _, err := repo.MarkDone(1, true); !errors.Is(err, errWrite) {
+		t.Fatalf("MarkDone() error = %v, want %v", err, errWrite)
+	}
+	checkStored(t, repo)
+
+	// the flag is saved with the next change
+	store.fail = ""
+	if _, err := repo.MarkDone(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if task, _ := repo.GetTask(2); task.Blocked {
+		t.Error("task is still blocked after its dependency is done")
+	}
+	checkStored(t, repo)
+}
+
+func TestRepository_ArchiveWriteFails(t *testing.T) {
+	now := time.Now()
+	doneOn := now.AddDate(0, 0, -2)
+	store := &failingStore{fail: "Home"}
+	store.Lists = []*core.List{
+		{Name: "Work", Owner: "alice", ArchiveAfter: 1, Items: []*core.Task{
+			{ID: 1, Title: "Report", List: "Work", Done: true, DoneOn: doneOn},
+		}},
+		{Name: "Home", Owner: "alice", ArchiveAfter: 1, Items: []*core.Task{
+			{ID: 2, Title: "Dishes", List: "Home", Done: true, DoneOn: doneOn},
+		}},
+	}
+	repo := NewRepository(store).As("alice")
+
+	if _, err := repo.ArchiveDone(now); !errors.Is(err, errWrite) {
+		t.Fatalf("ArchiveDone() error = %v, want %v", err, errWrite)
+	}
+	checkStored(t, repo)
+	if _, err := repo.GetTask(2); err != nil {
+		t.Errorf("task of the unsaved list: %v", err)
+	}
+
+	// the task of the unsaved list is archived again, the archive keeps one copy of it
+	store.fail = ""
+	if n, err := repo.ArchiveDone(now); err != nil || n != 1 {
+		t.Fatalf("ArchiveDone() = %d, %v, want 1", n, err)
+	}
+	if archived, _ := repo.Archived("", time.Time{}, time.Time{}); len(archived) != 2 {
+		t.Errorf("archived tasks = %+v, want 2", archived)
+	}
+	checkStored(t, repo)
+}
+
+func TestRepository_RestoreWriteFails(t *testing.T) {
+	store := &failingStore{fail: "Work"}
+	store.Lists = []*core.List{{Name: "Work", Owner: "alice"}}
+	store.Archive = []*core.List{{Name: "Work", Owner: "alice", Items: []*core.Task{
+		{ID: 1, Title: "Report", List: "Work", Done: true, DoneOn: time.Now(), Archived: time.Now(), Tags: []string{"acme"}},
+	}}}
+	repo := NewRepository(store).As("alice")
+
+	if _, err := repo.RestoreTask(1); !errors.Is(err, errWrite) {
+		t.Fatalf("RestoreTask() error = %v, want %v", err, errWrite)
+	}
+	checkStored(t, repo)
+
+	// the task is in its list and still in the archive
+	store.fail, store.failArchive = "", true
+	if _, err := repo.RestoreTask(1); !errors.Is(err, errWrite) {
+		t.Fatalf("RestoreTask() error = %v, want %v", err, errWrite)
+	}
+	checkStored(t, repo)
+
+	// restoring again keeps one copy in the list
+	store.failArchive = false
+	task, err := repo.RestoreTask(1)
+	if err != nil || task.Done || !task.Archived.IsZero() {
+		t.Fatalf("RestoreTask() = %+v, %v, want the open task", task, err)
+	}
+	task.Tags[0] = "changed"
+	list, _ := repo.GetList("Work")
+	if len(list.Items) != 1 || list.Items[0].Tags[0] != "acme" {
+		t.Errorf("list = %+v, want the restored task once with its tags", list)
+	}
+	if archived, _ := repo.Archived("", time.Time{}, time.Time{}); len(archived) != 0 {
+		t.Errorf("archived tasks = %+v, want none", archived)
+	}
+	checkStored(t, repo)
+}
+
+func TestRepository_DeleteUserWriteFails(t *testing.T) {
+	tests := []struct {
+		name string
+		fail func(s *failingStore, fail bool)
+	}{
+		{"lists", func(s *failingStore, fail bool) { s.failReplace = fail }},
+		{"archive", func(s *failingStore, fail bool) { s.failArchive = fail }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &failingStore{}
+			store.Lists = []*core.List{
+				{Name: "Garden", Owner: "bob", Members: []core.Member{{User: "alice", Role: core.RoleEditor}}, Items: []*core.Task{
+					{ID: 1, Title: "Mow", List: "Garden"},
+				}},
+				{Name: "Work", Owner: "alice", Members: []core.Member{{User: "bob", Role: core.RoleEditor}}, Items: []*core.Task{
+					{ID: 2, Title: "Report", List: "Work", Assignee: "bob", BlockedBy: []int{1}, Blocked: true},
+				}},
+			}
+			store.Archive = []*core.List{{Name: "Garden", Owner: "bob", Items: []*core.Task{
+				{ID: 3, Title: "Rake", List: "Garden"},
+			}}}
+			store.Groups = []*core.Group{
+				{Name: "Outside", Owner: "alice", Lists: []string{"Garden", "Work"}},
+				{Name: "Home", Owner: "bob", Lists: []string{"Garden"}},
+			}
+			repo := NewRepository(store)
+			var deleted []string
+			repo.Subscribe(func(c Change) {
+				if c.Type == ListDeleted {
+					deleted = append(deleted, c.List.Name)
+				}
+			})
+
+			tt.fail(store, true)
+			if err := repo.DeleteUser("bob"); !errors.Is(err, errWrite) {
+				t.Fatalf("DeleteUser() error = %v, want %v", err, errWrite)
+			}
+			checkStored(t, repo)
+			if len(deleted) != 0 {
+				t.Errorf("deleted lists = %v before the lists were written", deleted)
+			}
+
+			// the rest is deleted when trying again
+			tt.fail(store, false)
+			if err := repo.DeleteUser("bob"); err != nil {
+				t.Fatal(err)
+			}
+			checkStored(t, repo)
+			lists, _ := repo.Lists()
+			if len(lists) != 1 || len(lists[0].Members) != 0 {
+				t.Fatalf("lists = %+v, want Work without members", lists)
+			}
+			if task := lists[0].Items[0]; task.Assignee != "" || task.Blocked || len(task.BlockedBy) != 0 {
+				t.Errorf("task = %+v, want it unassigned and no longer blocked", task)
+			}
+			if archived, _ := repo.Archived("", time.Time{}, time.Time{}); len(archived) != 0 {
+				t.Errorf("archived tasks = %+v, want none", archived)
+			}
+			if groups := repo.As("alice").Groups(); len(groups) != 1 || !slices.Equal(groups[0].Lists, []string{"Work"}) {
+				t.Errorf("groups of alice = %+v, want Outside with Work", groups)
+			}
+			if groups := repo.As("bob").Groups(); len(groups) != 0 {
+				t.Errorf("groups of bob = %+v, want none", groups)
+			}
+			if !slices.Equal(deleted, []string{"Garden"}) {
+				t.Errorf("deleted lists = %v, want Garden", deleted)
+			}
+		})
+	}
+}
+
+func TestRepository_ClaimUnowned(t *testing.T) {
+	store := &failingStore{failReplace: true}
+	store.Lists = []*core.List{
+		{Name: "Work", Owner: "alice"},
+		{Name: "Work", Items: []*core.Task{{ID: 1, Title: "Report", List: "Work"}}},
+	}
+	store.Filtered = []*filter.List{{Name: "Today"}}
+	repo := NewRepository(store)
+
+	if _, err := repo.ClaimUnowned("alice"); !errors.Is(err, errWrite) {
+		t.Fatalf("ClaimUnowned() error = %v, want %v", err, errWrite)
+	}
+	checkStored(t, repo)
+
+	store.failReplace = false
+	if n, err := repo.ClaimUnowned("alice"); err != nil || n != 2 {
+		t.Fatalf("ClaimUnowned() = %d, %v, want 2", n, err)
+	}
+	checkStored(t, repo)
+	task, err := repo.As("alice").GetTask(1)
+	if err != nil || task.List != "Work (2)" {
+		t.Errorf("claimed task = %+v, %v, want it in the renamed list", task, err)
+	}
+	if n, err := repo.ClaimUnowned("bob"); err != nil || n != 0 {
+		t.Errorf("ClaimUnowned() after claiming everything = %d, %v, want 0", n, err)
+	}
+}
+
+// TestRepository_ReturnsCopies changes the tasks returned by the repository, which mustn't change its cache.
+func TestRepository_ReturnsCopies(t *testing.T) {
+	at := time.Now().Add(time.Hour)
+	newRepo := func() *Repository {
+		return NewRepository(&storage.Fake{Lists: []*core.List{{Name: "Work", Owner: "alice", Items: []*core.Task{
+			{ID: 1, Title: "Report", List: "Work", Tags: []string{"acme"}, BlockedBy: []int{2},
+				Comments:  []core.Comment{{ID: 1, Author: "alice", Text: "Draft?"}},
+				Reminders: []core.Reminder{{At: at}},
+				History:   []core.Event{{User: "alice", Field: "title", From: "a", To: "Report"}}},
+			{ID: 2, Title: "Slides", List: "Work", Done: true},
+		}}}}).As("alice")
+	}
+	tests := []struct {
+		name string
+		get  func(repo *Repository) (*core.Task, error)
+	}{
+		{"GetTask", func(repo *Repository) (*core.Task, error) {
+			task, err := repo.GetTask(1)
+			return &task, err
+		}},
+		{"MarkDone", func(repo *Repository) (*core.Task, error) {
+			task, err := repo.MarkDone(1, false)
+			return &task, err
+		}},
+		{"UpdateTask", func(repo *Repository) (*core.Task, error) {
+			task, _ := repo.GetTask(1)
+			change := api.NewTaskChange(task)
+			change.Title = "Final report"
+			task, err := repo.UpdateTask(1, change)
+			return &task, err
+		}},
+		{"SnoozeTask", func(repo *Repository) (*core.Task, error) {
+			task, err := repo.SnoozeTask(1, at)
+			return &task, err
+		}},
+		{"GetList", func(repo *Repository) (*core.Task, error) {
+			list, err := repo.GetList("Work")
+			return list.Items[0], err
+		}},
+		{"Lists", func(repo *Repository) (*core.Task, error) {
+			lists, _ := repo.Lists()
+			return lists[0].Items[0], nil
+		}},
+		{"Import", func(repo *Repository) (*core.Task, error) {
+			task, _ := repo.GetTask(1)
+			task.Title = "Imported report"
+			_, err := repo.Import([]*core.List{{Name: "Work", Items: []*core.Task{&task}}}, nil, api.MergeOverwrite)
+			return &task, err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newRepo()
+			got, err := tt.get(repo)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := repo.GetTask(1)
+
+			got.Title = "changed"
+			got.Tags[0] = "changed"
+			got.BlockedBy[0] = 99
+			got.Comments[0].Text = "changed"
+			got.Reminders[0].Fired = true
+			got.History[0].To = "changed"
+			if task, _ := repo.GetTask(1); !reflect.DeepEqual(task, want) {
+				t.Errorf("task = %+v after changing the returned one, want %+v", task, want)
+			}
+		})
+	}
+}
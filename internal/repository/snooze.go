@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// SnoozeTask hides a task from lists and filtered lists until the given time. The zero time shows it again.
+func (r *Repository) SnoozeTask(id int, until time.Time) (core.Task, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, l, err := r.getTask(id)
+	if err != nil {
+		return core.Task{}, err
+	}
+	if err = r.checkRole(l, core.RoleEditor); err != nil {
+		return core.Task{}, err
+	}
+	if !t.HiddenUntil.Equal(until) {
+		r.record(t, "hidden_until", formatTime(t.HiddenUntil), formatTime(until))
+	}
+	t.HiddenUntil = until
+
+	if err = r.store.UpdateList(l.Owner, l.Name, l); err != nil {
+		return core.Task{}, err
+	}
+	if err = r.updateListCache(); err != nil {
+		return core.Task{}, fmt.Errorf("failed to update list cache: %w", err)
+	}
+	r.notifyTask(TaskUpdated, id)
+	return t.Clone(), nil
+}
+
+// formatTime formats a time for the history of a task, the zero time is empty.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+var (
+	ErrTimerRunning      = fmt.Errorf("a timer is already running")
+	ErrTimerNotRunning   = fmt.Errorf("no timer is running")
+	ErrTimeEntryNotFound = fmt.Errorf("time entry not found")
+)
+
+// StartTimer starts tracking the time the user spends on a task. Viewers of the list can't track time and only one
+// timer may run per user.
+func (r *Repository) StartTimer(taskID int) (core.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return core.TimeEntry{}, err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.TimeEntry{}, err
+	}
+	if running, _ := r.runningTimer(); running != nil {
+		return core.TimeEntry{}, fmt.Errorf("%w on task %d %q", ErrTimerRunning, running.ID, running.Title)
+	}
+
+	e := core.TimeEntry{ID: nextEntryID(t), User: r.user, Start: time.Now()}
+	t.TimeEntries = append(t.TimeEntries, e)
+
+	if _, err = r.saveList(list); err != nil {
+		return core.TimeEntry{}, err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return e, nil
+}
+
+// StopTimer stops the running timer of the user on a task, also if the user became a viewer of the list since.
+func (r *Repository) StopTimer(taskID int) (core.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return core.TimeEntry{}, err
+	}
+	running, i := r.runningTimer()
+	if running == nil || running.ID != taskID {
+		return core.TimeEntry{}, fmt.Errorf("%w on task %d", ErrTimerNotRunning, taskID)
+	}
+
+	t.TimeEntries[i].End = time.Now()
+	e := t.TimeEntries[i]
+
+	if _, err = r.saveList(list); err != nil {
+		return core.TimeEntry{}, err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return e, nil
+}
+
+// AddTimeEntry adds time the user spent on a task without a timer.
+func (r *Repository) AddTimeEntry(taskID int, entry api.TimeEntryAdd) (core.TimeEntry, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return core.TimeEntry{}, err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return core.TimeEntry{}, err
+	}
+	d, err := time.ParseDuration(entry.Duration)
+	if err != nil || d <= 0 {
+		msg := fmt.Sprintf("invalid duration %q, must be positive like 45m or 1h30m", entry.Duration)
+		return core.TimeEntry{}, &api.FieldError{Field: "duration", Message: msg}
+	}
+	end := time.Now()
+	if !entry.Start.IsZero() {
+		end = entry.Start.Add(d)
+	}
+
+	e := core.TimeEntry{ID: nextEntryID(t), User: r.user, Start: end.Add(-d), End: end, Note: strings.TrimSpace(entry.Note)}
+	t.TimeEntries = append(t.TimeEntries, e)
+
+	if _, err = r.saveList(list); err != nil {
+		return core.TimeEntry{}, err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return e, nil
+}
+
+// DelTimeEntry deletes a time entry, editors may only delete their own entries.
+func (r *Repository) DelTimeEntry(taskID, entryID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t, list, err := r.getTask(taskID)
+	if err != nil {
+		return err
+	}
+	if err = r.checkRole(list, core.RoleEditor); err != nil {
+		return err
+	}
+	i := -1
+	for j, e := range t.TimeEntries {
+		if e.ID == entryID {
+			i = j
+		}
+	}
+	if i < 0 {
+		return ErrTimeEntryNotFound
+	}
+	if e := t.TimeEntries[i]; r.user != "" && e.User != r.user {
+		return fmt.Errorf("%w: time entry %d was tracked by %s", ErrForbidden, entryID, e.User)
+	}
+
+	t.TimeEntries = append(t.TimeEntries[:i], t.TimeEntries[i+1:]...)
+
+	if _, err = r.saveList(list); err != nil {
+		return err
+	}
+	r.notifyTask(TaskUpdated, taskID)
+	return nil
+}
+
+// runningTimer returns the task with the running timer of the user and the index of its time entry, or nil if the
+// user's timers are stopped.
+func (r *Repository) runningTimer() (*core.Task, int) {
+	for _, l := range r.visibleLists() {
+		for _, t := range l.Items {
+			for i, e := range t.TimeEntries {
+				if e.Running() && e.User == r.user {
+					return t, i
+				}
+			}
+		}
+	}
+	return nil, 0
+}
+
+// nextEntryID returns the ID of a new time entry on the task. IDs only increase, also after the newest entry was
+// deleted. Tasks saved before LastEntryID start after their highest entry ID.
+func nextEntryID(t *core.Task) int {
+	for _, e := range t.TimeEntries {
+		t.LastEntryID = max(t.LastEntryID, e.ID)
+	}
+	t.LastEntryID++
+	return t.LastEntryID
+}
+
+// parseEstimate parses the estimate of a task, e.g. "2h30m". Empty means the task has none.
+func parseEstimate(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, &api.FieldError{Field: "estimate", Message: fmt.Sprintf("invalid estimate %q, must be a duration like 2h30m", s)}
+	}
+	return d, nil
+}
+
+// setTags sets the tags of a task as sent in TaskAdd and TaskChange.
+func setTags(t *core.Task, tags []string) error {
+	if err := t.SetTags(tags); err != nil {
+		return &api.FieldError{Field: "tags", Message: err.Error()}
+	}
+	return nil
+}
+
+// formatEstimate formats an estimate for the history of a task, no estimate is empty.
+func formatEstimate(d time.Duration) string {
+	if d == 0 {
+		return ""
+	}
+	return d.String()
+}
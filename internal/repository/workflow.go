@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"slices"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// SetStatuses changes the workflow of a list, only owners may change it. Tasks whose status isn't part of the new
+// workflow start over with its first status, done tasks get its last one.
+func (r *Repository) SetStatuses(name string, statuses []string) (core.List, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	l, err := r.listWithRole(name, core.RoleOwner)
+	if err != nil {
+		return core.List{}, err
+	}
+	if err = core.ValidateStatuses(statuses); err != nil {
+		return core.List{}, &api.FieldError{Field: "statuses", Message: err.Error()}
+	}
+
+	l.Statuses = slices.Clone(statuses)
+	for _, t := range l.Items {
+		r.setStatus(t, l.StatusFor(*t))
+	}
+	return r.saveListChange(l)
+}
+
+// checkStatus returns an error if the workflow of a list doesn't have a status.
+func checkStatus(l *core.List, status string) error {
+	if !slices.Contains(l.Workflow(), status) {
+		return &api.FieldError{Field: "status", Message: "list " + l.Name + " has no status " + status}
+	}
+	return nil
+}
+
+// isDoneStatus reports whether a status is the last one of the workflow of a list.
+func isDoneStatus(l *core.List, status string) bool {
+	workflow := l.Workflow()
+	return status == workflow[len(workflow)-1]
+}
+
+// setStatus sets the status of a task and records the change.
+func (r *Repository) setStatus(t *core.Task, status string) {
+	if t.CurrentStatus() != status {
+		r.record(t, "status", t.CurrentStatus(), status)
+	}
+	t.Status = status
+}
@@ -0,0 +1,122 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// handleListArchive changes after how many days done tasks of a list are archived.
+func (s *Server) handleListArchive(w http.ResponseWriter, r *http.Request) {
+	var req api.ListArchive
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	l, err := s.organiser(r).SetArchiveAfter(r.PathValue("name"), req.ArchiveAfter)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		List api.ListResponse `json:"list"`
+	}{List: api.FromList(l)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleArchiveGet returns the archived tasks the user can see, optionally only those of the "list" and done from the
+// "from" until the "to" date including.
+func (s *Server) handleArchiveGet(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseDateRange(r, time.Time{}, time.Time{})
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1)
+	}
+
+	tasks, err := s.organiser(r).Archived(r.URL.Query().Get("list"), from, to)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Tasks []api.TaskResponse `json:"tasks"`
+	}{Tasks: make([]api.TaskResponse, 0, len(tasks))}
+	for _, t := range tasks {
+		resp.Tasks = append(resp.Tasks, api.FromTask(t))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleArchiveRestore moves an archived task back to its list.
+func (s *Server) handleArchiveRestore(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := s.organiser(r).RestoreTask(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Task api.TaskResponse `json:"task"`
+	}{Task: api.FromTask(t)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// parseDateRange parses the "from" and "to" dates of a request, which default to from and to.
+func parseDateRange(r *http.Request, from, to time.Time) (time.Time, time.Time, error) {
+	for _, date := range []struct {
+		field string
+		t     *time.Time
+	}{{"from", &from}, {"to", &to}} {
+		v := r.URL.Query().Get(date.field)
+		if v == "" {
+			continue
+		}
+		d, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			return from, to, &api.FieldError{Field: date.field, Message: fmt.Sprintf("invalid date %q", v)}
+		}
+		*date.t = d
+	}
+	return from, to, nil
+}
+
+// reportLists returns the lists the user can see together with their archived tasks, so reports don't lose the tasks
+// the archiver moved out of the lists. Archived tasks are in lists of their own with the name of their list.
+func reportLists(o Organiser) []*core.List {
+	lists, _ := o.Lists()
+	archived, err := o.Archived("", time.Time{}, time.Time{})
+	if err != nil {
+		return lists
+	}
+	byName := make(map[string]*core.List)
+	for _, t := range archived {
+		l, ok := byName[t.List]
+		if !ok {
+			l = &core.List{Name: t.List}
+			byName[t.List] = l
+			lists = append(lists, l)
+		}
+		l.Items = append(l.Items, &t)
+	}
+	return lists
+}
@@ -0,0 +1,190 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/events"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+func TestArchive(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	_, token, _ = store.CreateToken("bob", "test")
+	bob := http.Header{"Authorization": {"Bearer " + token}}
+	repo := repository.NewRepository(&storage.Fake{})
+	var archived []repository.Change
+	repo.Subscribe(func(c repository.Change) {
+		if c.Type == repository.TaskArchived {
+			archived = append(archived, c)
+		}
+	})
+	orga := func(user string) Organiser { return repo.As(user) }
+	do := requester(NewServer(fstest.MapFS{}, orga, store, nil, events.NewBroker(), nil, log.NewEntry(logger)))
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Work"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	var ids []string
+	for _, title := range []string{"Report", "Slides"} {
+		w := do(http.MethodPost, "/api/v1/list/Work", `{"title": "`+title+`"}`, alice)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+		}
+		ids = append(ids, strconv.Itoa(decodeTask(t, w.Body).ID))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		user   http.Header
+		want   int
+	}{
+		{"Share", http.MethodPut, "/api/v1/list/Work/members/bob", `{"role": "viewer"}`, alice, http.StatusOK},
+		{"Done", http.MethodPatch, "/api/v1/items/" + ids[0], `{"title": "Report", "list": "Work", "done": true}`, alice, http.StatusAccepted},
+		{"Negative days", http.MethodPut, "/api/v1/list/Work/archive", `{"archive_after": -1}`, alice, http.StatusBadRequest},
+		{"Viewer", http.MethodPut, "/api/v1/list/Work/archive", `{"archive_after": 7}`, bob, http.StatusForbidden},
+		{"Archive after", http.MethodPut, "/api/v1/list/Work/archive", `{"archive_after": 7}`, alice, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, tt.user); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	if n, err := repo.ArchiveDone(time.Now().AddDate(0, 0, 6)); err != nil || n != 0 {
+		t.Fatalf("ArchiveDone() = %d, %v, want nothing archived before 7 days", n, err)
+	}
+	if n, err := repo.ArchiveDone(time.Now().AddDate(0, 0, 8)); err != nil || n != 1 {
+		t.Fatalf("ArchiveDone() = %d, %v, want 1", n, err)
+	}
+	if len(archived) != 1 || !archived[0].VisibleTo("bob") {
+		t.Errorf("archived changes = %+v, want one visible to bob", archived)
+	}
+	if w := do(http.MethodGet, "/api/v1/list/Work", "", alice); countItems(t, w.Body) != 1 {
+		t.Errorf("list still has the archived task")
+	}
+	if w := do(http.MethodGet, "/api/v1/items/"+ids[0], "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("archived task status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// statistics still count the archived task
+	w := do(http.MethodGet, "/api/v1/stats", "", bob)
+	var stats api.Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats.Total.Created != 2 || stats.Total.Completed != 1 {
+		t.Errorf("stats total = %+v, want 2 created and 1 completed", stats.Total)
+	}
+
+	today := time.Now().Format("2006-01-02")
+	for _, q := range []struct {
+		query string
+		want  int
+	}{{"", 1}, {"?list=Work&from=" + today + "&to=" + today, 1}, {"?to=" + time.Now().AddDate(0, 0, -1).Format("2006-01-02"), 0}} {
+		w := do(http.MethodGet, "/api/v1/archive"+q.query, "", bob)
+		var resp struct {
+			Tasks []api.TaskResponse `json:"tasks"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if len(resp.Tasks) != q.want || q.want > 0 && (resp.Tasks[0].Title != "Report" || resp.Tasks[0].Archived.IsZero()) {
+			t.Errorf("archive%s = %+v, want %d tasks", q.query, resp.Tasks, q.want)
+		}
+	}
+	if w := do(http.MethodGet, "/api/v1/archive?list=Home", "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("archive of unknown list status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	rule, _ := filter.NewRule("archived", "true")
+	if tasks := repo.As("bob").FindTasks(filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{rule}}}}); len(tasks) != 1 {
+		t.Errorf("archived rule found %d tasks, want 1", len(tasks))
+	}
+	done, _ := filter.NewRule("done", "true")
+	if tasks := repo.As("bob").FindTasks(filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{done}}}}); len(tasks) != 0 {
+		t.Errorf("filter without archived rule found %d tasks, want 0", len(tasks))
+	}
+
+	restore := "/api/v1/archive/" + ids[0] + "/restore"
+	if w := do(http.MethodPost, restore, "", bob); w.Code != http.StatusForbidden {
+		t.Errorf("restore by viewer status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	w = do(http.MethodPost, restore, "", alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("restore status = %d: %s", w.Code, w.Body)
+	}
+	if task := decodeTask(t, w.Body); task.Done || !task.Archived.IsZero() || task.List != "Work" {
+		t.Errorf("restored task = %+v, want open in Work", task)
+	}
+	if w := do(http.MethodPost, restore, "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("restore again status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+
+	// new tasks don't reuse the IDs of archived ones
+	w = do(http.MethodPost, "/api/v1/list/Work", `{"title": "Review", "blocked_by": [`+ids[1]+`]}`, alice)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add blocked task status = %d: %s", w.Code, w.Body)
+	}
+	review := strconv.Itoa(decodeTask(t, w.Body).ID)
+	_, _ = repo.As("alice").MarkDone(decodeTask(t, do(http.MethodGet, "/api/v1/items/"+ids[1], "", alice).Body).ID, true)
+	if n, _ := repo.ArchiveDone(time.Now().AddDate(0, 0, 8)); n != 1 {
+		t.Fatalf("ArchiveDone() = %d, want 1", n)
+	}
+	w = do(http.MethodPost, "/api/v1/list/Work", `{"title": "Next"}`, alice)
+	if id := strconv.Itoa(decodeTask(t, w.Body).ID); id == ids[1] {
+		t.Errorf("new task got the ID %s of an archived task", id)
+	}
+	// an archived task is done, tasks blocked by it keep the dependency
+	if task := decodeTask(t, do(http.MethodGet, "/api/v1/items/"+review, "", alice).Body); task.Blocked ||
+		len(task.BlockedBy) != 1 || strconv.Itoa(task.BlockedBy[0]) != ids[1] {
+		t.Errorf("task blocked by archived task = %+v, want unblocked with the dependency", task)
+	}
+
+	// neither do imported tasks, with or without an ID
+	archivedID, _ := strconv.Atoi(ids[1])
+	imported := []*core.List{{Name: "Work", Items: []*core.Task{{ID: archivedID, Title: "Imported"}, {Title: "New"}}}}
+	if _, err := repo.As("alice").Import(imported, nil, api.MergeOverwrite); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.As("alice").RestoreTask(archivedID); err != nil {
+		t.Fatal(err)
+	}
+	l, _ := repo.As("alice").GetList("Work")
+	seen := make(map[int]bool)
+	for _, task := range l.Items {
+		if seen[task.ID] {
+			t.Errorf("ID %d is used twice after restoring", task.ID)
+		}
+		seen[task.ID] = true
+	}
+	if task, err := repo.As("alice").GetTask(archivedID); err != nil || task.Title != "Slides" {
+		t.Errorf("GetTask(%d) = %+v, %v, want the restored Slides", archivedID, task, err)
+	}
+	if task := decodeTask(t, do(http.MethodGet, "/api/v1/items/"+review, "", alice).Body); !task.Blocked {
+		t.Errorf("task blocked by restored task = %+v, want blocked", task)
+	}
+}
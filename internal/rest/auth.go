@@ -0,0 +1,212 @@
+package rest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+// sessionCookie is the name of the cookie holding the session ID of the web UI.
+const sessionCookie = "gotasks_session"
+
+type contextKey int
+
+const userKey contextKey = 0
+
+var (
+	errAuthDisabled  = errors.New("authentication is disabled")
+	errNoCredentials = fmt.Errorf("%w: missing API token or session cookie", auth.ErrUnauthenticated)
+)
+
+// requireAuth only calls handler for requests with a valid API token (Authorization: Bearer <token>) or session
+// cookie, all other requests get 401. The user is available via requestUser. Without auth store all requests are
+// allowed.
+func (s *Server) requireAuth(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil {
+			handler(w, r)
+			return
+		}
+		user, err := s.authenticate(r)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="gotasks"`)
+			s.httpError(w, http.StatusUnauthorized, err)
+			return
+		}
+		handler(w, r.WithContext(context.WithValue(r.Context(), userKey, user)))
+	}
+}
+
+// authenticate returns the user of the API token or, if there is none, the session cookie of a request.
+func (s *Server) authenticate(r *http.Request) (string, error) {
+	if header := r.Header.Get("Authorization"); header != "" {
+		token, ok := strings.CutPrefix(header, "Bearer ")
+		if !ok {
+			return "", auth.ErrUnauthenticated
+		}
+		return s.auth.TokenUser(token)
+	}
+	c, err := r.Cookie(sessionCookie)
+	if err != nil {
+		return "", errNoCredentials
+	}
+	return s.auth.SessionUser(c.Value)
+}
+
+// requestUser returns the authenticated user of a request, it is empty if authentication is disabled.
+func requestUser(r *http.Request) string {
+	user, _ := r.Context().Value(userKey).(string)
+	return user
+}
+
+// handleLogin checks the password of a user and sets the session cookie.
+func (s *Server) handleLogin(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	var req api.Login
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	id, err := s.auth.Login(req.Username, req.Password)
+	if err != nil {
+		s.log.WithField("user", req.Username).Info("Failed login.")
+		s.httpError(w, http.StatusUnauthorized, err)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Value:    id,
+		Path:     "/",
+		MaxAge:   int(auth.SessionTTL.Seconds()),
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	resp := struct {
+		User string `json:"user"`
+	}{User: req.Username}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleLogout ends the session of the request and deletes the session cookie.
+func (s *Server) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	if c, err := r.Cookie(sessionCookie); err == nil {
+		s.auth.Logout(c.Value)
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookie,
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   isHTTPS(r),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleTokenGetAll(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	tokens, err := s.auth.Tokens(requestUser(r))
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Tokens []api.TokenResponse `json:"tokens"`
+	}{Tokens: make([]api.TokenResponse, 0, len(tokens))}
+	for _, t := range tokens {
+		resp.Tokens = append(resp.Tokens, tokenResponse(t))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleTokenPost creates an API token for the user of the request. The token is only included in this response.
+func (s *Server) handleTokenPost(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	var req api.TokenAdd
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Name == "" {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "name", Message: "missing token name"})
+		return
+	}
+
+	t, secret, err := s.auth.CreateToken(requestUser(r), req.Name)
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Token  api.TokenResponse `json:"token"`
+		Secret string            `json:"secret"`
+	}{Token: tokenResponse(t), Secret: secret}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleTokenDel(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.auth.RevokeToken(requestUser(r), id); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func tokenResponse(t auth.Token) api.TokenResponse {
+	resp := api.TokenResponse{ID: t.ID, Name: t.Name, Created: t.Created}
+	if !t.LastUsed.IsZero() {
+		resp.LastUsed = &t.LastUsed
+	}
+	return resp
+}
+
+// isHTTPS reports whether the client connected via HTTPS, directly or through a reverse proxy.
+func isHTTPS(r *http.Request) bool {
+	return r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https"
+}
@@ -0,0 +1,45 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+// handleListStatuses changes the workflow of a list.
+func (s *Server) handleListStatuses(w http.ResponseWriter, r *http.Request) {
+	var req api.ListStatuses
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	l, err := s.organiser(r).SetStatuses(r.PathValue("name"), req.Statuses)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		List api.ListResponse `json:"list"`
+	}{List: api.FromList(l)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleListBoard returns the tasks of a list grouped by status for a Kanban board. Like the list itself, the board
+// leaves out snoozed tasks unless ?hidden=true.
+func (s *Server) handleListBoard(w http.ResponseWriter, r *http.Request) {
+	l, err := s.organiser(r).GetList(r.PathValue("name"))
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Board api.BoardResponse `json:"board"`
+	}{Board: api.NewBoard(withoutHidden(r, l))}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
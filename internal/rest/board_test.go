@@ -0,0 +1,97 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestWorkflow(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Sprint"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	w := do(http.MethodPost, "/api/v1/list/Sprint", `{"title": "Write docs"}`, alice)
+	docs := decodeTask(t, w.Body)
+	if docs.Status != "todo" {
+		t.Errorf("status = %q, want todo before the workflow is changed", docs.Status)
+	}
+
+	for _, body := range []string{`{"statuses": ["todo"]}`, `{"statuses": ["todo", "done", "todo"]}`, `{"statuses": ["todo", ""]}`} {
+		if w := do(http.MethodPut, "/api/v1/list/Sprint/statuses", body, alice); w.Code != http.StatusBadRequest {
+			t.Errorf("set %s status = %d, want %d", body, w.Code, http.StatusBadRequest)
+		}
+	}
+	w = do(http.MethodPut, "/api/v1/list/Sprint/statuses", `{"statuses": ["todo", "in progress", "review", "done"]}`, alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("set statuses status = %d: %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodPost, "/api/v1/list/Sprint", `{"title": "Fix bug", "status": "in progress"}`, alice)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+	}
+	bug := decodeTask(t, w.Body)
+
+	path := "/api/v1/items/" + strconv.Itoa(docs.ID)
+	tests := []struct {
+		name     string
+		body     string
+		want     int
+		wantDone bool
+	}{
+		{"Review", `{"status": "review"}`, http.StatusAccepted, false},
+		{"Unknown status", `{"status": "blocked"}`, http.StatusBadRequest, false},
+		{"Last status marks done", `{"status": "done"}`, http.StatusAccepted, true},
+		{"Undone", `{"done": false}`, http.StatusAccepted, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodPatch, path, tt.body, alice); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := decodeTask(t, do(http.MethodGet, path, "", alice).Body); got.Done != tt.wantDone {
+				t.Errorf("done = %v, want %v", got.Done, tt.wantDone)
+			}
+		})
+	}
+
+	w = do(http.MethodGet, "/api/v1/list/Sprint/board", "", alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("board status = %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Board api.BoardResponse `json:"board"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]int{"todo": {docs.ID}, "in progress": {bug.ID}, "review": nil, "done": nil}
+	if len(resp.Board.Columns) != len(want) {
+		t.Fatalf("board = %+v, want %d columns", resp.Board, len(want))
+	}
+	for _, c := range resp.Board.Columns {
+		var ids []int
+		for _, task := range c.Tasks {
+			ids = append(ids, task.ID)
+		}
+		if len(ids) != len(want[c.Status]) || (len(ids) > 0 && ids[0] != want[c.Status][0]) {
+			t.Errorf("column %q has tasks %v, want %v", c.Status, ids, want[c.Status])
+		}
+	}
+}
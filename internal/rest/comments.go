@@ -0,0 +1,109 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+func (s *Server) handleCommentGetAll(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := s.organiser(r).GetTask(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Comments []api.CommentResponse `json:"comments"`
+	}{Comments: make([]api.CommentResponse, 0, len(t.Comments))}
+	for _, c := range t.Comments {
+		resp.Comments = append(resp.Comments, api.FromComment(c))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCommentPost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req api.CommentAdd
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c, err := s.organiser(r).AddComment(id, req.Text)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Comment api.CommentResponse `json:"comment"`
+	}{Comment: api.FromComment(c)}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleCommentEdit(w http.ResponseWriter, r *http.Request) {
+	id, cid, err := commentIDs(r)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req api.CommentAdd
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	c, err := s.organiser(r).EditComment(id, cid, req.Text)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Comment api.CommentResponse `json:"comment"`
+	}{Comment: api.FromComment(c)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleCommentDel(w http.ResponseWriter, r *http.Request) {
+	id, cid, err := commentIDs(r)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.organiser(r).DelComment(id, cid); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// commentIDs returns the task and comment ID of a request.
+func commentIDs(r *http.Request) (int, int, error) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		return 0, 0, err
+	}
+	cid, err := strconv.Atoi(r.PathValue("cid"))
+	return id, cid, err
+}
@@ -0,0 +1,101 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestComments(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob", "carol", "dave"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+	alice, bob, carol, dave := headers["alice"], headers["bob"], headers["carol"], headers["dave"]
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Work"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	for user, role := range map[string]string{"bob": "editor", "dave": "viewer"} {
+		if w := do(http.MethodPut, "/api/v1/list/Work/members/"+user, `{"role": "`+role+`"}`, alice); w.Code != http.StatusOK {
+			t.Fatalf("share status = %d: %s", w.Code, w.Body)
+		}
+	}
+	w := do(http.MethodPost, "/api/v1/list/Work", `{"title": "Review PR"}`, alice)
+	var resp struct {
+		Task struct {
+			ID int `json:"id"`
+		} `json:"task"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	task := "/api/v1/items/" + strconv.Itoa(resp.Task.ID)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header http.Header
+		want   int
+	}{
+		{"Empty comment", http.MethodPost, task + "/comments", `{"text": " "}`, alice, http.StatusBadRequest},
+		{"Add comment", http.MethodPost, task + "/comments", `{"text": "Looks good"}`, alice, http.StatusCreated},
+		{"Editor comments", http.MethodPost, task + "/comments", `{"text": "Agreed"}`, bob, http.StatusCreated},
+		{"Viewer can't comment", http.MethodPost, task + "/comments", `{"text": "Hi"}`, dave, http.StatusForbidden},
+		{"Viewer reads comments", http.MethodGet, task + "/comments", "", dave, http.StatusOK},
+		{"Others can't comment", http.MethodPost, task + "/comments", `{"text": "Hi"}`, carol, http.StatusNotFound},
+		{"Others can't read comments", http.MethodGet, task + "/comments", "", carol, http.StatusNotFound},
+		{"Edit own comment", http.MethodPatch, task + "/comments/1", `{"text": "Looks good to me"}`, alice, http.StatusOK},
+		{"Can't edit others' comments", http.MethodPatch, task + "/comments/1", `{"text": "Nope"}`, bob, http.StatusForbidden},
+		{"Can't delete others' comments", http.MethodDelete, task + "/comments/2", "", alice, http.StatusForbidden},
+		{"Delete own comment", http.MethodDelete, task + "/comments/2", "", bob, http.StatusNoContent},
+		{"Unknown comment", http.MethodDelete, task + "/comments/2", "", bob, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, tt.header); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	w = do(http.MethodGet, task+"/comments", "", bob)
+	var comments struct {
+		Comments []struct {
+			Author string  `json:"author"`
+			Text   string  `json:"text"`
+			Edited *string `json:"edited"`
+		} `json:"comments"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&comments)
+	if len(comments.Comments) != 1 {
+		t.Fatalf("comments = %+v, want one", comments.Comments)
+	}
+	if c := comments.Comments[0]; c.Author != "alice" || c.Text != "Looks good to me" || c.Edited == nil {
+		t.Errorf("comment = %+v", c)
+	}
+
+	w = do(http.MethodGet, task, "", alice)
+	var got struct {
+		Task struct {
+			Comments int `json:"comments"`
+		} `json:"task"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&got)
+	if got.Task.Comments != 1 {
+		t.Errorf("comment count = %d, want 1", got.Task.Comments)
+	}
+}
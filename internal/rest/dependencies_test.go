@@ -0,0 +1,88 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestDependencies(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Release"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	add := func(body string) api.TaskResponse {
+		w := do(http.MethodPost, "/api/v1/list/Release", body, alice)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+		}
+		return decodeTask(t, w.Body)
+	}
+	get := func(id int) api.TaskResponse {
+		return decodeTask(t, do(http.MethodGet, "/api/v1/items/"+strconv.Itoa(id), "", alice).Body)
+	}
+	build := add(`{"title": "Build"}`)
+	test := add(`{"title": "Test", "blocked_by": [` + strconv.Itoa(build.ID) + `]}`)
+	release := add(`{"title": "Release", "blocked_by": [` + strconv.Itoa(test.ID) + `]}`)
+	if !test.Blocked || !release.Blocked || build.Blocked {
+		t.Fatalf("blocked = %v, %v, %v, want only test and release", build.Blocked, test.Blocked, release.Blocked)
+	}
+
+	tests := []struct {
+		name string
+		id   int
+		body string
+		want int
+	}{
+		{"Cycle", build.ID, `{"blocked_by": [` + strconv.Itoa(release.ID) + `]}`, http.StatusBadRequest},
+		{"Itself", build.ID, `{"blocked_by": [` + strconv.Itoa(build.ID) + `]}`, http.StatusBadRequest},
+		{"Unknown task", build.ID, `{"blocked_by": [999]}`, http.StatusBadRequest},
+		{"Not an ID", build.ID, `{"blocked_by": ["build"]}`, http.StatusBadRequest},
+		{"Done unblocks", build.ID, `{"done": true}`, http.StatusAccepted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodPatch, "/api/v1/items/"+strconv.Itoa(tt.id), tt.body, alice); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+	if test = get(test.ID); test.Blocked {
+		t.Error("test is still blocked after build was done")
+	}
+	if release = get(release.ID); !release.Blocked {
+		t.Error("release isn't blocked by test anymore")
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/items/"+strconv.Itoa(test.ID), "", alice); w.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d: %s", w.Code, w.Body)
+	}
+	if release = get(release.ID); release.Blocked || len(release.BlockedBy) > 0 {
+		t.Errorf("release = %+v, want it unblocked without dependencies", release)
+	}
+}
+
+func decodeTask(t *testing.T, body io.Reader) api.TaskResponse {
+	var resp struct {
+		Task api.TaskResponse `json:"task"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return resp.Task
+}
@@ -0,0 +1,55 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// keepAlive is the interval of comments sent on idle event streams, so that proxies don't close them.
+const keepAlive = 30 * time.Second
+
+var errEventsDisabled = errors.New("the event stream is disabled")
+
+// handleEvents streams the changes of the lists the user can see as server-sent events, until the client disconnects.
+// The event type is the type of the change, the data a ChangeEvent.
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if s.stream == nil || !ok {
+		s.httpError(w, http.StatusNotFound, errEventsDisabled)
+		return
+	}
+
+	changes, cancel := s.stream.Subscribe(requestUser(r))
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	ticker := time.NewTicker(keepAlive)
+	defer ticker.Stop()
+	for {
+		select {
+		case c := <-changes:
+			data, err := json.Marshal(c.Event())
+			if err != nil {
+				s.log.WithError(err).Warn("Failed to encode event")
+				continue
+			}
+			if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", c.Type, data); err != nil {
+				return
+			}
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,56 @@
+package rest
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestEvents(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, aliceToken, _ := store.CreateToken("alice", "test")
+	_, bobToken, _ := store.CreateToken("bob", "test")
+	srv := httptest.NewServer(newAuthServer(fstest.MapFS{}, store, logger))
+	defer srv.Close()
+
+	send := func(method, path, body, token string) *http.Response {
+		req, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	stream := send(http.MethodGet, "/api/v1/events", "", aliceToken)
+	defer stream.Body.Close()
+	if ct := stream.Header.Get("Content-Type"); stream.StatusCode != http.StatusOK || ct != "text/event-stream" {
+		t.Fatalf("status = %d, content type = %q", stream.StatusCode, ct)
+	}
+
+	// bob's change isn't visible to alice, so the first event is alice's own
+	_ = send(http.MethodPost, "/api/v1/list", `{"name": "Secret"}`, bobToken).Body.Close()
+	_ = send(http.MethodPost, "/api/v1/list", `{"name": "Home"}`, aliceToken).Body.Close()
+
+	lines := bufio.NewScanner(stream.Body)
+	var event []string
+	for lines.Scan() && lines.Text() != "" {
+		event = append(event, lines.Text())
+	}
+	if len(event) != 2 || event[0] != "event: list.created" || !strings.Contains(event[1], `"list":"Home"`) {
+		t.Errorf("event = %q", event)
+	}
+}
@@ -0,0 +1,85 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+// maxImportSize limits the size of import request bodies.
+const maxImportSize = 32 << 20
+
+// handleExport returns all lists, tasks and filtered lists in the export format. The format is selected with the
+// "format" query parameter, either "json" (default) or "csv".
+func (s *Server) handleExport(w http.ResponseWriter, r *http.Request) {
+	lists, filtered := s.organiser(r).Lists()
+	export := api.NewExport(lists, filtered)
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Disposition", `attachment; filename="gotasks-export.json"`)
+		s.jsonResponse(w, http.StatusOK, export)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="gotasks-export.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err := export.WriteCSV(w); err != nil {
+			s.log.WithError(err).Warn("Failed to write CSV export")
+		}
+	default:
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "format", Message: fmt.Sprintf("invalid format %q", format)})
+	}
+}
+
+// handleImport merges an export into the repository. The format is taken from the "format" query parameter or, if
+// missing, from the Content-Type header. Conflicts are resolved according to the "strategy" query parameter. With
+// "dry_run=true" only the report is returned.
+func (s *Server) handleImport(w http.ResponseWriter, r *http.Request) {
+	strategy, err := api.ParseMergeStrategy(r.URL.Query().Get("strategy"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "strategy", Message: err.Error()})
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" && strings.HasPrefix(r.Header.Get("Content-Type"), "text/csv") {
+		format = "csv"
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	var export api.Export
+	switch format {
+	case "", "json":
+		err = json.NewDecoder(body).Decode(&export)
+	case "csv":
+		export, err = api.ReadCSV(body)
+	default:
+		err = &api.FieldError{Field: "format", Message: fmt.Sprintf("invalid format %q", format)}
+	}
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lists, filtered, err := export.Core()
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if r.URL.Query().Get("dry_run") == "true" {
+		s.jsonResponse(w, http.StatusOK, s.organiser(r).PreviewImport(lists, filtered, strategy))
+		return
+	}
+
+	report, err := s.organiser(r).Import(lists, filtered, strategy)
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, report)
+}
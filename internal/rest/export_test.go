@@ -0,0 +1,68 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestExportImport_RoundTrip(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(nil, store, logger))
+
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, "/api/v1/list", `{"name": "Work"}`},
+		{http.MethodPost, "/api/v1/list/Work", `{"title": "Report"}`},
+		{http.MethodPost, "/api/v1/list/Work", `{"title": "Slides", "blocked_by": [1], "estimate": "2h"}`},
+		{http.MethodPost, "/api/v1/items/2/comments", `{"text": "Draft?"}`},
+		{http.MethodPost, "/api/v1/items/2/time", `{"duration": "1h"}`},
+		{http.MethodPost, "/api/v1/items/2/snooze", `{"until": "tomorrow"}`},
+	} {
+		if w := do(req.method, req.path, req.body, alice); w.Code >= 300 {
+			t.Fatalf("%s %s status = %d: %s", req.method, req.path, w.Code, w.Body)
+		}
+	}
+	check := func(t *testing.T, do func(method, path, body string, header http.Header) *httptest.ResponseRecorder) {
+		t.Helper()
+		w := do(http.MethodGet, "/api/v1/items/2", "", alice)
+		if w.Code != http.StatusOK {
+			t.Fatalf("get task status = %d: %s", w.Code, w.Body)
+		}
+		task := decodeTask(t, w.Body)
+		if task.Comments != 1 || task.Tracked == "" || task.Estimate != "2h0m0s" || !slices.Equal(task.BlockedBy, []int{1}) ||
+			task.HiddenUntil.IsZero() {
+			t.Errorf("task = %+v, want its comment, tracked time, estimate, dependency and snooze", task)
+		}
+	}
+
+	for _, format := range []string{"json", "csv"} {
+		t.Run("Overwrite "+format, func(t *testing.T) {
+			export := do(http.MethodGet, "/api/v1/export?format="+format, "", alice)
+			w := do(http.MethodPost, "/api/v1/import?strategy=overwrite&format="+format, export.Body.String(), alice)
+			if w.Code != http.StatusOK {
+				t.Fatalf("import status = %d: %s", w.Code, w.Body)
+			}
+			check(t, do)
+		})
+	}
+
+	t.Run("New server", func(t *testing.T) {
+		export := do(http.MethodGet, "/api/v1/export", "", alice)
+		other := requester(newAuthServer(nil, store, logger))
+		if w := other(http.MethodPost, "/api/v1/import", export.Body.String(), alice); w.Code != http.StatusOK {
+			t.Fatalf("import status = %d: %s", w.Code, w.Body)
+		}
+		check(t, other)
+	})
+}
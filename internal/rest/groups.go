@@ -0,0 +1,93 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+func (s *Server) handleGroupGetAll(w http.ResponseWriter, r *http.Request) {
+	groups := s.organiser(r).Groups()
+
+	resp := struct {
+		Groups []api.GroupResponse `json:"groups"`
+	}{Groups: make([]api.GroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, api.FromGroup(g))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGroupAdd(w http.ResponseWriter, r *http.Request) {
+	var req api.GroupAdd
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	g, err := s.organiser(r).AddGroup(req)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Group api.GroupResponse `json:"group"`
+	}{Group: api.FromGroup(g)}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleGroupEdit(w http.ResponseWriter, r *http.Request) {
+	var req api.GroupAdd
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	g, err := s.organiser(r).EditGroup(r.PathValue("name"), req)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Group api.GroupResponse `json:"group"`
+	}{Group: api.FromGroup(g)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleGroupDel(w http.ResponseWriter, r *http.Request) {
+	if err := s.organiser(r).DelGroup(r.PathValue("name")); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleGroupOrder(w http.ResponseWriter, r *http.Request) {
+	var req api.GroupOrder
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	groups, err := s.organiser(r).OrderGroups(req.Groups)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Groups []api.GroupResponse `json:"groups"`
+	}{Groups: make([]api.GroupResponse, 0, len(groups))}
+	for _, g := range groups {
+		resp.Groups = append(resp.Groups, api.FromGroup(g))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
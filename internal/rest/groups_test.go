@@ -0,0 +1,152 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+	"github.com/jniewt/gotodo/internal/events"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+)
+
+func TestGroups(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	_, token, _ = store.CreateToken("bob", "test")
+	bob := http.Header{"Authorization": {"Bearer " + token}}
+	repo := repository.NewRepository(&storage.Fake{})
+	var changes []repository.Change
+	repo.Subscribe(func(c repository.Change) { changes = append(changes, c) })
+	orga := func(user string) Organiser { return repo.As(user) }
+	do := requester(NewServer(fstest.MapFS{}, orga, store, nil, events.NewBroker(), nil, log.NewEntry(logger)))
+
+	for _, name := range []string{"Office", "Meetings", "Home", "Garden"} {
+		if w := do(http.MethodPost, "/api/v1/list", `{"name": "`+name+`"}`, alice); w.Code != http.StatusCreated {
+			t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+		}
+	}
+	for _, task := range [][2]string{{"Office", "Report"}, {"Meetings", "Agenda"}, {"Home", "Dishes"}} {
+		if w := do(http.MethodPost, "/api/v1/list/"+task[0], `{"title": "`+task[1]+`"}`, alice); w.Code != http.StatusCreated {
+			t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		user   http.Header
+		want   int
+	}{
+		{"Add", http.MethodPost, "/api/v1/groups", `{"name": "Work", "lists": ["Office", "Meetings"]}`, alice, http.StatusCreated},
+		{"Add personal", http.MethodPost, "/api/v1/groups", `{"name": "Personal", "lists": ["Home", "Office"]}`, alice, http.StatusCreated},
+		{"Exists", http.MethodPost, "/api/v1/groups", `{"name": "Work"}`, alice, http.StatusConflict},
+		{"Missing name", http.MethodPost, "/api/v1/groups", `{"name": " "}`, alice, http.StatusBadRequest},
+		{"Unknown list", http.MethodPost, "/api/v1/groups", `{"name": "Other", "lists": ["Nope"]}`, alice, http.StatusBadRequest},
+		{"Duplicate list", http.MethodPost, "/api/v1/groups", `{"name": "Other", "lists": ["Home", "Home"]}`, alice, http.StatusBadRequest},
+		{"Invisible list", http.MethodPost, "/api/v1/groups", `{"name": "Other", "lists": ["Home"]}`, bob, http.StatusBadRequest},
+		{"Other user", http.MethodPost, "/api/v1/groups", `{"name": "Work"}`, bob, http.StatusCreated},
+		{"Edit", http.MethodPut, "/api/v1/groups/Personal", `{"colour": {"r": 0, "g": 128, "b": 0}, "lists": ["Home", "Garden"]}`, alice, http.StatusOK},
+		{"Edit unknown", http.MethodPut, "/api/v1/groups/Nope", `{}`, alice, http.StatusNotFound},
+		{"Order unknown", http.MethodPut, "/api/v1/groups", `{"groups": ["Nope"]}`, alice, http.StatusBadRequest},
+		{"Order", http.MethodPut, "/api/v1/groups", `{"groups": ["Personal"]}`, alice, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, tt.user); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	// lists has all lists of alice, her groups name theirs; Office moved from Work to Personal and out of it by the edit
+	w := do(http.MethodGet, "/api/v1/list", "", alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("get lists status = %d: %s", w.Code, w.Body)
+	}
+	var resp struct {
+		Groups []api.GroupResponse `json:"groups"`
+		Lists  []api.ListResponse  `json:"lists"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string][]string)
+	var order []string
+	for _, g := range resp.Groups {
+		order = append(order, g.Name)
+		got[g.Name] = g.Lists
+	}
+	if !slices.Equal(order, []string{"Personal", "Work"}) {
+		t.Errorf("groups = %v, want [Personal Work]", order)
+	}
+	if !slices.Equal(got["Personal"], []string{"Home", "Garden"}) || !slices.Equal(got["Work"], []string{"Meetings"}) {
+		t.Errorf("grouped lists = %v, want Personal: [Home Garden], Work: [Meetings]", got)
+	}
+	if len(resp.Lists) != 4 {
+		t.Errorf("lists = %v, want all 4 lists", resp.Lists)
+	}
+
+	// changes tell webhooks the group of their list
+	if w = do(http.MethodPost, "/api/v1/list/Meetings", `{"title": "Minutes"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+	}
+	if c := changes[len(changes)-1]; c.Groups["alice"] != "Work" || c.GroupLists("alice")("Work")[0] != "Meetings" {
+		t.Errorf("change groups = %v, want Meetings in Work of alice", c.Groups)
+	}
+
+	// the group rule matches the tasks of the lists in the group
+	rule, err := filter.NewRule("group", "Work")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := filter.Filter{RuleSets: []filter.RuleSet{{Rules: []filter.Rule{rule}}}}
+	if tasks := repo.As("alice").FindTasks(f); len(tasks) != 2 {
+		t.Errorf("FindTasks(group Work) = %v, want Agenda and Minutes", tasks)
+	}
+	if _, err = repo.As("alice").AddFilteredList("Work tasks", f); err != nil {
+		t.Fatal(err)
+	}
+	if w = do(http.MethodGet, "/api/v1/list/Work%20tasks", "", alice); countItems(t, w.Body) != 2 {
+		t.Errorf("filtered list of group Work doesn't have 2 tasks")
+	}
+	// the group of bob is empty
+	if tasks := repo.As("bob").FindTasks(f); len(tasks) != 0 {
+		t.Errorf("FindTasks(group Work) of bob = %v, want none", tasks)
+	}
+
+	// deleting a list removes it from its group, deleting a group keeps its lists
+	if w = do(http.MethodDelete, "/api/v1/list/Meetings", "", alice); w.Code != http.StatusNoContent {
+		t.Fatalf("delete list status = %d: %s", w.Code, w.Body)
+	}
+	if groups := repo.As("alice").Groups(); len(groups[1].Lists) != 0 {
+		t.Errorf("lists of Work = %v, want none after deleting Meetings", groups[1].Lists)
+	}
+	if w = do(http.MethodDelete, "/api/v1/groups/Personal", "", alice); w.Code != http.StatusNoContent {
+		t.Fatalf("delete group status = %d: %s", w.Code, w.Body)
+	}
+	if w = do(http.MethodDelete, "/api/v1/groups/Personal", "", alice); w.Code != http.StatusNotFound {
+		t.Errorf("delete group again status = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	if _, err = repo.As("alice").GetList("Garden"); err != nil {
+		t.Errorf("GetList(Garden) after deleting its group: %v", err)
+	}
+	if groups := repo.As("bob").Groups(); len(groups) != 1 || groups[0].Name != "Work" {
+		t.Errorf("groups of bob = %v, want only Work", groups)
+	}
+}
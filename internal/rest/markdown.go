@@ -0,0 +1,84 @@
+package rest
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/markdown"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// maxMarkdownSize limits the size of Markdown documents that can be imported.
+const maxMarkdownSize = 1 << 20
+
+// handleListMarkdown renders a list or filtered list as a Markdown checklist.
+func (s *Server) handleListMarkdown(w http.ResponseWriter, r *http.Request, name string) {
+	orga := s.organiser(r)
+	l, err := orga.GetList(name)
+	if errors.Is(err, repository.ErrListNotFound) {
+		var tasks []*core.Task
+		tasks, err = orga.GetFilteredTasks(name)
+		l = core.List{Name: name, Items: tasks}
+	}
+	if errors.Is(err, repository.ErrListNotFound) {
+		s.httpError(w, http.StatusNotFound, err)
+		return
+	} else if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	var buf bytes.Buffer
+	if err = markdown.Render(&buf, l); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	if _, err = w.Write(buf.Bytes()); err != nil {
+		s.log.WithError(err).Warn("Failed to write Markdown response")
+	}
+}
+
+// handleListImportMarkdown adds all checklist items of a Markdown document as tasks to a list. Checked items are added
+// as done tasks.
+func (s *Server) handleListImportMarkdown(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	orga := s.organiser(r)
+	if _, err := orga.GetList(name); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	items, err := markdown.Parse(http.MaxBytesReader(w, r.Body, maxMarkdownSize))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	tasks := make([]api.TaskResponse, 0, len(items))
+	for _, item := range items {
+		t, err := orga.AddItem(name, item.Task)
+		if err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if item.Done {
+			if t, err = orga.MarkDone(t.ID, true); err != nil {
+				s.httpError(w, http.StatusInternalServerError, err)
+				return
+			}
+		}
+		tasks = append(tasks, api.FromTask(t))
+	}
+
+	resp := struct {
+		Tasks []api.TaskResponse `json:"tasks"`
+	}{Tasks: tasks}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
@@ -0,0 +1,20 @@
+package rest
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+// openAPI is the OpenAPI 3 document describing the REST API. It is checked against the registered routes and the
+// types of package api in the tests.
+//
+//go:embed openapi.json
+var openAPI []byte
+
+func (s *Server) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(openAPI); err != nil {
+		s.log.WithError(err).Warn("Failed to write OpenAPI document")
+	}
+}
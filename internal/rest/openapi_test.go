@@ -0,0 +1,568 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/events"
+	"github.com/jniewt/gotodo/internal/pomodoro"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/storage"
+	"github.com/jniewt/gotodo/internal/webhook"
+)
+
+type openAPIDoc struct {
+	OpenAPI    string                                `json:"openapi"`
+	Paths      map[string]map[string]json.RawMessage `json:"paths"`
+	Components struct {
+		Schemas map[string]struct {
+			Properties map[string]json.RawMessage `json:"properties"`
+		} `json:"schemas"`
+	} `json:"components"`
+}
+
+// specOnlyPaths are documented in the spec but served by another route, as Go patterns can't match them.
+var specOnlyPaths = map[string]string{
+	"/api/v1/list/{name}.md": "/api/v1/list/{name}",
+}
+
+func newTestServer(t *testing.T) *Server {
+	t.Helper()
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	repo := repository.NewRepository(&storage.Fake{})
+	return NewServer(fstest.MapFS{}, func(user string) Organiser { return repo.As(user) }, nil, nil, nil, nil, log.NewEntry(logger))
+}
+
+func loadOpenAPI(t *testing.T) openAPIDoc {
+	t.Helper()
+	var doc openAPIDoc
+	if err := json.Unmarshal(openAPI, &doc); err != nil {
+		t.Fatalf("invalid openapi.json: %v", err)
+	}
+	return doc
+}
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc := loadOpenAPI(t)
+	s := newTestServer(t)
+
+	var documented []string
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			if method == "parameters" {
+				continue
+			}
+			if served, ok := specOnlyPaths[path]; ok {
+				if !slices.Contains(s.apiRoutes, strings.ToUpper(method)+" "+served) {
+					t.Errorf("%s %s is documented, but %s isn't registered", strings.ToUpper(method), path, served)
+				}
+				continue
+			}
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+
+	registered := slices.Clone(s.apiRoutes)
+	sort.Strings(documented)
+	sort.Strings(registered)
+	for _, r := range registered {
+		if !slices.Contains(documented, r) {
+			t.Errorf("route %q is missing in openapi.json", r)
+		}
+	}
+	for _, d := range documented {
+		if !slices.Contains(registered, d) {
+			t.Errorf("openapi.json documents %q, but it isn't registered", d)
+		}
+	}
+}
+
+func TestOpenAPISchemas(t *testing.T) {
+	doc := loadOpenAPI(t)
+
+	types := map[string]any{
+		"RGB":             api.RGB{},
+		"ListAdd":         api.ListAdd{},
+		"List":            api.ListResponse{},
+		"Task":            api.TaskResponse{},
+		"TaskAdd":         api.TaskAdd{},
+		"TaskChange":      api.TaskChange{},
+		"Export":          api.Export{},
+		"ExportList":      api.ExportList{},
+		"ExportTask":      api.ExportTask{},
+		"ExportPomodoro":  api.ExportPomodoro{},
+		"ExportFiltered":  api.ExportFiltered{},
+		"ExportRule":      api.ExportRule{},
+		"ImportReport":    api.ImportReport{},
+		"ImportConflict":  api.ImportConflict{},
+		"ErrorResponse":   api.ErrorResponse{},
+		"Error":           api.Error{},
+		"FieldError":      api.FieldError{},
+		"Login":           api.Login{},
+		"TokenAdd":        api.TokenAdd{},
+		"Token":           api.TokenResponse{},
+		"User":            api.UserResponse{},
+		"UserAdd":         api.UserAdd{},
+		"UserChange":      api.UserChange{},
+		"ListMember":      api.ListMember{},
+		"ListShare":       api.ListShare{},
+		"ListStatuses":    api.ListStatuses{},
+		"ListArchive":     api.ListArchive{},
+		"GroupAdd":        api.GroupAdd{},
+		"Group":           api.GroupResponse{},
+		"GroupOrder":      api.GroupOrder{},
+		"Board":           api.BoardResponse{},
+		"BoardColumn":     api.BoardColumn{},
+		"TaskEvent":       api.TaskEvent{},
+		"Comment":         api.CommentResponse{},
+		"CommentAdd":      api.CommentAdd{},
+		"TimeEntry":       api.TimeEntryResponse{},
+		"TimeEntryAdd":    api.TimeEntryAdd{},
+		"TimeReport":      api.TimeReport{},
+		"TimeReportRow":   api.TimeReportRow{},
+		"Stats":           api.Stats{},
+		"StatsPeriod":     api.StatsPeriod{},
+		"StatsGroup":      api.StatsGroup{},
+		"PomodoroStart":   api.PomodoroStart{},
+		"PomodoroSession": api.PomodoroSession{},
+		"PomodoroStats":   api.PomodoroStats{},
+		"PomodoroDay":     api.PomodoroDay{},
+		"TaskSnooze":      api.TaskSnooze{},
+		"Webhook":         api.WebhookResponse{},
+		"WebhookAdd":      api.WebhookAdd{},
+		"WebhookDelivery": api.WebhookDelivery{},
+		"ChangeEvent":     api.ChangeEvent{},
+		"TaskReminder":    api.TaskReminder{},
+	}
+
+	for name, v := range types {
+		schema, ok := doc.Components.Schemas[name]
+		if !ok {
+			t.Errorf("schema %s is missing", name)
+			continue
+		}
+		var documented []string
+		for p := range schema.Properties {
+			documented = append(documented, p)
+		}
+		fields := jsonFields(reflect.TypeOf(v))
+		sort.Strings(documented)
+		sort.Strings(fields)
+		if !slices.Equal(documented, fields) {
+			t.Errorf("schema %s has properties %v, want %v", name, documented, fields)
+		}
+	}
+
+	// every reference must point to a schema
+	refs := regexp.MustCompile(`"#/components/schemas/(\w+)"`).FindAllSubmatch(openAPI, -1)
+	for _, ref := range refs {
+		if _, ok := doc.Components.Schemas[string(ref[1])]; !ok {
+			t.Errorf("reference to unknown schema %s", ref[1])
+		}
+	}
+}
+
+// jsonFields returns the JSON names of the fields of the given struct type.
+func jsonFields(t reflect.Type) []string {
+	var fields []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if f.Anonymous && name == "" {
+			fields = append(fields, jsonFields(f.Type)...)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields = append(fields, name)
+	}
+	return fields
+}
+
+func TestOpenAPIServed(t *testing.T) {
+	s := newTestServer(t)
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/openapi.json", nil))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	var doc openAPIDoc
+	if err := json.NewDecoder(w.Body).Decode(&doc); err != nil {
+		t.Fatalf("invalid document: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.") {
+		t.Errorf("openapi = %q, want 3.x", doc.OpenAPI)
+	}
+}
+
+// schemaChecker validates JSON values against the schemas in openapi.json. It supports the parts of JSON Schema the
+// document uses. Objects with properties are closed unless they set additionalProperties, so fields missing in the
+// document are reported.
+type schemaChecker struct {
+	schemas map[string]any
+}
+
+func newSchemaChecker(t *testing.T) (schemaChecker, map[string]any) {
+	t.Helper()
+	var doc map[string]any
+	if err := json.Unmarshal(openAPI, &doc); err != nil {
+		t.Fatalf("invalid openapi.json: %v", err)
+	}
+	return schemaChecker{schemas: doc["components"].(map[string]any)["schemas"].(map[string]any)}, doc
+}
+
+// resolve follows $ref and merges allOf into one object schema.
+func (c schemaChecker) resolve(schema map[string]any) map[string]any {
+	if ref, ok := schema["$ref"].(string); ok {
+		return c.resolve(c.schemas[strings.TrimPrefix(ref, "#/components/schemas/")].(map[string]any))
+	}
+	all, ok := schema["allOf"].([]any)
+	if !ok {
+		return schema
+	}
+	merged := map[string]any{}
+	properties := map[string]any{}
+	var required []any
+	for _, s := range append(all, schema) {
+		s := s.(map[string]any)
+		if _, ok := s["allOf"]; !ok {
+			s = c.resolve(s)
+		}
+		for k, v := range s {
+			switch k {
+			case "allOf":
+			case "properties":
+				for name, p := range v.(map[string]any) {
+					properties[name] = p
+				}
+			case "required":
+				required = append(required, v.([]any)...)
+			default:
+				merged[k] = v
+			}
+		}
+	}
+	if len(properties) > 0 {
+		merged["properties"] = properties
+	}
+	merged["required"] = required
+	return merged
+}
+
+// check returns the differences between value and schema, path names the value in the messages.
+func (c schemaChecker) check(schema map[string]any, value any, path string) []string {
+	schema = c.resolve(schema)
+	if value == nil {
+		if schema["nullable"] == true || len(schema) == 0 {
+			return nil
+		}
+		return []string{path + " is null"}
+	}
+	if enum, ok := schema["enum"].([]any); ok && !slices.Contains(enum, value) {
+		return []string{fmt.Sprintf("%s = %v isn't one of %v", path, value, enum)}
+	}
+	typ, _ := schema["type"].(string)
+	if typ == "" && schema["properties"] != nil {
+		typ = "object"
+	}
+	switch typ {
+	case "object":
+		obj, ok := value.(map[string]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s = %v isn't an object", path, value)}
+		}
+		return c.checkObject(schema, obj, path)
+	case "array":
+		arr, ok := value.([]any)
+		if !ok {
+			return []string{fmt.Sprintf("%s = %v isn't an array", path, value)}
+		}
+		var errs []string
+		items, _ := schema["items"].(map[string]any)
+		for i, v := range arr {
+			errs = append(errs, c.check(items, v, fmt.Sprintf("%s[%d]", path, i))...)
+		}
+		return errs
+	case "string":
+		s, ok := value.(string)
+		if !ok {
+			return []string{fmt.Sprintf("%s = %v isn't a string", path, value)}
+		}
+		return checkString(schema, s, path)
+	case "integer", "number":
+		n, ok := value.(float64)
+		if !ok || (typ == "integer" && n != math.Trunc(n)) {
+			return []string{fmt.Sprintf("%s = %v isn't an %s", path, value, typ)}
+		}
+		if min, ok := schema["minimum"].(float64); ok && n < min {
+			return []string{fmt.Sprintf("%s = %v is less than %v", path, n, min)}
+		}
+		if max, ok := schema["maximum"].(float64); ok && n > max {
+			return []string{fmt.Sprintf("%s = %v is greater than %v", path, n, max)}
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return []string{fmt.Sprintf("%s = %v isn't a boolean", path, value)}
+		}
+	}
+	return nil
+}
+
+func (c schemaChecker) checkObject(schema map[string]any, obj map[string]any, path string) []string {
+	var errs []string
+	required, _ := schema["required"].([]any)
+	for _, r := range required {
+		if _, ok := obj[r.(string)]; !ok {
+			errs = append(errs, fmt.Sprintf("%s.%s is missing", path, r))
+		}
+	}
+	properties, _ := schema["properties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"]
+	for name, v := range obj {
+		if p, ok := properties[name]; ok {
+			errs = append(errs, c.check(p.(map[string]any), v, path+"."+name)...)
+			continue
+		}
+		switch a := additional.(type) {
+		case map[string]any:
+			errs = append(errs, c.check(a, v, path+"."+name)...)
+		case nil:
+			if !hasAdditional && properties != nil {
+				errs = append(errs, fmt.Sprintf("%s.%s isn't documented", path, name))
+			}
+		case bool:
+			if !a {
+				errs = append(errs, fmt.Sprintf("%s.%s isn't documented", path, name))
+			}
+		}
+	}
+	return errs
+}
+
+func checkString(schema map[string]any, s, path string) []string {
+	if pattern, ok := schema["pattern"].(string); ok && !regexp.MustCompile(pattern).MatchString(s) {
+		return []string{fmt.Sprintf("%s = %q doesn't match %s", path, s, pattern)}
+	}
+	var err error
+	switch schema["format"] {
+	case "date-time":
+		_, err = time.Parse(time.RFC3339, s)
+	case "date":
+		_, err = time.Parse(time.DateOnly, s)
+	case "uri":
+		_, err = url.ParseRequestURI(s)
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("%s = %q isn't a %s", path, s, schema["format"])}
+	}
+	return nil
+}
+
+func TestOpenAPIExamples(t *testing.T) {
+	c, doc := newSchemaChecker(t)
+	var walk func(v any, path string)
+	walk = func(v any, path string) {
+		switch v := v.(type) {
+		case map[string]any:
+			if example, ok := v["example"]; ok {
+				for _, err := range c.check(v, example, path) {
+					t.Errorf("example %s", err)
+				}
+			}
+			for k, child := range v {
+				walk(child, path+"/"+k)
+			}
+		case []any:
+			for i, child := range v {
+				walk(child, fmt.Sprintf("%s/%d", path, i))
+			}
+		}
+	}
+	walk(doc, "#")
+}
+
+// TestOpenAPIMessages sends requests using every feature of the API and checks the request bodies and the JSON
+// responses against the schemas of their operations.
+func TestOpenAPIMessages(t *testing.T) {
+	c, doc := newSchemaChecker(t)
+	paths := doc["paths"].(map[string]any)
+	responses := doc["components"].(map[string]any)["responses"].(map[string]any)
+
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	_ = store.SetAdmin("alice", true)
+	repo := repository.NewRepository(&storage.Fake{})
+	hooks, _ := webhook.NewDispatcher("", log.NewEntry(logger))
+	stream := events.NewBroker()
+	repo.Subscribe(stream.Notify)
+	orga := func(user string) Organiser { return repo.As(user) }
+	timer := pomodoro.NewTimer(repo, log.NewEntry(logger), stream)
+	do := requester(NewServer(fstest.MapFS{}, orga, store, hooks, stream, timer, log.NewEntry(logger)))
+	alice := headers["alice"]
+
+	// call sends a request for the operation "METHOD /path/{param}" of the document and returns the decoded response.
+	// Bodies are checked against the schema of the request unless they are strings, which are sent as they are.
+	call := func(operation, path string, body any, want int) any {
+		t.Helper()
+		method, route, _ := strings.Cut(operation, " ")
+		op, ok := paths[route].(map[string]any)[strings.ToLower(method)].(map[string]any)
+		if !ok {
+			t.Fatalf("%s isn't documented", operation)
+		}
+		raw, isRaw := body.(string)
+		if body != nil && !isRaw {
+			data, err := json.Marshal(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			raw = string(data)
+			var req any
+			_ = json.Unmarshal(data, &req)
+			content := op["requestBody"].(map[string]any)["content"].(map[string]any)
+			schema := content["application/json"].(map[string]any)["schema"].(map[string]any)
+			for _, err := range c.check(schema, req, "request") {
+				t.Errorf("%s %s: %s", method, path, err)
+			}
+		}
+
+		w := do(method, path, raw, alice)
+		if w.Code != want {
+			t.Fatalf("%s %s status = %d, want %d: %s", method, path, w.Code, want, w.Body)
+		}
+		resp, ok := op["responses"].(map[string]any)[strconv.Itoa(w.Code)].(map[string]any)
+		if !ok {
+			t.Fatalf("%s %s: status %d isn't documented", method, path, w.Code)
+		}
+		if ref, ok := resp["$ref"].(string); ok {
+			resp = responses[strings.TrimPrefix(ref, "#/components/responses/")].(map[string]any)
+		}
+		content, ok := resp["content"].(map[string]any)["application/json"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		var got any
+		if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+			t.Fatalf("%s %s: invalid response %s", method, path, w.Body)
+		}
+		for _, err := range c.check(content["schema"].(map[string]any), got, "response") {
+			t.Errorf("%s %s: %s", method, path, err)
+		}
+		return got
+	}
+
+	due := time.Date(2026, 10, 20, 14, 0, 0, 0, time.Local)
+	call("POST /api/v1/login", "/api/v1/login", api.Login{Username: "alice", Password: "password123"}, http.StatusOK)
+	call("GET /api/v1/me", "/api/v1/me", nil, http.StatusOK)
+	call("POST /api/v1/tokens", "/api/v1/tokens", api.TokenAdd{Name: "ci"}, http.StatusCreated)
+	call("GET /api/v1/tokens", "/api/v1/tokens", nil, http.StatusOK)
+	call("POST /api/v1/users", "/api/v1/users", api.UserAdd{Name: "carol", Password: "password123"}, http.StatusCreated)
+	call("PATCH /api/v1/users/{name}", "/api/v1/users/carol", map[string]bool{"admin": true}, http.StatusOK)
+	call("GET /api/v1/users", "/api/v1/users", nil, http.StatusOK)
+
+	call("POST /api/v1/list", "/api/v1/list", api.ListAdd{Name: "Work", Colour: api.RGB{R: 200}}, http.StatusCreated)
+	call("PUT /api/v1/list/{name}/statuses", "/api/v1/list/Work/statuses",
+		api.ListStatuses{Statuses: []string{"todo", "review", "done"}}, http.StatusOK)
+	call("PUT /api/v1/list/{name}/members/{user}", "/api/v1/list/Work/members/bob", api.ListShare{Role: "editor"},
+		http.StatusOK)
+	call("PUT /api/v1/list/{name}/archive", "/api/v1/list/Work/archive", api.ListArchive{ArchiveAfter: 7}, http.StatusOK)
+	call("POST /api/v1/list/{name}", "/api/v1/list/Work", api.TaskAdd{
+		Title: "Report", Priority: 1, DueType: core.DueBy, Due: due, Reminders: []string{"30m before"},
+		Status: "review", Estimate: "2h", Tags: []string{"acme"},
+	}, http.StatusCreated)
+	call("POST /api/v1/list/{name}", "/api/v1/list/Work", api.TaskAdd{
+		Title: "Slides", AllDay: true, DueType: core.DueOn, Due: due, BlockedBy: []int{1},
+	}, http.StatusCreated)
+	call("POST /api/v1/quickadd", "/api/v1/quickadd", map[string]string{"text": "Call client tomorrow 9am !high #Work"},
+		http.StatusCreated)
+
+	// the SDK sends all fields of a task to change it
+	change := api.NewTaskChange(core.Task{
+		Title: "Report", List: "Work", Priority: 1, DueType: core.DueBy, Due: due, Status: "review", Tags: []string{"acme"},
+	})
+	change.Assignee = "bob"
+	call("PATCH /api/v1/items/{id}", "/api/v1/items/1", change, http.StatusAccepted)
+	call("PATCH /api/v1/items/{id}", "/api/v1/items/1", map[string]any{"reminders": nil, "tags": nil}, http.StatusAccepted)
+
+	call("POST /api/v1/items/{id}/comments", "/api/v1/items/1/comments", api.CommentAdd{Text: "Draft?"},
+		http.StatusCreated)
+	call("PATCH /api/v1/items/{id}/comments/{cid}", "/api/v1/items/1/comments/1", api.CommentAdd{Text: "Draft ready?"},
+		http.StatusOK)
+	call("GET /api/v1/items/{id}/comments", "/api/v1/items/1/comments", nil, http.StatusOK)
+	call("POST /api/v1/items/{id}/timer/start", "/api/v1/items/1/timer/start", nil, http.StatusCreated)
+	call("POST /api/v1/items/{id}/timer/stop", "/api/v1/items/1/timer/stop", nil, http.StatusOK)
+	call("POST /api/v1/items/{id}/time", "/api/v1/items/1/time", api.TimeEntryAdd{Duration: "1h30m", Note: "Draft"},
+		http.StatusCreated)
+	call("GET /api/v1/items/{id}/time", "/api/v1/items/1/time", nil, http.StatusOK)
+	call("POST /api/v1/items/{id}/pomodoro", "/api/v1/items/1/pomodoro", api.PomodoroStart{Focus: "50m"},
+		http.StatusCreated)
+	call("GET /api/v1/pomodoro", "/api/v1/pomodoro", nil, http.StatusOK)
+	call("POST /api/v1/items/{id}/snooze", "/api/v1/items/2/snooze", api.TaskSnooze{Until: "1h"}, http.StatusOK)
+	call("GET /api/v1/items/{id}/history", "/api/v1/items/1/history", nil, http.StatusOK)
+
+	call("POST /api/v1/groups", "/api/v1/groups", api.GroupAdd{Name: "Office", Lists: []string{"Work"}},
+		http.StatusCreated)
+	call("PUT /api/v1/groups", "/api/v1/groups", api.GroupOrder{Groups: []string{"Office"}}, http.StatusOK)
+	call("GET /api/v1/groups", "/api/v1/groups", nil, http.StatusOK)
+	call("POST /api/v1/webhooks", "/api/v1/webhooks", api.WebhookAdd{URL: "https://chat.example.com/hook"},
+		http.StatusCreated)
+	call("GET /api/v1/webhooks", "/api/v1/webhooks", nil, http.StatusOK)
+	call("GET /api/v1/webhooks/{id}/deliveries", "/api/v1/webhooks/1/deliveries", nil, http.StatusOK)
+
+	call("GET /api/v1/list", "/api/v1/list", nil, http.StatusOK)
+	call("GET /api/v1/list/{name}", "/api/v1/list/Work", nil, http.StatusOK)
+	call("GET /api/v1/list/{name}/board", "/api/v1/list/Work/board", nil, http.StatusOK)
+	call("GET /api/v1/stats", "/api/v1/stats", nil, http.StatusOK)
+	call("GET /api/v1/time/report", "/api/v1/time/report?group=tag", nil, http.StatusOK)
+	call("GET /api/v1/pomodoro/stats", "/api/v1/pomodoro/stats", nil, http.StatusOK)
+
+	export := call("GET /api/v1/export", "/api/v1/export", nil, http.StatusOK)
+	call("POST /api/v1/import", "/api/v1/import?strategy=duplicate", export, http.StatusOK)
+
+	call("PATCH /api/v1/items/{id}", "/api/v1/items/1", map[string]string{"status": "done"}, http.StatusAccepted)
+	if _, err := repo.ArchiveDone(time.Now().AddDate(0, 0, 8)); err != nil {
+		t.Fatal(err)
+	}
+	call("GET /api/v1/archive", "/api/v1/archive", nil, http.StatusOK)
+	call("POST /api/v1/archive/{id}/restore", "/api/v1/archive/1/restore", nil, http.StatusOK)
+
+	call("POST /api/v1/list/{name}", "/api/v1/list/Work", `{"due_type": "due_by"}`, http.StatusBadRequest)
+	call("GET /api/v1/items/{id}", "/api/v1/items/99", nil, http.StatusNotFound)
+}
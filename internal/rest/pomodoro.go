@@ -0,0 +1,116 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+var errPomodorosDisabled = errors.New("Pomodoro sessions are disabled")
+
+// handlePomodoroStart starts a Pomodoro session of the user on a task. The phase changes are sent on the event stream.
+func (s *Server) handlePomodoroStart(w http.ResponseWriter, r *http.Request) {
+	if s.pomodoros == nil {
+		s.httpError(w, http.StatusNotFound, errPomodorosDisabled)
+		return
+	}
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req api.PomodoroStart
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	focus, err := parsePhase("focus", req.Focus)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	brk, err := parsePhase("break", req.Break)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	session, err := s.pomodoros.Start(requestUser(r), id, focus, brk)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Pomodoro api.PomodoroSession `json:"pomodoro"`
+	}{Pomodoro: session}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+// handlePomodoroGet returns the running Pomodoro session of the user, null if there is none.
+func (s *Server) handlePomodoroGet(w http.ResponseWriter, r *http.Request) {
+	if s.pomodoros == nil {
+		s.httpError(w, http.StatusNotFound, errPomodorosDisabled)
+		return
+	}
+
+	var resp struct {
+		Pomodoro *api.PomodoroSession `json:"pomodoro"`
+	}
+	if session, ok := s.pomodoros.Session(requestUser(r)); ok {
+		resp.Pomodoro = &session
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handlePomodoroStop(w http.ResponseWriter, r *http.Request) {
+	if s.pomodoros == nil {
+		s.httpError(w, http.StatusNotFound, errPomodorosDisabled)
+		return
+	}
+
+	if _, err := s.pomodoros.Stop(requestUser(r)); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handlePomodoroStats counts the completed Pomodoro sessions of the user per day from the "from" until the "to" date
+// including, by default during the last 7 days. Sessions on archived tasks count as well.
+func (s *Server) handlePomodoroStats(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, to, err := parseDateRange(r, today.AddDate(0, 0, -6), today)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "to", Message: "to must be after from and at most a year later"})
+		return
+	}
+
+	s.jsonResponse(w, http.StatusOK, api.NewPomodoroStats(reportLists(s.organiser(r)), requestUser(r), from, to))
+}
+
+// parsePhase parses the length of a phase of a Pomodoro session, empty means the default length.
+func parsePhase(field, v string) (time.Duration, error) {
+	if v == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, &api.FieldError{Field: field, Message: fmt.Sprintf("invalid %s %q, must be a duration like 25m", field, v)}
+	}
+	return d, nil
+}
@@ -0,0 +1,94 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestPomodoro(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	_, token, _ = store.CreateToken("bob", "test")
+	bob := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Work"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	w := do(http.MethodPost, "/api/v1/list/Work", `{"title": "Write report"}`, alice)
+	task := "/api/v1/items/" + strconv.Itoa(decodeTask(t, w.Body).ID)
+	if w = do(http.MethodPut, "/api/v1/list/Work/members/bob", `{"role": "viewer"}`, alice); w.Code != http.StatusOK {
+		t.Fatalf("share status = %d: %s", w.Code, w.Body)
+	}
+	if w = do(http.MethodPost, task+"/pomodoro", `{}`, bob); w.Code != http.StatusForbidden {
+		t.Errorf("start by viewer status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"Invalid focus", http.MethodPost, task + "/pomodoro", `{"focus": "-5m"}`, http.StatusBadRequest},
+		{"Unknown task", http.MethodPost, "/api/v1/items/999/pomodoro", `{}`, http.StatusNotFound},
+		{"Stop without session", http.MethodDelete, "/api/v1/pomodoro", "", http.StatusNotFound},
+		{"Start", http.MethodPost, task + "/pomodoro", `{"focus": "20ms", "break": "1h"}`, http.StatusCreated},
+		{"Second session", http.MethodPost, task + "/pomodoro", `{}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, alice); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	// wait for the break, which starts once the focus phase has been recorded
+	var resp struct {
+		Pomodoro *api.PomodoroSession `json:"pomodoro"`
+	}
+	for deadline := time.Now().Add(time.Second); ; {
+		if err := json.NewDecoder(do(http.MethodGet, "/api/v1/pomodoro", "", alice).Body).Decode(&resp); err != nil {
+			t.Fatal(err)
+		}
+		if resp.Pomodoro != nil && resp.Pomodoro.Phase == api.PhaseBreak {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("session = %+v, want break", resp.Pomodoro)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := decodeTask(t, do(http.MethodGet, task, "", alice).Body); got.Pomodoros != 1 {
+		t.Errorf("pomodoros = %d, want 1", got.Pomodoros)
+	}
+
+	if w := do(http.MethodDelete, "/api/v1/pomodoro", "", alice); w.Code != http.StatusNoContent {
+		t.Errorf("stop status = %d: %s", w.Code, w.Body)
+	}
+	var stats api.PomodoroStats
+	if err := json.NewDecoder(do(http.MethodGet, "/api/v1/pomodoro/stats", "", alice).Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	today := stats.Days[len(stats.Days)-1]
+	if len(stats.Days) != 7 || today.Date != time.Now().Format("2006-01-02") || today.Sessions != 1 || stats.Total.Tasks != 1 {
+		t.Errorf("stats = %+v, want one session today", stats)
+	}
+}
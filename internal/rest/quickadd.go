@@ -0,0 +1,51 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/quickadd"
+)
+
+// handleQuickAdd adds a task from a one-line description like "Call client tomorrow 9am !high #Work". Dates are
+// resolved in the server's time zone. The list given in the description takes precedence over the one in the request.
+func (s *Server) handleQuickAdd(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Text string `json:"text"`
+		List string `json:"list"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	task, err := quickadd.Parse(req.Text, time.Now())
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "text", Message: err.Error()})
+		return
+	}
+	if task.List == "" {
+		task.List = req.List
+	}
+	if task.List == "" {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "list", Message: "missing list name"})
+		return
+	}
+	orga := s.organiser(r)
+	lists, _ := orga.Lists()
+	task.List = quickadd.ResolveList(task.List, lists)
+
+	t, err := orga.AddItem(task.List, task)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Task api.TaskResponse `json:"task"`
+	}{Task: api.FromTask(t)}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
@@ -7,41 +7,249 @@ import (
 )
 
 func (s *Server) routes() {
-	// allowCors is a middleware that allows CORS requests from any origin.
-	allowCors := cors.New([]string{"*"}).HandleFunc
-
 	s.router.Handle("GET /", http.FileServer(http.FS(s.staticFS)))
 
-	// allow CORS preflight requests for all routes under /api
-	s.router.Handle("OPTIONS /api/*", allowCors(func(_ http.ResponseWriter, _ *http.Request) {}))
+	// allow CORS preflight requests for all routes under /api/v1
+	s.router.Handle("OPTIONS /api/v1/", allowCors(func(_ http.ResponseWriter, _ *http.Request) {}))
+
+	// log in with a password, sets the session cookie used by the web UI
+	// accepts JSON: Login, returns JSON: {user: string}
+	s.handlePublicAPI("POST /api/v1/login", s.handleLogin)
+
+	// end the session of the request
+	s.handleAPI("POST /api/v1/logout", s.handleLogout)
+
+	// get the API tokens of the user, without the tokens themselves
+	// returns JSON: {tokens: [Token]}
+	s.handleAPI("GET /api/v1/tokens", s.handleTokenGetAll)
+
+	// create an API token, which is only returned in this response
+	// accepts JSON: TokenAdd, returns JSON: {token: Token, secret: string}
+	s.handleAPI("POST /api/v1/tokens", s.handleTokenPost)
+
+	// revoke an API token
+	s.handleAPI("DELETE /api/v1/tokens/{id}", s.handleTokenDel)
+
+	// get the authenticated user
+	// returns JSON: {user: User}
+	s.handleAPI("GET /api/v1/me", s.handleMe)
+
+	// get all users, admins only
+	// returns JSON: {users: [User]}
+	s.handleAPI("GET /api/v1/users", s.requireAdmin(s.handleUserGetAll))
+
+	// create a user, admins only
+	// accepts JSON: UserAdd, returns JSON: {user: User}
+	s.handleAPI("POST /api/v1/users", s.requireAdmin(s.handleUserPost))
+
+	// change the password or admin role of a user, admins only
+	// accepts JSON: UserChange, returns JSON: {user: User}
+	s.handleAPI("PATCH /api/v1/users/{name}", s.requireAdmin(s.handleUserChange))
+
+	// delete a user with their API tokens and all their data, admins only
+	s.handleAPI("DELETE /api/v1/users/{name}", s.requireAdmin(s.handleUserDel))
+
+	// follow the changes of the lists the user can see, including reminders, as server-sent events
+	// returns text/event-stream of ChangeEvent
+	s.handleAPI("GET /api/v1/events", s.handleEvents)
+
+	// get the webhooks of the user, without their secrets
+	// returns JSON: {webhooks: [Webhook]}
+	s.handleAPI("GET /api/v1/webhooks", s.handleWebhookGetAll)
+
+	// register a webhook for changes of the user's lists, the secret is only returned in this response
+	// accepts JSON: WebhookAdd, returns JSON: {webhook: Webhook, secret: string}
+	s.handleAPI("POST /api/v1/webhooks", s.handleWebhookPost)
+
+	// delete a webhook
+	s.handleAPI("DELETE /api/v1/webhooks/{id}", s.handleWebhookDel)
 
-	// get names of all lists and filtered lists
-	// returns JSON: {lists: [string], filtered_lists: [string]}
-	s.router.HandleFunc("GET /api/list", allowCors(s.handleListGetAll))
+	// get the latest deliveries to a webhook, newest first
+	// returns JSON: {deliveries: [WebhookDelivery]}
+	s.handleAPI("GET /api/v1/webhooks/{id}/deliveries", s.handleWebhookDeliveries)
 
-	// get a list and its tasks, also works for filtered lists
+	// the OpenAPI document describing all routes below, keep openapi.json in sync when changing them
+	// returns JSON: OpenAPI 3 document
+	s.handleAPI("GET /api/v1/openapi.json", s.handleOpenAPI)
+
+	// get all lists and filtered lists with their tasks, without snoozed tasks unless ?hidden=true, and the groups of the
+	// user with the names of their lists
+	// returns JSON: {groups: [Group], lists: [List], filtered_lists: [List]}
+	s.handleAPI("GET /api/v1/list", s.handleListGetAll)
+
+	// get a list and its tasks, also works for filtered lists, snoozed tasks are left out unless ?hidden=true
 	// returns JSON: {list: List, filtered: [bool]}
-	s.router.HandleFunc("GET /api/list/{name}", allowCors(s.handleListGet))
+	s.handleAPI("GET /api/v1/list/{name}", s.handleListGet)
+
+	// get a list or filtered list as Markdown checklist: GET /api/v1/list/{name}.md, handled by the route above as Go
+	// patterns can't match a suffix
+
+	// add tasks from a Markdown checklist to a list
+	// accepts Markdown, returns JSON: {tasks: [Task]}
+	s.handleAPI("POST /api/v1/list/{name}/import-md", s.handleListImportMarkdown)
 
 	// create a new list
 	// accepts JSON: ListAdd, returns JSON: {list: List}
-	s.router.HandleFunc("POST /api/list", allowCors(s.handleListPost))
+	s.handleAPI("POST /api/v1/list", s.handleListPost)
 
 	// update a list
 	// accepts JSON: ListAdd, returns JSON: {list: List}
-	s.router.HandleFunc("PATCH /api/list/{name}", allowCors(s.handleListEdit))
+	s.handleAPI("PATCH /api/v1/list/{name}", s.handleListEdit)
 
 	// delete a list
-	s.router.HandleFunc("DELETE /api/list/{name}", allowCors(s.handleListDel))
+	s.handleAPI("DELETE /api/v1/list/{name}", s.handleListDel)
+
+	// share a list with a user or change the user's role, owners only
+	// accepts JSON: ListShare, returns JSON: {list: List}
+	s.handleAPI("PUT /api/v1/list/{name}/members/{user}", s.handleListShare)
+
+	// remove a user from a shared list, owners can remove anyone, members themselves
+	s.handleAPI("DELETE /api/v1/list/{name}/members/{user}", s.handleListUnshare)
+
+	// change the workflow of a list, e.g. todo, in progress, review, done, owners only
+	// accepts JSON: ListStatuses, returns JSON: {list: List}
+	s.handleAPI("PUT /api/v1/list/{name}/statuses", s.handleListStatuses)
+
+	// get the tasks of a list grouped by status, snoozed tasks are left out unless ?hidden=true
+	// returns JSON: {board: Board}
+	s.handleAPI("GET /api/v1/list/{name}/board", s.handleListBoard)
+
+	// change after how many days done tasks of a list are archived, 0 turns archiving off, owners only
+	// accepts JSON: ListArchive, returns JSON: {list: List}
+	s.handleAPI("PUT /api/v1/list/{name}/archive", s.handleListArchive)
+
+	// get the archived tasks, most recently done first, ?list=name&from=2006-01-02&to=2006-01-02
+	// returns JSON: {tasks: [Task]}
+	s.handleAPI("GET /api/v1/archive", s.handleArchiveGet)
+
+	// move an archived task back to its list, it is open again
+	// returns JSON: {task: Task}
+	s.handleAPI("POST /api/v1/archive/{id}/restore", s.handleArchiveRestore)
+
+	// get the groups of lists of the user in order
+	// returns JSON: {groups: [Group]}
+	s.handleAPI("GET /api/v1/groups", s.handleGroupGetAll)
+
+	// add a group of lists, its lists are moved out of other groups
+	// accepts JSON: GroupAdd, returns JSON: {group: Group}
+	s.handleAPI("POST /api/v1/groups", s.handleGroupAdd)
+
+	// change the order of the groups, groups left out follow the given ones
+	// accepts JSON: GroupOrder, returns JSON: {groups: [Group]}
+	s.handleAPI("PUT /api/v1/groups", s.handleGroupOrder)
+
+	// change the colour and the lists of a group, renaming is not supported
+	// accepts JSON: GroupAdd, returns JSON: {group: Group}
+	s.handleAPI("PUT /api/v1/groups/{name}", s.handleGroupEdit)
+
+	// delete a group, its lists are kept
+	s.handleAPI("DELETE /api/v1/groups/{name}", s.handleGroupDel)
 
 	// add a task
 	// accepts JSON: TaskAdd, returns JSON: {task: Task}
-	s.router.HandleFunc("POST /api/list/{name}", allowCors(s.handleTaskAdd))
+	s.handleAPI("POST /api/v1/list/{name}", s.handleTaskAdd)
+
+	// get a task
+	// returns JSON: {task: Task}
+	s.handleAPI("GET /api/v1/items/{id}", s.handleTaskGet)
+
+	// get the changes of a task, e.g. reassignments
+	// returns JSON: {history: [TaskEvent]}
+	s.handleAPI("GET /api/v1/items/{id}/history", s.handleTaskHistory)
+
+	// hide a task from lists until a time like "1h", "tomorrow" or "next week", an empty time shows it again
+	// accepts JSON: TaskSnooze, returns JSON: {task: Task}
+	s.handleAPI("POST /api/v1/items/{id}/snooze", s.handleTaskSnooze)
+
+	// start tracking time on a task, only one timer may run per user
+	// returns JSON: {entry: TimeEntry}
+	s.handleAPI("POST /api/v1/items/{id}/timer/start", s.handleTimerStart)
+
+	// stop the running timer of the user on a task
+	// returns JSON: {entry: TimeEntry}
+	s.handleAPI("POST /api/v1/items/{id}/timer/stop", s.handleTimerStop)
+
+	// get the time tracked on a task, oldest first
+	// returns JSON: {entries: [TimeEntry]}
+	s.handleAPI("GET /api/v1/items/{id}/time", s.handleTimeGetAll)
+
+	// add time spent on a task without a timer
+	// accepts JSON: TimeEntryAdd, returns JSON: {entry: TimeEntry}
+	s.handleAPI("POST /api/v1/items/{id}/time", s.handleTimePost)
+
+	// delete an own time entry
+	s.handleAPI("DELETE /api/v1/items/{id}/time/{eid}", s.handleTimeDel)
+
+	// sum up the tracked time per list, tag or day, ?from=2006-01-02&to=2006-01-02&group=list|tag|day&format=json|csv
+	// returns JSON: TimeReport or CSV
+	s.handleAPI("GET /api/v1/time/report", s.handleTimeReport)
+
+	// count the created, completed and overdue tasks per day or week, list and priority,
+	// ?from=2006-01-02&to=2006-01-02&interval=day|week&format=json|csv
+	// returns JSON: Stats or CSV
+	s.handleAPI("GET /api/v1/stats", s.handleStats)
+
+	// start a Pomodoro session on a task, the phase changes are sent on the event stream, one session per user
+	// accepts JSON: PomodoroStart, returns JSON: {pomodoro: PomodoroSession}
+	s.handleAPI("POST /api/v1/items/{id}/pomodoro", s.handlePomodoroStart)
+
+	// get the running Pomodoro session of the user
+	// returns JSON: {pomodoro: PomodoroSession or null}
+	s.handleAPI("GET /api/v1/pomodoro", s.handlePomodoroGet)
+
+	// stop the running Pomodoro session of the user, an unfinished focus phase isn't recorded
+	s.handleAPI("DELETE /api/v1/pomodoro", s.handlePomodoroStop)
+
+	// count the completed Pomodoro sessions of the user per day, ?from=2006-01-02&to=2006-01-02
+	// returns JSON: PomodoroStats
+	s.handleAPI("GET /api/v1/pomodoro/stats", s.handlePomodoroStats)
+
+	// get the comments on a task, oldest first
+	// returns JSON: {comments: [Comment]}
+	s.handleAPI("GET /api/v1/items/{id}/comments", s.handleCommentGetAll)
+
+	// comment on a task
+	// accepts JSON: CommentAdd, returns JSON: {comment: Comment}
+	s.handleAPI("POST /api/v1/items/{id}/comments", s.handleCommentPost)
+
+	// change the text of an own comment
+	// accepts JSON: CommentAdd, returns JSON: {comment: Comment}
+	s.handleAPI("PATCH /api/v1/items/{id}/comments/{cid}", s.handleCommentEdit)
+
+	// delete an own comment
+	s.handleAPI("DELETE /api/v1/items/{id}/comments/{cid}", s.handleCommentDel)
+
+	// add a task from a one-line description, e.g. "Call client tomorrow 9am !high #Work"
+	// accepts JSON: {text: string, list: string}, returns JSON: {task: Task}
+	s.handleAPI("POST /api/v1/quickadd", s.handleQuickAdd)
 
 	// delete a task
-	s.router.HandleFunc("DELETE /api/items/{id}", allowCors(s.handleTaskDel))
+	s.handleAPI("DELETE /api/v1/items/{id}", s.handleTaskDel)
 
 	// change a task, e.g. mark item as done
 	// accepts JSON: TaskChange, returns JSON: {task: Task}
-	s.router.HandleFunc("PATCH /api/items/{id}", allowCors(s.handleTaskChange))
+	s.handleAPI("PATCH /api/v1/items/{id}", s.handleTaskChange)
+
+	// export all lists, tasks and filtered lists, ?format=json|csv
+	// returns JSON: Export or CSV
+	s.handleAPI("GET /api/v1/export", s.handleExport)
+
+	// import lists, tasks and filtered lists, ?format=json|csv&strategy=skip|overwrite|duplicate&dry_run=true
+	// accepts JSON: Export or CSV, returns JSON: ImportReport
+	s.handleAPI("POST /api/v1/import", s.handleImport)
+}
+
+// allowCors is a middleware that allows CORS requests from any origin.
+var allowCors = cors.New([]string{"*"}).HandleFunc
+
+// handleAPI registers an API route that requires authentication, see handlePublicAPI.
+func (s *Server) handleAPI(pattern string, handler http.HandlerFunc) {
+	s.handlePublicAPI(pattern, s.requireAuth(handler))
+}
+
+// handlePublicAPI registers an API route allowing CORS requests and records its pattern, so that it can be checked
+// against the OpenAPI document.
+func (s *Server) handlePublicAPI(pattern string, handler http.HandlerFunc) {
+	s.apiRoutes = append(s.apiRoutes, pattern)
+	s.router.HandleFunc(pattern, allowCors(handler))
 }
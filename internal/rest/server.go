@@ -6,31 +6,58 @@ import (
 	"io/fs"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
 	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
 	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/events"
 	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/pomodoro"
+	"github.com/jniewt/gotodo/internal/quickadd"
 	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/webhook"
 )
 
 type Server struct {
-	orga Organiser
+	// orga returns the organiser with the lists of a user
+	orga func(user string) Organiser
+	// auth authenticates API requests, nil disables authentication
+	auth *auth.Store
+	// hooks are the webhooks of the users, nil disables webhooks
+	hooks *webhook.Dispatcher
+	// stream passes changes to the event stream, nil disables it
+	stream *events.Broker
+	// pomodoros runs the Pomodoro sessions of the users, nil disables them
+	pomodoros *pomodoro.Timer
 
 	router   *http.ServeMux
 	staticFS fs.FS
+	// apiRoutes are the patterns of all registered API routes
+	apiRoutes []string
 
 	log *log.Entry
 }
 
-func NewServer(static fs.FS, orga Organiser, logger *log.Entry) *Server {
+// NewServer returns a server for the web UI and the REST API. All API requests except login need an API token or
+// session cookie from authStore, unless it is nil. Requests only see the lists of the organiser orga returns for their
+// user, the user is empty if authentication is disabled. Users can register webhooks with hooks, follow changes on
+// the event stream fed by stream and run Pomodoro sessions with pomodoros, unless they are nil.
+func NewServer(static fs.FS, orga func(user string) Organiser, authStore *auth.Store, hooks *webhook.Dispatcher,
+	stream *events.Broker, pomodoros *pomodoro.Timer, logger *log.Entry) *Server {
 
 	s := &Server{
-		orga:     orga,
-		router:   http.NewServeMux(),
-		staticFS: static,
-		log:      logger,
+		orga:      orga,
+		auth:      authStore,
+		hooks:     hooks,
+		stream:    stream,
+		pomodoros: pomodoros,
+		router:    http.NewServeMux(),
+		staticFS:  static,
+		log:       logger,
 	}
 
 	s.routes()
@@ -41,23 +68,79 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	s.router.ServeHTTP(w, r)
 }
 
-// httpError is a helper that writes an JSON error response to the response writer in a standard way.
+// organiser returns the organiser of the authenticated user of a request.
+func (s *Server) organiser(r *http.Request) Organiser {
+	return s.orga(requestUser(r))
+}
+
+// httpError is a helper that writes an JSON error response to the response writer in a standard way. Known errors,
+// e.g. repository.ErrListNotFound, are sent with their own status code, code is used for all other errors.
 func (s *Server) httpError(w http.ResponseWriter, code int, err error) {
-	w.WriteHeader(code)
+	code, resp := errorResponse(code, err)
 	w.Header().Set("Content-Type", "application/json")
-	_, wErr := w.Write([]byte(`{"error":"` + err.Error() + `"}`))
-	if wErr != nil {
-		// use slog to log the error
+	w.WriteHeader(code)
+	if wErr := json.NewEncoder(w).Encode(resp); wErr != nil {
 		s.log.WithError(wErr).Warn("Failed to write error response")
 	}
 }
 
+// errorResponse returns the status code and the body of the error response for err.
+func errorResponse(code int, err error) (int, api.ErrorResponse) {
+	e := api.Error{Message: err.Error()}
+	var fieldErr *api.FieldError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, repository.ErrListNotFound):
+		code, e.Code = http.StatusNotFound, api.CodeListNotFound
+	case errors.Is(err, repository.ErrTaskNotFound):
+		code, e.Code = http.StatusNotFound, api.CodeTaskNotFound
+	case errors.Is(err, repository.ErrListExists):
+		code, e.Code = http.StatusConflict, api.CodeListExists
+	case errors.Is(err, repository.ErrGroupExists):
+		code, e.Code = http.StatusConflict, api.CodeGroupExists
+	case errors.Is(err, auth.ErrUnauthenticated):
+		code, e.Code = http.StatusUnauthorized, api.CodeUnauthenticated
+	case errors.Is(err, errForbidden), errors.Is(err, repository.ErrForbidden):
+		code, e.Code = http.StatusForbidden, api.CodeForbidden
+	case errors.Is(err, auth.ErrTokenNotFound), errors.Is(err, auth.ErrUserNotFound), errors.Is(err, repository.ErrMemberNotFound),
+		errors.Is(err, repository.ErrCommentNotFound), errors.Is(err, repository.ErrTimeEntryNotFound), errors.Is(err, webhook.ErrNotFound),
+		errors.Is(err, pomodoro.ErrNotRunning), errors.Is(err, repository.ErrGroupNotFound):
+		code, e.Code = http.StatusNotFound, api.CodeNotFound
+	case errors.Is(err, auth.ErrUserExists):
+		code, e.Code = http.StatusConflict, api.CodeUserExists
+	case errors.Is(err, repository.ErrTimerRunning):
+		code, e.Code = http.StatusConflict, api.CodeTimerRunning
+	case errors.Is(err, repository.ErrTimerNotRunning):
+		code, e.Code = http.StatusConflict, api.CodeTimerNotRunning
+	case errors.Is(err, pomodoro.ErrRunning):
+		code, e.Code = http.StatusConflict, api.CodePomodoroRunning
+	case errors.As(err, &fieldErr):
+		code, e.Code = http.StatusBadRequest, api.CodeValidationFailed
+		e.Details = []api.FieldError{*fieldErr}
+	case errors.As(err, &typeErr):
+		code, e.Code = http.StatusBadRequest, api.CodeValidationFailed
+		e.Details = []api.FieldError{{Field: typeErr.Field, Message: "must be of type " + typeErr.Type.String()}}
+	case code == http.StatusNotFound:
+		e.Code = api.CodeNotFound
+	case code >= http.StatusInternalServerError:
+		e.Code = api.CodeInternal
+	default:
+		e.Code = api.CodeBadRequest
+	}
+	return code, api.ErrorResponse{Error: e}
+}
+
 // helper that writes a JSON response to the response writer in a standard way.
 func (s *Server) jsonResponse(w http.ResponseWriter, code int, data interface{}) {
-	w.WriteHeader(code)
-	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	body, err := json.Marshal(data)
+	if err != nil {
 		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(append(body, '\n')); err != nil {
+		s.log.WithError(err).Warn("Failed to write response")
 	}
 }
 
@@ -65,8 +148,9 @@ func (s *Server) handleIndex(w http.ResponseWriter, r *http.Request) {
 	http.ServeFile(w, r, "static/index.html")
 }
 
-func (s *Server) handleListGetAll(w http.ResponseWriter, _ *http.Request) {
-	lists, filtered := s.orga.Lists()
+func (s *Server) handleListGetAll(w http.ResponseWriter, r *http.Request) {
+	orga := s.organiser(r)
+	lists, filtered := orga.Lists()
 
 	type filteredList struct {
 		api.ListResponse
@@ -74,18 +158,20 @@ func (s *Server) handleListGetAll(w http.ResponseWriter, _ *http.Request) {
 	}
 
 	type response struct {
-		Lists         []api.ListResponse `json:"lists"`
-		FilteredLists []filteredList     `json:"filtered_lists"`
+		// Groups name the lists in the groups of the user, Lists has all lists.
+		Groups        []api.GroupResponse `json:"groups"`
+		Lists         []api.ListResponse  `json:"lists"`
+		FilteredLists []filteredList      `json:"filtered_lists"`
 	}
 
 	listsAPI := make([]api.ListResponse, 0, len(lists))
 	for _, l := range lists {
-		listsAPI = append(listsAPI, api.FromList(*l))
+		listsAPI = append(listsAPI, api.FromList(withoutHidden(r, *l)))
 	}
 
 	filteredLists := make([]filteredList, 0, len(filtered))
 	for _, fl := range filtered {
-		tasks, err := s.orga.GetFilteredTasks(fl.Name)
+		tasks, err := orga.GetFilteredTasks(fl.Name)
 		if err != nil {
 			s.httpError(w, http.StatusBadRequest, err)
 			return
@@ -98,7 +184,13 @@ func (s *Server) handleListGetAll(w http.ResponseWriter, _ *http.Request) {
 		filteredLists = append(filteredLists, filteredList{api.FromList(l), true})
 	}
 
-	s.jsonResponse(w, http.StatusOK, response{Lists: listsAPI, FilteredLists: filteredLists})
+	groups := orga.Groups()
+	groupsAPI := make([]api.GroupResponse, 0, len(groups))
+	for _, g := range groups {
+		groupsAPI = append(groupsAPI, api.FromGroup(g))
+	}
+
+	s.jsonResponse(w, http.StatusOK, response{Groups: groupsAPI, Lists: listsAPI, FilteredLists: filteredLists})
 }
 
 func (s *Server) handleListGet(w http.ResponseWriter, r *http.Request) {
@@ -108,13 +200,21 @@ func (s *Server) handleListGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// names ending in .md return the list as Markdown checklist, unless a list with exactly that name exists
+	if md, ok := strings.CutSuffix(name, ".md"); ok {
+		if _, err := s.organiser(r).GetList(name); errors.Is(err, repository.ErrListNotFound) {
+			s.handleListMarkdown(w, r, md)
+			return
+		}
+	}
+
 	type response struct {
 		List     api.ListResponse `json:"list"`
 		Filtered bool             `json:"filtered"`
 	}
-	l, err := s.orga.GetList(name)
+	l, err := s.organiser(r).GetList(name)
 	if err == nil {
-		s.jsonResponse(w, http.StatusOK, response{List: api.FromList(l)})
+		s.jsonResponse(w, http.StatusOK, response{List: api.FromList(withoutHidden(r, l))})
 		return
 	} else if !errors.Is(err, repository.ErrListNotFound) {
 		s.httpError(w, http.StatusInternalServerError, err)
@@ -126,6 +226,22 @@ func (s *Server) handleListGet(w http.ResponseWriter, r *http.Request) {
 	s.handleFilteredGet(w, r)
 }
 
+// withoutHidden returns the list without its snoozed tasks, unless the request asks for them with ?hidden=true.
+// Filtered lists leave them out by themselves, see repository.Repository.GetFilteredTasks.
+func withoutHidden(r *http.Request, l core.List) core.List {
+	if r.URL.Query().Get("hidden") == "true" {
+		return l
+	}
+	items := make([]*core.Task, 0, len(l.Items))
+	for _, t := range l.Items {
+		if !t.IsHidden() {
+			items = append(items, t)
+		}
+	}
+	l.Items = items
+	return l
+}
+
 // handleFilteredGet returns a virtual list containing the tasks matching the given filter. The response looks exactly
 // like a normal list response but has the "filtered" field set to true.
 func (s *Server) handleFilteredGet(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +256,7 @@ func (s *Server) handleFilteredGet(w http.ResponseWriter, r *http.Request) {
 		Filtered bool             `json:"filtered"`
 	}
 
-	tasks, err := s.orga.GetFilteredTasks(name)
+	tasks, err := s.organiser(r).GetFilteredTasks(name)
 	if err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
@@ -163,11 +279,11 @@ func (s *Server) handleListPost(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Name == "" {
-		s.httpError(w, http.StatusBadRequest, errors.New("missing list name"))
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "name", Message: "missing list name"})
 		return
 	}
 	col := core.RGB{R: req.Colour.R, G: req.Colour.G, B: req.Colour.B}
-	l, err := s.orga.AddList(req.Name, col)
+	l, err := s.organiser(r).AddList(req.Name, col)
 	if err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
@@ -194,17 +310,17 @@ func (s *Server) handleListEdit(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if req.Name == "" {
-		s.httpError(w, http.StatusBadRequest, errors.New("missing list name"))
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "name", Message: "missing list name"})
 		return
 	}
 
 	if req.Name != name {
-		s.httpError(w, http.StatusBadRequest, errors.New("renaming lists is not supported"))
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "name", Message: "renaming lists is not supported"})
 		return
 	}
 
 	col := core.RGB{R: req.Colour.R, G: req.Colour.G, B: req.Colour.B}
-	l, err := s.orga.EditList(req.Name, col)
+	l, err := s.organiser(r).EditList(req.Name, col)
 	if err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
@@ -224,7 +340,7 @@ func (s *Server) handleListDel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.orga.DelList(name); err != nil {
+	if err := s.organiser(r).DelList(name); err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -239,7 +355,7 @@ func (s *Server) handleTaskChange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	change, err := s.initTaskChange(id)
+	change, err := s.initTaskChange(r, id)
 	if err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
@@ -251,7 +367,7 @@ func (s *Server) handleTaskChange(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// validate the input
-	if _, err = s.orga.GetTask(id); err != nil {
+	if _, err = s.organiser(r).GetTask(id); err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -260,7 +376,7 @@ func (s *Server) handleTaskChange(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	res, err := s.orga.UpdateTask(id, change)
+	res, err := s.organiser(r).UpdateTask(id, change)
 	if err != nil {
 		s.httpError(w, http.StatusInternalServerError, err)
 		return
@@ -277,22 +393,13 @@ func (s *Server) handleTaskChange(w http.ResponseWriter, r *http.Request) {
 }
 
 // initTaskChange initializes a TaskChange struct from the existing task with the given ID.
-func (s *Server) initTaskChange(id int) (api.TaskChange, error) {
-	t, err := s.orga.GetTask(id)
+func (s *Server) initTaskChange(r *http.Request, id int) (api.TaskChange, error) {
+	t, err := s.organiser(r).GetTask(id)
 	if err != nil {
 		return api.TaskChange{}, err
 	}
 
-	change := api.TaskChange{
-		Title:   t.Title,
-		Done:    t.Done,
-		List:    t.List,
-		AllDay:  t.AllDay,
-		Due:     t.Due,
-		DueType: t.DueType,
-	}
-
-	return change, nil
+	return api.NewTaskChange(t), nil
 }
 
 func (s *Server) handleTaskAdd(w http.ResponseWriter, r *http.Request) {
@@ -308,7 +415,7 @@ func (s *Server) handleTaskAdd(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	t, err := s.orga.AddItem(list, request)
+	t, err := s.organiser(r).AddItem(list, request)
 	if err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
@@ -321,6 +428,79 @@ func (s *Server) handleTaskAdd(w http.ResponseWriter, r *http.Request) {
 	s.jsonResponse(w, http.StatusCreated, resp)
 }
 
+func (s *Server) handleTaskGet(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := s.organiser(r).GetTask(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Task api.TaskResponse `json:"task"`
+	}{Task: api.FromTask(t)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleTaskHistory returns the changes of a task, oldest first.
+func (s *Server) handleTaskHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := s.organiser(r).GetTask(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		History []api.TaskEvent `json:"history"`
+	}{History: api.FromHistory(t)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleTaskSnooze hides a task until a time like "1h", "tomorrow" or "next week", resolved in the server's time zone.
+func (s *Server) handleTaskSnooze(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req api.TaskSnooze
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	until, err := quickadd.ParseSnooze(req.Until, time.Now())
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "until", Message: err.Error()})
+		return
+	}
+
+	t, err := s.organiser(r).SnoozeTask(id, until)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Task api.TaskResponse `json:"task"`
+	}{Task: api.FromTask(t)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
 func (s *Server) handleTaskDel(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.Atoi(r.PathValue("id"))
 	if err != nil {
@@ -328,7 +508,7 @@ func (s *Server) handleTaskDel(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := s.orga.DelItem(id); err != nil {
+	if err := s.organiser(r).DelItem(id); err != nil {
 		s.httpError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -342,10 +522,31 @@ type Organiser interface {
 	AddList(name string, col core.RGB) (core.List, error)
 	EditList(name string, col core.RGB) (core.List, error) // renaming list is not supported
 	DelList(name string) error
+	ShareList(name, user string, role core.Role) (core.List, error)
+	UnshareList(name, user string) (core.List, error)
+	SetStatuses(name string, statuses []string) (core.List, error)
+	SetArchiveAfter(name string, days int) (core.List, error)
+	Archived(list string, from, to time.Time) ([]core.Task, error)
+	RestoreTask(id int) (core.Task, error)
+	Groups() []core.Group
+	AddGroup(group api.GroupAdd) (core.Group, error)
+	EditGroup(name string, group api.GroupAdd) (core.Group, error) // renaming groups is not supported
+	DelGroup(name string) error
+	OrderGroups(names []string) ([]core.Group, error)
 	AddItem(list string, item api.TaskAdd) (core.Task, error)
 	DelItem(id int) error
 	MarkDone(taskID int, done bool) (core.Task, error)
 	GetTask(id int) (core.Task, error)
 	GetFilteredTasks(name string) ([]*core.Task, error)
 	UpdateTask(id int, request api.TaskChange) (core.Task, error)
+	SnoozeTask(id int, until time.Time) (core.Task, error)
+	AddComment(taskID int, text string) (core.Comment, error)
+	EditComment(taskID, commentID int, text string) (core.Comment, error)
+	DelComment(taskID, commentID int) error
+	StartTimer(taskID int) (core.TimeEntry, error)
+	StopTimer(taskID int) (core.TimeEntry, error)
+	AddTimeEntry(taskID int, entry api.TimeEntryAdd) (core.TimeEntry, error)
+	DelTimeEntry(taskID, entryID int) error
+	Import(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) (api.ImportReport, error)
+	PreviewImport(lists []*core.List, filtered []*filter.List, strategy api.MergeStrategy) api.ImportReport
 }
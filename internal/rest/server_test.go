@@ -0,0 +1,54 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+func TestErrorResponse(t *testing.T) {
+	var typeErr *json.UnmarshalTypeError
+	err := json.Unmarshal([]byte(`{"name": 1}`), &api.ListAdd{})
+	if !errors.As(err, &typeErr) {
+		t.Fatalf("expected UnmarshalTypeError, got %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		code       int
+		err        error
+		wantCode   int
+		wantErr    api.ErrorCode
+		wantDetail string
+	}{
+		{"List not found", http.StatusBadRequest, repository.ErrListNotFound, http.StatusNotFound, api.CodeListNotFound, ""},
+		{"Wrapped list not found", http.StatusInternalServerError, fmt.Errorf("move: %w", repository.ErrListNotFound), http.StatusNotFound, api.CodeListNotFound, ""},
+		{"Task not found", http.StatusBadRequest, repository.ErrTaskNotFound, http.StatusNotFound, api.CodeTaskNotFound, ""},
+		{"List exists", http.StatusBadRequest, repository.ErrListExists, http.StatusConflict, api.CodeListExists, ""},
+		{"Field error", http.StatusBadRequest, &api.FieldError{Field: "title", Message: "missing task title"}, http.StatusBadRequest, api.CodeValidationFailed, "title"},
+		{"Type error", http.StatusBadRequest, err, http.StatusBadRequest, api.CodeValidationFailed, "name"},
+		{"Other client error", http.StatusBadRequest, errors.New("bad"), http.StatusBadRequest, api.CodeBadRequest, ""},
+		{"Other not found", http.StatusNotFound, errors.New("gone"), http.StatusNotFound, api.CodeNotFound, ""},
+		{"Server error", http.StatusInternalServerError, errors.New("disk full"), http.StatusInternalServerError, api.CodeInternal, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := errorResponse(tt.code, tt.err)
+			if code != tt.wantCode || resp.Error.Code != tt.wantErr {
+				t.Errorf("errorResponse() = %d %s, want %d %s", code, resp.Error.Code, tt.wantCode, tt.wantErr)
+			}
+			if resp.Error.Message != tt.err.Error() {
+				t.Errorf("message = %q, want %q", resp.Error.Message, tt.err.Error())
+			}
+			if tt.wantDetail != "" && (len(resp.Error.Details) != 1 || resp.Error.Details[0].Field != tt.wantDetail) {
+				t.Errorf("details = %+v, want field %s", resp.Error.Details, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package rest
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+)
+
+// handleListShare gives a user a role in a list.
+func (s *Server) handleListShare(w http.ResponseWriter, r *http.Request) {
+	name, user := r.PathValue("name"), r.PathValue("user")
+
+	var req api.ListShare
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	role, err := core.ParseRole(req.Role)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "role", Message: err.Error()})
+		return
+	}
+	if s.auth != nil {
+		if _, err = s.auth.GetUser(user); err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	l, err := s.organiser(r).ShareList(name, user, role)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		List api.ListResponse `json:"list"`
+	}{List: api.FromList(l)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleListUnshare removes a user from the members of a list.
+func (s *Server) handleListUnshare(w http.ResponseWriter, r *http.Request) {
+	if _, err := s.organiser(r).UnshareList(r.PathValue("name"), r.PathValue("user")); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
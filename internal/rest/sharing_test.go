@@ -0,0 +1,157 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestSharedLists(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+	alice, bob, carol := headers["alice"], headers["bob"], headers["carol"]
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Release checklist"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Release checklist"}`, carol); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	w := do(http.MethodPost, "/api/v1/list/Release%20checklist", `{"title": "Tag release"}`, alice)
+	var resp struct {
+		Task struct {
+			ID int `json:"id"`
+		} `json:"task"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	task := "/api/v1/items/" + strconv.Itoa(resp.Task.ID)
+	list := "/api/v1/list/Release%20checklist"
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header http.Header
+		want   int
+	}{
+		{"Private before sharing", http.MethodGet, list, "", bob, http.StatusNotFound},
+		{"Invalid role", http.MethodPut, list + "/members/bob", `{"role": "admin"}`, alice, http.StatusBadRequest},
+		{"Unknown user", http.MethodPut, list + "/members/dave", `{"role": "viewer"}`, alice, http.StatusNotFound},
+		{"Name clash", http.MethodPut, list + "/members/carol", `{"role": "viewer"}`, alice, http.StatusConflict},
+		{"Share as viewer", http.MethodPut, list + "/members/bob", `{"role": "viewer"}`, alice, http.StatusOK},
+		{"Viewer sees list", http.MethodGet, list, "", bob, http.StatusOK},
+		{"Viewer sees task", http.MethodGet, task, "", bob, http.StatusOK},
+		{"Viewer can't add tasks", http.MethodPost, list, `{"title": "Write notes"}`, bob, http.StatusForbidden},
+		{"Viewer can't change tasks", http.MethodPatch, task, `{"done": true}`, bob, http.StatusForbidden},
+		{"Viewer can't delete tasks", http.MethodDelete, task, "", bob, http.StatusForbidden},
+		{"Make editor", http.MethodPut, list + "/members/bob", `{"role": "editor"}`, alice, http.StatusOK},
+		{"Editor adds tasks", http.MethodPost, list, `{"title": "Write notes"}`, bob, http.StatusCreated},
+		{"Editor changes tasks", http.MethodPatch, task, `{"done": true}`, bob, http.StatusAccepted},
+		{"Editor can't move tasks to private lists", http.MethodPatch, task, `{"list": "Inbox"}`, bob, http.StatusNotFound},
+		{"Editor can't change list", http.MethodPatch, list, `{"name": "Release checklist", "colour": {"r": 1}}`, bob, http.StatusForbidden},
+		{"Editor can't share", http.MethodPut, list + "/members/carol", `{"role": "viewer"}`, bob, http.StatusForbidden},
+		{"Editor can't delete list", http.MethodDelete, list, "", bob, http.StatusForbidden},
+		{"Owner role can't change", http.MethodPut, list + "/members/alice", `{"role": "viewer"}`, alice, http.StatusBadRequest},
+		{"Member leaves", http.MethodDelete, list + "/members/bob", "", bob, http.StatusNoContent},
+		{"Private after leaving", http.MethodGet, task, "", bob, http.StatusNotFound},
+		{"Not a member", http.MethodDelete, list + "/members/bob", "", alice, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, tt.header); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestAssignees(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob", "carol"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+	alice, bob := headers["alice"], headers["bob"]
+
+	list := "/api/v1/list/Release"
+	_ = do(http.MethodPost, "/api/v1/list", `{"name": "Release"}`, alice)
+	_ = do(http.MethodPut, list+"/members/bob", `{"role": "editor"}`, alice)
+	w := do(http.MethodPost, list, `{"title": "Tag release"}`, alice)
+	var resp struct {
+		Task struct {
+			ID int `json:"id"`
+		} `json:"task"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	task := "/api/v1/items/" + strconv.Itoa(resp.Task.ID)
+
+	if w = do(http.MethodPatch, task, `{"assignee": "carol"}`, alice); w.Code != http.StatusBadRequest {
+		t.Errorf("assign to user without access: status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if w = do(http.MethodPatch, task, `{"assignee": "bob"}`, alice); w.Code != http.StatusAccepted {
+		t.Fatalf("assign: status = %d: %s", w.Code, w.Body)
+	}
+
+	w = do(http.MethodGet, task+"/history", "", bob)
+	var history struct {
+		History []struct {
+			User  string `json:"user"`
+			Field string `json:"field"`
+			To    string `json:"to"`
+		} `json:"history"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&history)
+	if h := history.History; len(h) != 1 || h[0].Field != "assignee" || h[0].User != "alice" || h[0].To != "bob" {
+		t.Errorf("history = %+v, want reassignment to bob by alice", h)
+	}
+
+	// a filtered list with assignee "me" shows bob the task in alice's list
+	export := `{"version": 1, "lists": [], "filtered_lists": [{"name": "Mine", "rule_sets": [[{"field": "assignee", "value": "me"}]]}]}`
+	for _, h := range []http.Header{alice, bob} {
+		if w = do(http.MethodPost, "/api/v1/import", export, h); w.Code != http.StatusOK {
+			t.Fatalf("import status = %d: %s", w.Code, w.Body)
+		}
+	}
+	tests := []struct {
+		name   string
+		header http.Header
+		want   int
+	}{
+		{"Assignee", bob, 1},
+		{"Other user", alice, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got struct {
+				List struct {
+					Items []json.RawMessage `json:"items"`
+				} `json:"list"`
+			}
+			_ = json.NewDecoder(do(http.MethodGet, "/api/v1/list/Mine", "", tt.header).Body).Decode(&got)
+			if len(got.List.Items) != tt.want {
+				t.Errorf("tasks = %d, want %d", len(got.List.Items), tt.want)
+			}
+		})
+	}
+}
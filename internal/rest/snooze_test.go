@@ -0,0 +1,81 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestSnooze(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+	alice, bob := headers["alice"], headers["bob"]
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Work"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPut, "/api/v1/list/Work/members/bob", `{"role": "viewer"}`, alice); w.Code != http.StatusOK {
+		t.Fatalf("share status = %d: %s", w.Code, w.Body)
+	}
+	w := do(http.MethodPost, "/api/v1/list/Work", `{"title": "Someday project"}`, alice)
+	var resp struct {
+		Task struct {
+			ID int `json:"id"`
+		} `json:"task"`
+	}
+	_ = json.NewDecoder(w.Body).Decode(&resp)
+	snooze := "/api/v1/items/" + strconv.Itoa(resp.Task.ID) + "/snooze"
+
+	tests := []struct {
+		name      string
+		body      string
+		header    http.Header
+		want      int
+		wantItems int
+	}{
+		{"Invalid time", `{"until": "someday"}`, alice, http.StatusBadRequest, 1},
+		{"Viewers can't snooze", `{"until": "1h"}`, bob, http.StatusForbidden, 1},
+		{"Snooze", `{"until": "next week"}`, alice, http.StatusOK, 0},
+		{"Wake up", `{"until": ""}`, alice, http.StatusOK, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(http.MethodPost, snooze, tt.body, tt.header); w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if got := countItems(t, do(http.MethodGet, "/api/v1/list/Work", "", bob).Body); got != tt.wantItems {
+				t.Errorf("list has %d tasks, want %d", got, tt.wantItems)
+			}
+			if got := countItems(t, do(http.MethodGet, "/api/v1/list/Work?hidden=true", "", bob).Body); got != 1 {
+				t.Errorf("list with hidden tasks has %d tasks, want 1", got)
+			}
+		})
+	}
+}
+
+func countItems(t *testing.T, body io.Reader) int {
+	var resp struct {
+		List struct {
+			Items []json.RawMessage `json:"items"`
+		} `json:"list"`
+	}
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		t.Fatal(err)
+	}
+	return len(resp.List.Items)
+}
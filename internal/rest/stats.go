@@ -0,0 +1,42 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+// handleStats makes the productivity statistics of the tasks the user can see, archived ones included, from the "from"
+// until the "to" date including, by default in the last 30 days, per "interval". They are returned as JSON or, with
+// "format=csv", as CSV.
+func (s *Server) handleStats(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	from, to, err := parseDateRange(r, today.AddDate(0, 0, -29), today)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	stats, err := api.NewStats(reportLists(s.organiser(r)), from, to.AddDate(0, 0, 1), r.URL.Query().Get("interval"), now)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		s.jsonResponse(w, http.StatusOK, stats)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="gotasks-stats.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err = stats.WriteCSV(w); err != nil {
+			s.log.WithError(err).Warn("Failed to write CSV statistics")
+		}
+	default:
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "format", Message: fmt.Sprintf("invalid format %q", format)})
+	}
+}
@@ -0,0 +1,71 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestStats(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	_, token, _ = store.CreateToken("bob", "test")
+	bob := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+
+	for _, req := range []struct {
+		path, body string
+		user       http.Header
+	}{
+		{"/api/v1/list", `{"name": "Work"}`, alice},
+		{"/api/v1/list/Work", `{"title": "Report", "priority": 1}`, alice},
+		{"/api/v1/list/Work", `{"title": "Slides", "due_type": "due_by", "due": "2020-01-01T10:00"}`, alice},
+		{"/api/v1/list", `{"name": "Private"}`, bob},
+		{"/api/v1/list/Private", `{"title": "Secret"}`, bob},
+	} {
+		if w := do(http.MethodPost, req.path, req.body, req.user); w.Code != http.StatusCreated {
+			t.Fatalf("POST %s status = %d: %s", req.path, w.Code, w.Body)
+		}
+	}
+
+	w := do(http.MethodGet, "/api/v1/stats", "", alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("stats status = %d: %s", w.Code, w.Body)
+	}
+	var stats api.Stats
+	if err := json.NewDecoder(w.Body).Decode(&stats); err != nil {
+		t.Fatal(err)
+	}
+	if len(stats.Periods) != 30 || stats.Periods[29].Created != 2 {
+		t.Errorf("periods = %+v, want 30 days with 2 tasks created today", stats.Periods)
+	}
+	if stats.Total.Created != 2 || stats.Total.Open != 2 || stats.Total.Overdue != 1 {
+		t.Errorf("total = %+v, want 2 open tasks of alice, 1 overdue", stats.Total)
+	}
+	if len(stats.Lists) != 1 || stats.Lists[0].Key != "Work" || len(stats.Priorities) != 2 {
+		t.Errorf("lists = %+v, priorities = %+v", stats.Lists, stats.Priorities)
+	}
+
+	w = do(http.MethodGet, "/api/v1/stats?interval=week&format=csv", "", alice)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "kind,key,created,") || !strings.Contains(w.Body.String(), "\nlist,Work,2,0,0,2,1,0.00\n") {
+		t.Errorf("CSV stats status = %d:\n%s", w.Code, w.Body)
+	}
+	for _, query := range []string{"?interval=month", "?to=today", "?from=2026-10-20&to=2026-10-19", "?format=xml"} {
+		if w := do(http.MethodGet, "/api/v1/stats"+query, "", alice); w.Code != http.StatusBadRequest {
+			t.Errorf("stats%s status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package rest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jniewt/gotodo/api"
+)
+
+func (s *Server) handleTimerStart(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	e, err := s.organiser(r).StartTimer(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Entry api.TimeEntryResponse `json:"entry"`
+	}{Entry: api.FromTimeEntry(e, time.Now())}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleTimerStop(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	e, err := s.organiser(r).StopTimer(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Entry api.TimeEntryResponse `json:"entry"`
+	}{Entry: api.FromTimeEntry(e, time.Now())}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTimeGetAll(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	t, err := s.organiser(r).GetTask(id)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	now := time.Now()
+	resp := struct {
+		Entries []api.TimeEntryResponse `json:"entries"`
+	}{Entries: make([]api.TimeEntryResponse, 0, len(t.TimeEntries))}
+	for _, e := range t.TimeEntries {
+		resp.Entries = append(resp.Entries, api.FromTimeEntry(e, now))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleTimePost(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var req api.TimeEntryAdd
+	if err = json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	e, err := s.organiser(r).AddTimeEntry(id, req)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	resp := struct {
+		Entry api.TimeEntryResponse `json:"entry"`
+	}{Entry: api.FromTimeEntry(e, time.Now())}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleTimeDel(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	eid, err := strconv.Atoi(r.PathValue("eid"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.organiser(r).DelTimeEntry(id, eid); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleTimeReport sums up the time tracked on the tasks the user can see, archived ones included, from the "from" until
+// the "to" date including, by default in the current month. The rows are grouped by "group" and returned as JSON or, with
+// "format=csv", as CSV.
+func (s *Server) handleTimeReport(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from, to, err := parseDateRange(r, time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local),
+		time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	report, err := api.NewTimeReport(reportLists(s.organiser(r)), from, to.AddDate(0, 0, 1), r.URL.Query().Get("group"), now)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		s.jsonResponse(w, http.StatusOK, report)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="gotasks-time.csv"`)
+		w.WriteHeader(http.StatusOK)
+		if err = report.WriteCSV(w); err != nil {
+			s.log.WithError(err).Warn("Failed to write CSV time report")
+		}
+	default:
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "format", Message: fmt.Sprintf("invalid format %q", format)})
+	}
+}
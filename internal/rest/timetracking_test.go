@@ -0,0 +1,128 @@
+package rest
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestTimeTracking(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	_ = store.AddUser("alice", "password123")
+	_ = store.AddUser("bob", "password123")
+	_, token, _ := store.CreateToken("alice", "test")
+	alice := http.Header{"Authorization": {"Bearer " + token}}
+	_, token, _ = store.CreateToken("bob", "test")
+	bob := http.Header{"Authorization": {"Bearer " + token}}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+
+	if w := do(http.MethodPost, "/api/v1/list", `{"name": "Client"}`, alice); w.Code != http.StatusCreated {
+		t.Fatalf("add list status = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPut, "/api/v1/list/Client/members/bob", `{"role": "viewer"}`, alice); w.Code != http.StatusOK {
+		t.Fatalf("share status = %d: %s", w.Code, w.Body)
+	}
+	var ids []string
+	for _, body := range []string{`{"title": "Design", "estimate": "4h"}`, `{"title": "Build"}`} {
+		w := do(http.MethodPost, "/api/v1/list/Client", body, alice)
+		if w.Code != http.StatusCreated {
+			t.Fatalf("add task status = %d: %s", w.Code, w.Body)
+		}
+		ids = append(ids, strconv.Itoa(decodeTask(t, w.Body).ID))
+	}
+	design, build := "/api/v1/items/"+ids[0], "/api/v1/items/"+ids[1]
+
+	start := time.Now().Add(-3 * time.Hour).Format(time.RFC3339)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"Invalid estimate", http.MethodPatch, build, `{"estimate": "soon"}`, http.StatusBadRequest},
+		{"Estimate", http.MethodPatch, build, `{"estimate": "1h30m"}`, http.StatusAccepted},
+		{"Invalid tag", http.MethodPatch, build, `{"tags": ["a,b"]}`, http.StatusBadRequest},
+		{"Tags", http.MethodPatch, build, `{"tags": ["acme", "billable"]}`, http.StatusAccepted},
+		{"Start", http.MethodPost, design + "/timer/start", "", http.StatusCreated},
+		{"Second timer", http.MethodPost, build + "/timer/start", "", http.StatusConflict},
+		{"Stop other task", http.MethodPost, build + "/timer/stop", "", http.StatusConflict},
+		{"Stop", http.MethodPost, design + "/timer/stop", "", http.StatusOK},
+		{"Stop again", http.MethodPost, design + "/timer/stop", "", http.StatusConflict},
+		{"Manual entry", http.MethodPost, build + "/time", `{"start": "` + start + `", "duration": "1h30m", "note": "pairing"}`, http.StatusCreated},
+		{"Invalid duration", http.MethodPost, build + "/time", `{"duration": "-1h"}`, http.StatusBadRequest},
+		{"Unknown entry", http.MethodDelete, build + "/time/9", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, alice); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+
+	// viewers see the tracked time but can't track any
+	for _, req := range []struct{ method, path, body string }{
+		{http.MethodPost, design + "/timer/start", ""},
+		{http.MethodPost, build + "/time", `{"duration": "1h"}`},
+	} {
+		if w := do(req.method, req.path, req.body, bob); w.Code != http.StatusForbidden {
+			t.Errorf("%s %s by viewer status = %d, want %d", req.method, req.path, w.Code, http.StatusForbidden)
+		}
+	}
+
+	task := decodeTask(t, do(http.MethodGet, build, "", alice).Body)
+	if task.Estimate != "1h30m0s" || task.Tracked != "1h30m0s" {
+		t.Errorf("estimate = %q, tracked = %q, want 1h30m0s", task.Estimate, task.Tracked)
+	}
+	var entries struct {
+		Entries []api.TimeEntryResponse `json:"entries"`
+	}
+	if err := json.NewDecoder(do(http.MethodGet, design+"/time", "", alice).Body).Decode(&entries); err != nil {
+		t.Fatal(err)
+	}
+	if len(entries.Entries) != 1 || entries.Entries[0].Running || entries.Entries[0].User != "alice" {
+		t.Errorf("entries = %+v, want one stopped entry of alice", entries.Entries)
+	}
+
+	// the manual entry may have started yesterday
+	from := "?from=" + time.Now().AddDate(0, 0, -1).Format("2006-01-02")
+	w := do(http.MethodGet, "/api/v1/time/report"+from+"&group=tag", "", alice)
+	if w.Code != http.StatusOK {
+		t.Fatalf("report status = %d: %s", w.Code, w.Body)
+	}
+	var report api.TimeReport
+	if err := json.NewDecoder(w.Body).Decode(&report); err != nil {
+		t.Fatal(err)
+	}
+	// the time on Build counts for both of its tags, the timer on Design ran for milliseconds without a tag
+	hours := make(map[string]float64)
+	for _, row := range report.Rows {
+		hours[row.Key] = row.Hours
+	}
+	if hours["acme"] != 1.5 || hours["billable"] != 1.5 || hours[""] != 0 || report.Total.Hours != 1.5 {
+		t.Errorf("report = %+v, want 1.5 hours each of acme and billable", report)
+	}
+
+	w = do(http.MethodGet, "/api/v1/time/report"+from+"&format=csv", "", alice)
+	if w.Code != http.StatusOK || !strings.HasPrefix(w.Body.String(), "list,duration,hours\nClient,1h30m0s,1.50\ntotal,") {
+		t.Errorf("CSV report status = %d:\n%s", w.Code, w.Body)
+	}
+	for _, query := range []string{"?group=user", "?from=yesterday", "?from=2026-10-20&to=2026-10-19"} {
+		if w := do(http.MethodGet, "/api/v1/time/report"+query, "", alice); w.Code != http.StatusBadRequest {
+			t.Errorf("report%s status = %d, want %d", query, w.Code, http.StatusBadRequest)
+		}
+	}
+}
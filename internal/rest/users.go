@@ -0,0 +1,194 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+var errForbidden = errors.New("admin role required")
+
+// requireAdmin only calls handler for requests of admin users, all other requests get 403. It must be used within
+// requireAuth.
+func (s *Server) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil {
+			s.httpError(w, http.StatusNotFound, errAuthDisabled)
+			return
+		}
+		u, err := s.auth.GetUser(requestUser(r))
+		if err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+		if !u.Admin {
+			s.httpError(w, http.StatusForbidden, errForbidden)
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// handleMe returns the authenticated user of the request.
+func (s *Server) handleMe(w http.ResponseWriter, r *http.Request) {
+	if s.auth == nil {
+		s.httpError(w, http.StatusNotFound, errAuthDisabled)
+		return
+	}
+
+	u, err := s.auth.GetUser(requestUser(r))
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		User api.UserResponse `json:"user"`
+	}{User: userResponse(u)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUserGetAll(w http.ResponseWriter, _ *http.Request) {
+	users, err := s.auth.Users()
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Users []api.UserResponse `json:"users"`
+	}{Users: make([]api.UserResponse, 0, len(users))}
+	for _, u := range users {
+		resp.Users = append(resp.Users, userResponse(u))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleUserPost(w http.ResponseWriter, r *http.Request) {
+	var req api.UserAdd
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Name == "" {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "name", Message: "missing user name"})
+		return
+	}
+
+	if err := s.auth.AddUser(req.Name, req.Password); err != nil {
+		s.httpError(w, http.StatusBadRequest, passwordError(err))
+		return
+	}
+	if req.Admin {
+		if err := s.auth.SetAdmin(req.Name, true); err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.log.WithFields(log.Fields{"user": req.Name, "by": requestUser(r)}).Info("User added.")
+
+	resp := struct {
+		User api.UserResponse `json:"user"`
+	}{User: api.UserResponse{Name: req.Name, Admin: req.Admin}}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+// handleUserChange changes the password or the admin role of a user. Admins can't revoke their own admin role, so that
+// there is always an admin left.
+func (s *Server) handleUserChange(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+
+	var req api.UserChange
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	if _, err := s.auth.GetUser(name); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	if req.Admin != nil && !*req.Admin && name == requestUser(r) {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "admin", Message: "can't revoke own admin role"})
+		return
+	}
+	if req.Password != nil {
+		if err := s.auth.SetPassword(name, *req.Password); err != nil {
+			s.httpError(w, http.StatusBadRequest, passwordError(err))
+			return
+		}
+	}
+	if req.Admin != nil {
+		if err := s.auth.SetAdmin(name, *req.Admin); err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	u, err := s.auth.GetUser(name)
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		User api.UserResponse `json:"user"`
+	}{User: userResponse(u)}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// userDeleter is implemented by organisers that can remove the data of a deleted user, see repository.DeleteUser.
+type userDeleter interface {
+	DeleteUser(user string) error
+}
+
+// handleUserDel deletes a user with their API tokens, sessions, lists, shares, groups and webhooks, so a new user with
+// the same name starts afresh.
+func (s *Server) handleUserDel(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == requestUser(r) {
+		s.httpError(w, http.StatusBadRequest, errors.New("can't delete own user"))
+		return
+	}
+
+	if err := s.auth.DeleteUser(name); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+	if d, ok := s.orga("").(userDeleter); ok {
+		if err := d.DeleteUser(name); err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	if s.hooks != nil {
+		if err := s.hooks.DeleteHooks(name); err != nil {
+			s.httpError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+	s.log.WithFields(log.Fields{"user": name, "by": requestUser(r)}).Info("User deleted.")
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// passwordError reports a weak password as invalid field.
+func passwordError(err error) error {
+	if errors.Is(err, auth.ErrWeakPassword) {
+		return &api.FieldError{Field: "password", Message: err.Error()}
+	}
+	return err
+}
+
+func userResponse(u auth.User) api.UserResponse {
+	return api.UserResponse{Name: u.Name, Admin: u.Admin}
+}
@@ -0,0 +1,138 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/webhook"
+)
+
+var errWebhooksDisabled = errors.New("webhooks are disabled")
+
+func (s *Server) handleWebhookGetAll(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		s.httpError(w, http.StatusNotFound, errWebhooksDisabled)
+		return
+	}
+
+	hooks, err := s.hooks.Hooks(requestUser(r))
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Webhooks []api.WebhookResponse `json:"webhooks"`
+	}{Webhooks: make([]api.WebhookResponse, 0, len(hooks))}
+	for _, h := range hooks {
+		resp.Webhooks = append(resp.Webhooks, webhookResponse(h))
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+// handleWebhookPost registers a webhook for the user of the request. The secret is only included in this response.
+func (s *Server) handleWebhookPost(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		s.httpError(w, http.StatusNotFound, errWebhooksDisabled)
+		return
+	}
+
+	var req api.WebhookAdd
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+	f, err := api.NewFilter(req.RuleSets)
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, &api.FieldError{Field: "rule_sets", Message: err.Error()})
+		return
+	}
+	h := webhook.Hook{User: requestUser(r), URL: req.URL, Secret: req.Secret, Filter: f}
+	for _, e := range req.Events {
+		h.Events = append(h.Events, repository.ChangeType(e))
+	}
+
+	if h, err = s.hooks.AddHook(h); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Webhook api.WebhookResponse `json:"webhook"`
+		Secret  string              `json:"secret"`
+	}{Webhook: webhookResponse(h), Secret: h.Secret}
+
+	s.jsonResponse(w, http.StatusCreated, resp)
+}
+
+func (s *Server) handleWebhookDel(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		s.httpError(w, http.StatusNotFound, errWebhooksDisabled)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err = s.hooks.DeleteHook(requestUser(r), id); err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) handleWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	if s.hooks == nil {
+		s.httpError(w, http.StatusNotFound, errWebhooksDisabled)
+		return
+	}
+
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		s.httpError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	deliveries, err := s.hooks.Deliveries(requestUser(r), id)
+	if err != nil {
+		s.httpError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := struct {
+		Deliveries []api.WebhookDelivery `json:"deliveries"`
+	}{Deliveries: make([]api.WebhookDelivery, 0, len(deliveries))}
+	for _, d := range deliveries {
+		resp.Deliveries = append(resp.Deliveries, api.WebhookDelivery{
+			ID:         d.ID,
+			Event:      string(d.Event),
+			Time:       d.Time,
+			Attempts:   d.Attempts,
+			State:      string(d.State),
+			StatusCode: d.StatusCode,
+			Error:      d.Error,
+		})
+	}
+
+	s.jsonResponse(w, http.StatusOK, resp)
+}
+
+func webhookResponse(h webhook.Hook) api.WebhookResponse {
+	resp := api.WebhookResponse{ID: h.ID, URL: h.URL, Events: make([]string, 0, len(h.Events)), Created: h.Created}
+	for _, e := range h.Events {
+		resp.Events = append(resp.Events, string(e))
+	}
+	if len(h.Filter.RuleSets) > 0 {
+		resp.RuleSets = api.FromFilter(h.Filter)
+	}
+	return resp
+}
@@ -0,0 +1,52 @@
+package rest
+
+import (
+	"io"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+func TestWebhooks(t *testing.T) {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	store, _ := auth.NewStore("")
+	headers := make(map[string]http.Header)
+	for _, name := range []string{"alice", "bob"} {
+		_ = store.AddUser(name, "password123")
+		_, token, _ := store.CreateToken(name, "test")
+		headers[name] = http.Header{"Authorization": {"Bearer " + token}}
+	}
+	do := requester(newAuthServer(fstest.MapFS{}, store, logger))
+	alice, bob := headers["alice"], headers["bob"]
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		header http.Header
+		want   int
+	}{
+		{"Invalid URL", http.MethodPost, "/api/v1/webhooks", `{"url": "chat.example.com"}`, alice, http.StatusBadRequest},
+		{"Metadata URL", http.MethodPost, "/api/v1/webhooks", `{"url": "http://169.254.169.254/latest/meta-data"}`, alice, http.StatusBadRequest},
+		{"Unknown event", http.MethodPost, "/api/v1/webhooks", `{"url": "https://chat.example.com", "events": ["task.moved"]}`, alice, http.StatusBadRequest},
+		{"Invalid filter", http.MethodPost, "/api/v1/webhooks", `{"url": "https://chat.example.com", "rule_sets": [[{"field": "nope"}]]}`, alice, http.StatusBadRequest},
+		{"Add webhook", http.MethodPost, "/api/v1/webhooks", `{"url": "https://chat.example.com", "events": ["task.completed"]}`, alice, http.StatusCreated},
+		{"Deliveries", http.MethodGet, "/api/v1/webhooks/1/deliveries", "", alice, http.StatusOK},
+		{"Others' deliveries", http.MethodGet, "/api/v1/webhooks/1/deliveries", "", bob, http.StatusNotFound},
+		{"Delete others' webhook", http.MethodDelete, "/api/v1/webhooks/1", "", bob, http.StatusNotFound},
+		{"Delete webhook", http.MethodDelete, "/api/v1/webhooks/1", "", alice, http.StatusNoContent},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := do(tt.method, tt.path, tt.body, tt.header); w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
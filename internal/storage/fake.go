@@ -11,11 +11,13 @@ import (
 type Fake struct {
 	Lists    []*core.List
 	Filtered []*filter.List
+	Archive  []*core.List
+	Groups   []*core.Group
 }
 
-func (f *Fake) GetList(name string) (*core.List, error) {
+func (f *Fake) GetList(owner, name string) (*core.List, error) {
 	for _, l := range f.Lists {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			return l, nil
 		}
 	}
@@ -31,9 +33,9 @@ func (f *Fake) AddList(list *core.List) error {
 	return nil
 }
 
-func (f *Fake) UpdateList(name string, list *core.List) error {
+func (f *Fake) UpdateList(owner, name string, list *core.List) error {
 	for i, l := range f.Lists {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			f.Lists[i] = list
 			return nil
 		}
@@ -41,9 +43,9 @@ func (f *Fake) UpdateList(name string, list *core.List) error {
 	return errors.New("list not found")
 }
 
-func (f *Fake) DeleteList(name string) error {
+func (f *Fake) DeleteList(owner, name string) error {
 	for i, l := range f.Lists {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			f.Lists = append(f.Lists[:i], f.Lists[i+1:]...)
 			return nil
 		}
@@ -51,9 +53,9 @@ func (f *Fake) DeleteList(name string) error {
 	return errors.New("list not found")
 }
 
-func (f *Fake) GetFiltered(name string) (*filter.List, error) {
+func (f *Fake) GetFiltered(owner, name string) (*filter.List, error) {
 	for _, l := range f.Filtered {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			return l, nil
 		}
 	}
@@ -69,12 +71,36 @@ func (f *Fake) AddFiltered(list *filter.List) error {
 	return nil
 }
 
-func (f *Fake) DeleteFiltered(name string) error {
+func (f *Fake) DeleteFiltered(owner, name string) error {
 	for i, l := range f.Filtered {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			f.Filtered = append(f.Filtered[:i], f.Filtered[i+1:]...)
 			return nil
 		}
 	}
 	return errors.New("filtered list not found")
 }
+
+func (f *Fake) Replace(lists []*core.List, filtered []*filter.List) error {
+	f.Lists = lists
+	f.Filtered = filtered
+	return nil
+}
+
+func (f *Fake) GetArchive() ([]*core.List, error) {
+	return f.Archive, nil
+}
+
+func (f *Fake) ReplaceArchive(lists []*core.List) error {
+	f.Archive = lists
+	return nil
+}
+
+func (f *Fake) GetGroups() ([]*core.Group, error) {
+	return f.Groups, nil
+}
+
+func (f *Fake) ReplaceGroups(groups []*core.Group) error {
+	f.Groups = groups
+	return nil
+}
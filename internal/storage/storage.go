@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
@@ -18,6 +19,13 @@ type File struct {
 type FileStore struct {
 	Lists    []*core.List
 	Filtered []*filter.List
+	Groups   []*core.Group `yaml:",omitempty"`
+}
+
+// ArchiveStore is the content of the archive, which is kept in its own file next to the database, see File.ArchivePath,
+// so that archived tasks aren't rewritten with every change of a list.
+type ArchiveStore struct {
+	Lists []*core.List
 }
 
 func NewFile(path string) *File {
@@ -51,24 +59,30 @@ func (f *File) load() (*FileStore, error) {
 	return store, err
 }
 
+// save writes the store to a temporary file and renames it over the database, so that readers never see a partially
+// written file.
 func (f *File) save(store *FileStore) error {
 	data, err := yaml.Marshal(store)
 	if err != nil {
 		return err
 	}
 
-	return os.WriteFile(f.Path, data, 0600)
+	tmp := f.Path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.Path)
 }
 
-// GetList retrieves a list by name from the YAML file.
-func (f *File) GetList(name string) (*core.List, error) {
+// GetList retrieves a list by owner and name from the YAML file.
+func (f *File) GetList(owner, name string) (*core.List, error) {
 	store, err := f.load()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, list := range store.Lists {
-		if list.Name == name {
+		if list.Owner == owner && list.Name == name {
 			return list, nil
 		}
 	}
@@ -95,14 +109,14 @@ func (f *File) AddList(list *core.List) error {
 	return f.save(store)
 }
 
-func (f *File) UpdateList(name string, list *core.List) error {
+func (f *File) UpdateList(owner, name string, list *core.List) error {
 	store, err := f.load()
 	if err != nil {
 		return err
 	}
 
 	for i, l := range store.Lists {
-		if l.Name == name {
+		if l.Owner == owner && l.Name == name {
 			store.Lists[i] = list
 			break
 		}
@@ -111,14 +125,14 @@ func (f *File) UpdateList(name string, list *core.List) error {
 	return f.save(store)
 }
 
-func (f *File) DeleteList(name string) error {
+func (f *File) DeleteList(owner, name string) error {
 	store, err := f.load()
 	if err != nil {
 		return err
 	}
 
 	for i, list := range store.Lists {
-		if list.Name == name {
+		if list.Owner == owner && list.Name == name {
 			store.Lists = append(store.Lists[:i], store.Lists[i+1:]...)
 			break
 		}
@@ -127,14 +141,14 @@ func (f *File) DeleteList(name string) error {
 	return f.save(store)
 }
 
-func (f *File) GetFiltered(name string) (*filter.List, error) {
+func (f *File) GetFiltered(owner, name string) (*filter.List, error) {
 	store, err := f.load()
 	if err != nil {
 		return nil, err
 	}
 
 	for _, list := range store.Filtered {
-		if list.Name == name {
+		if list.Owner == owner && list.Name == name {
 			return list, nil
 		}
 	}
@@ -162,14 +176,14 @@ func (f *File) AddFiltered(list *filter.List) error {
 	return f.save(store)
 }
 
-func (f *File) DeleteFiltered(name string) error {
+func (f *File) DeleteFiltered(owner, name string) error {
 	store, err := f.load()
 	if err != nil {
 		return err
 	}
 
 	for i, list := range store.Filtered {
-		if list.Name == name {
+		if list.Owner == owner && list.Name == name {
 			store.Filtered = append(store.Filtered[:i], store.Filtered[i+1:]...)
 			break
 		}
@@ -177,3 +191,71 @@ func (f *File) DeleteFiltered(name string) error {
 
 	return f.save(store)
 }
+
+// Replace overwrites all lists and filtered lists in a single write.
+func (f *File) Replace(lists []*core.List, filtered []*filter.List) error {
+	store, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	store.Lists = lists
+	store.Filtered = filtered
+
+	return f.save(store)
+}
+
+func (f *File) GetGroups() ([]*core.Group, error) {
+	store, err := f.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return store.Groups, nil
+}
+
+func (f *File) ReplaceGroups(groups []*core.Group) error {
+	store, err := f.load()
+	if err != nil {
+		return err
+	}
+
+	store.Groups = groups
+
+	return f.save(store)
+}
+
+// ArchivePath returns the path of the archive file, e.g. "tasks.archive.yaml" for the database "tasks.yaml".
+func (f *File) ArchivePath() string {
+	ext := filepath.Ext(f.Path)
+	return strings.TrimSuffix(f.Path, ext) + ".archive" + ext
+}
+
+// GetArchive returns the lists of archived tasks, none if the archive file doesn't exist yet.
+func (f *File) GetArchive() ([]*core.List, error) {
+	data, err := os.ReadFile(f.ArchivePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	store := &ArchiveStore{}
+	err = yaml.Unmarshal(data, store)
+	return store.Lists, err
+}
+
+// ReplaceArchive overwrites the archive file like save.
+func (f *File) ReplaceArchive(lists []*core.List) error {
+	data, err := yaml.Marshal(&ArchiveStore{Lists: lists})
+	if err != nil {
+		return err
+	}
+
+	tmp := f.ArchivePath() + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, f.ArchivePath())
+}
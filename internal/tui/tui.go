@@ -0,0 +1,441 @@
+// Package tui implements an interactive terminal interface with lists in a sidebar and the tasks of the selected
+// list in the main pane.
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/quickadd"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/rest"
+)
+
+const help = "[::b]Tab[::-] switch pane  [::b]a[::-] add  [::b]Space[::-] done  [::b]e[::-] title  [::b]d[::-] due date  " +
+	"[::b]+/-[::-] priority  [::b]h[::-] hide done  [::b]r[::-] refresh  [::b]q[::-] quit"
+
+// App is the terminal interface. All organiser calls happen on the UI goroutine, so the organiser doesn't need to be
+// safe for concurrent use.
+type App struct {
+	orga    rest.Organiser
+	refresh time.Duration
+
+	app     *tview.Application
+	pages   *tview.Pages
+	sidebar *tview.List
+	tasks   *tview.Table
+	status  *tview.TextView
+
+	// names of the entries in the sidebar and whether they are filtered lists
+	names    []string
+	filtered []bool
+	// current is the name of the list shown in the main pane
+	current  string
+	shown    []*core.Task
+	hideDone bool
+}
+
+// New returns a terminal interface for the given organiser which reloads the data every refresh interval. A zero
+// interval disables the live refresh.
+func New(orga rest.Organiser, refresh time.Duration) *App {
+	a := &App{
+		orga:     orga,
+		refresh:  refresh,
+		app:      tview.NewApplication(),
+		pages:    tview.NewPages(),
+		sidebar:  tview.NewList(),
+		tasks:    tview.NewTable(),
+		status:   tview.NewTextView(),
+		hideDone: true,
+	}
+
+	a.sidebar.ShowSecondaryText(false).SetHighlightFullLine(true).SetBorder(true).SetTitle(" Lists ")
+	a.sidebar.SetSelectedFunc(func(int, string, string, rune) {
+		a.app.SetFocus(a.tasks)
+	})
+
+	a.tasks.SetSelectable(true, false).SetBorder(true)
+	a.tasks.SetInputCapture(a.handleTaskKeys)
+
+	a.status.SetDynamicColors(true).SetText(help)
+
+	main := tview.NewFlex().
+		AddItem(a.sidebar, 28, 0, true).
+		AddItem(a.tasks, 0, 1, false)
+	layout := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(main, 0, 1, true).
+		AddItem(a.status, 1, 0, false)
+	a.pages.AddPage("main", layout, true, true)
+
+	a.app.SetRoot(a.pages, true).SetInputCapture(a.handleGlobalKeys)
+
+	return a
+}
+
+// Run shows the interface and blocks until the user quits.
+func (a *App) Run() error {
+	a.reload()
+
+	if a.refresh > 0 {
+		stop := make(chan struct{})
+		defer close(stop)
+		go func() {
+			ticker := time.NewTicker(a.refresh)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					a.app.QueueUpdateDraw(a.reload)
+				case <-stop:
+					return
+				}
+			}
+		}()
+	}
+
+	return a.app.Run()
+}
+
+func (a *App) handleGlobalKeys(ev *tcell.EventKey) *tcell.EventKey {
+	// don't steal keys from input dialogs
+	if name, _ := a.pages.GetFrontPage(); name != "main" {
+		return ev
+	}
+	switch {
+	case ev.Key() == tcell.KeyTab || ev.Key() == tcell.KeyBacktab:
+		if a.sidebar.HasFocus() {
+			a.app.SetFocus(a.tasks)
+		} else {
+			a.app.SetFocus(a.sidebar)
+		}
+		return nil
+	case ev.Rune() == 'q':
+		a.app.Stop()
+		return nil
+	case ev.Rune() == 'r':
+		a.reload()
+		return nil
+	case ev.Rune() == 'h':
+		a.hideDone = !a.hideDone
+		a.loadTasks()
+		return nil
+	case ev.Rune() == 'a':
+		a.promptAdd()
+		return nil
+	case ev.Rune() == 'j':
+		return tcell.NewEventKey(tcell.KeyDown, 0, tcell.ModNone)
+	case ev.Rune() == 'k':
+		return tcell.NewEventKey(tcell.KeyUp, 0, tcell.ModNone)
+	}
+	return ev
+}
+
+func (a *App) handleTaskKeys(ev *tcell.EventKey) *tcell.EventKey {
+	t := a.selectedTask()
+	if t == nil {
+		return ev
+	}
+	switch ev.Rune() {
+	case ' ', 'x':
+		a.apply(func() (core.Task, error) { return a.orga.MarkDone(t.ID, !t.Done) })
+	case '+', '-':
+		prio := t.Priority + 1
+		if ev.Rune() == '-' {
+			prio = t.Priority - 1
+		}
+		if prio < core.PrioLowest || prio > core.PrioHighest {
+			return nil
+		}
+		a.update(*t, func(c *api.TaskChange) error {
+			c.Priority = prio
+			return nil
+		})
+	case 'e':
+		a.prompt("Title", t.Title, func(text string) {
+			a.update(*t, func(c *api.TaskChange) error {
+				c.Title = text
+				return nil
+			})
+		})
+	case 'd':
+		a.prompt("Due (by|on date, e.g. by friday 5pm, empty for none)", formatDue(*t), func(text string) {
+			a.update(*t, func(c *api.TaskChange) error {
+				return parseDue(text, c)
+			})
+		})
+	default:
+		return ev
+	}
+	return nil
+}
+
+// promptAdd asks for a new task, parsed like "Call client tomorrow 9am !high #Work". Without #List the task is added
+// to the current list.
+func (a *App) promptAdd() {
+	list := a.current
+	if _, err := a.orga.GetList(list); err != nil {
+		list = ""
+	}
+	a.prompt("New task (#list !prio date)", "", func(text string) {
+		task, err := quickadd.Parse(text, time.Now())
+		if err != nil {
+			a.showError(err)
+			return
+		}
+		if task.List == "" {
+			task.List = list
+		}
+		if task.List == "" {
+			a.showError(errors.New("missing #list, tasks can't be added to filtered lists"))
+			return
+		}
+		lists, _ := a.orga.Lists()
+		task.List = quickadd.ResolveList(task.List, lists)
+		a.apply(func() (core.Task, error) {
+			return a.orga.AddItem(task.List, task)
+		})
+	})
+}
+
+// prompt shows an input field and calls done with the entered text unless the user cancels with Escape.
+func (a *App) prompt(label, text string, done func(string)) {
+	focus := a.app.GetFocus()
+	input := tview.NewInputField().SetLabel(label + ": ").SetText(text)
+	input.SetDoneFunc(func(key tcell.Key) {
+		a.pages.RemovePage("prompt")
+		a.app.SetFocus(focus)
+		if key == tcell.KeyEnter {
+			done(strings.TrimSpace(input.GetText()))
+		}
+	})
+	input.SetBorder(true)
+
+	modal := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(nil, 0, 1, false).
+		AddItem(input, 3, 0, true)
+	a.pages.AddPage("prompt", modal, true, true)
+	a.app.SetFocus(input)
+}
+
+// update changes the task with the given modification.
+func (a *App) update(t core.Task, modify func(*api.TaskChange) error) {
+	change := api.NewTaskChange(t)
+	if err := modify(&change); err != nil {
+		a.showError(err)
+		return
+	}
+	if err := change.Validate(); err != nil {
+		a.showError(err)
+		return
+	}
+	a.apply(func() (core.Task, error) { return a.orga.UpdateTask(t.ID, change) })
+}
+
+// apply runs a change and reloads the data, keeping the selection on the changed task.
+func (a *App) apply(change func() (core.Task, error)) {
+	t, err := change()
+	if err != nil {
+		a.showError(err)
+		return
+	}
+	a.reload()
+	for i, s := range a.shown {
+		if s.ID == t.ID {
+			a.tasks.Select(i, 0)
+		}
+	}
+}
+
+func (a *App) showError(err error) {
+	a.status.SetText("[red]" + tview.Escape(err.Error()))
+	go func() {
+		time.Sleep(3 * time.Second)
+		a.app.QueueUpdateDraw(func() { a.status.SetText(help) })
+	}()
+}
+
+// reload fetches the lists and the tasks of the current list.
+func (a *App) reload() {
+	lists, filtered := a.orga.Lists()
+	if err := lastErr(a.orga); err != nil {
+		a.showError(err)
+		return
+	}
+
+	a.names, a.filtered = a.names[:0], a.filtered[:0]
+	for _, l := range lists {
+		a.names = append(a.names, l.Name)
+		a.filtered = append(a.filtered, false)
+	}
+	for _, fl := range filtered {
+		a.names = append(a.names, fl.Name)
+		a.filtered = append(a.filtered, true)
+	}
+
+	selected := 0
+	for i, name := range a.names {
+		if name == a.current {
+			selected = i
+		}
+	}
+	// rebuilding the sidebar triggers the changed func, which would reset the current list
+	current := a.current
+	a.sidebar.SetChangedFunc(nil)
+	a.sidebar.Clear()
+	for i, name := range a.names {
+		label := name
+		if a.filtered[i] {
+			label = "» " + name
+		}
+		a.sidebar.AddItem(tview.Escape(label), "", 0, nil)
+	}
+	if len(a.names) > 0 {
+		a.sidebar.SetCurrentItem(selected)
+		a.current = a.names[selected]
+	}
+	if current != "" && a.current != current {
+		// the list was deleted
+		a.tasks.Select(0, 0)
+	}
+	a.sidebar.SetChangedFunc(func(i int, _, _ string, _ rune) {
+		a.current = a.names[i]
+		a.tasks.Select(0, 0)
+		a.loadTasks()
+	})
+
+	a.loadTasks()
+}
+
+// loadTasks shows the tasks of the current list.
+func (a *App) loadTasks() {
+	row, _ := a.tasks.GetSelection()
+	a.tasks.Clear()
+	a.shown = a.shown[:0]
+	a.tasks.SetTitle(" " + tview.Escape(a.current) + " ")
+	if a.current == "" {
+		return
+	}
+
+	var tasks []*core.Task
+	l, err := a.orga.GetList(a.current)
+	filtered := errors.Is(err, repository.ErrListNotFound)
+	if filtered {
+		tasks, err = a.orga.GetFilteredTasks(a.current)
+	} else {
+		tasks = l.Items
+	}
+	if err != nil {
+		a.showError(err)
+		return
+	}
+
+	for _, t := range tasks {
+		if a.hideDone && t.Done {
+			continue
+		}
+		a.shown = append(a.shown, t)
+	}
+	for i, t := range a.shown {
+		a.setRow(i, *t, filtered)
+	}
+	a.tasks.Select(min(row, max(len(a.shown)-1, 0)), 0)
+}
+
+// setRow shows a task in the given row, filtered lists also show the list of the task.
+func (a *App) setRow(row int, t core.Task, showList bool) {
+	check := "[ ]"
+	colour := tcell.ColorDefault
+	if t.Done {
+		check = "[x]"
+		colour = tcell.ColorGray
+	} else if t.IsOverdue() {
+		colour = tcell.ColorRed
+	}
+
+	cells := []string{check, t.Title, formatDue(t), priorityLabel(t.Priority)}
+	if showList {
+		cells = append(cells, t.List)
+	}
+	for col, text := range cells {
+		cell := tview.NewTableCell(tview.Escape(text)).SetTextColor(colour)
+		if col == 1 {
+			cell.SetExpansion(1)
+		}
+		a.tasks.SetCell(row, col, cell)
+	}
+}
+
+func (a *App) selectedTask() *core.Task {
+	row, _ := a.tasks.GetSelection()
+	if row < 0 || row >= len(a.shown) {
+		return nil
+	}
+	return a.shown[row]
+}
+
+// lastErr returns the error of the last organiser call that can't return one itself, e.g. Lists on a remote server.
+func lastErr(o rest.Organiser) error {
+	if e, ok := o.(interface{ Err() error }); ok {
+		return e.Err()
+	}
+	return nil
+}
+
+func formatDue(t core.Task) string {
+	if !t.HasDueDate() {
+		return ""
+	}
+	layout := "2006-01-02 15:04"
+	if t.AllDay {
+		layout = "2006-01-02"
+	}
+	keyword := "by"
+	if t.HasDueOnDate() {
+		keyword = "on"
+	}
+	return keyword + " " + t.Due.In(time.Local).Format(layout)
+}
+
+// parseDue parses the format written by formatDue, or any date understood by quickadd, into the change.
+func parseDue(text string, c *api.TaskChange) error {
+	if text == "" {
+		c.DueType, c.Due = core.DueNone, time.Time{}
+		return nil
+	}
+	keyword, date, _ := strings.Cut(text, " ")
+	switch keyword {
+	case "by":
+		c.DueType = core.DueBy
+	case "on":
+		c.DueType = core.DueOn
+	default:
+		return fmt.Errorf("due date must start with by or on")
+	}
+	due, allDay, err := quickadd.ParseDate(date, time.Now())
+	if err != nil {
+		return err
+	}
+	c.Due, c.AllDay = due, allDay
+	return nil
+}
+
+func priorityLabel(prio int) string {
+	switch prio {
+	case core.PrioLowest:
+		return "!lowest"
+	case core.PrioLow:
+		return "!low"
+	case core.PrioHigh:
+		return "!high"
+	case core.PrioHighest:
+		return "!highest"
+	}
+	return ""
+}
@@ -0,0 +1,527 @@
+// Package webhook sends changes of tasks and lists to URLs registered by users.
+//
+// Every webhook receives the changes of the lists its user can see, optionally restricted to some event types and to
+// tasks matching a filter. Payloads are api.ChangeEvent as JSON, signed with HMAC-SHA256 using the secret of the
+// webhook; the signature is sent as "sha256=<hex>" in the X-Gotasks-Signature header. Failed deliveries are retried
+// with increasing delays. Events are delivered concurrently, so their order isn't guaranteed. Webhooks can't reach
+// loopback, private or link-local addresses unless Dispatcher.AllowPrivate is set.
+//
+// Webhooks are kept in a YAML file, the delivery log only in memory.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// Headers of the requests sent to webhooks.
+const (
+	SignatureHeader = "X-Gotasks-Signature"
+	EventHeader     = "X-Gotasks-Event"
+	DeliveryHeader  = "X-Gotasks-Delivery"
+)
+
+// maxDeliveries is the number of deliveries kept in the log of each webhook.
+const maxDeliveries = 50
+
+// DefaultBackoff are the delays before the retries of a failed delivery.
+var DefaultBackoff = []time.Duration{10 * time.Second, time.Minute, 10 * time.Minute}
+
+var ErrNotFound = errors.New("webhook not found")
+
+// Hook is a webhook of a user.
+type Hook struct {
+	ID   int
+	User string
+	URL  string
+	// Secret is the key of the payload signatures.
+	Secret string
+	// Events are the change types to send, empty means all.
+	Events []repository.ChangeType `yaml:",omitempty"`
+	// Filter restricts task events to matching tasks, it is ignored if it has no rule sets.
+	Filter  filter.Filter `yaml:",omitempty"`
+	Created time.Time
+}
+
+// State is the state of a delivery.
+type State string
+
+const (
+	Pending   State = "pending"
+	Delivered State = "delivered"
+	Failed    State = "failed"
+)
+
+// Delivery is the delivery of a change to a webhook.
+type Delivery struct {
+	ID    int
+	Hook  int
+	Event repository.ChangeType
+	// Time is the time of the change.
+	Time     time.Time
+	Attempts int
+	State    State
+	// StatusCode is the response status of the last attempt, 0 if there was no response.
+	StatusCode int
+	// Error describes why the last attempt failed.
+	Error string
+}
+
+type fileStore struct {
+	Hooks []*Hook
+}
+
+// Dispatcher keeps the webhooks and delivers changes to them. It is safe for concurrent use.
+type Dispatcher struct {
+	path string
+
+	// Client sends the requests to webhooks.
+	Client *http.Client
+	// Backoff are the delays before the retries of a failed delivery, there is one retry per delay.
+	Backoff []time.Duration
+	// AllowPrivate allows webhooks on loopback, private and link-local addresses. By default they are rejected when a
+	// webhook is added and again when Client connects, so that users can't reach the network of the server.
+	AllowPrivate bool
+
+	mu sync.Mutex
+	// mem is used instead of the file if path is empty
+	mem *fileStore
+	// deliveries are the latest deliveries of every webhook, oldest first
+	deliveries   map[int][]*Delivery
+	lastDelivery int
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	log *log.Entry
+}
+
+// NewDispatcher returns a dispatcher keeping the webhooks in the YAML file at path, which is created if it doesn't
+// exist. An empty path keeps everything in memory. Pass Notify to repository.Subscribe to deliver changes.
+func NewDispatcher(path string, logger *log.Entry) (*Dispatcher, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	d := &Dispatcher{
+		path:       path,
+		Backoff:    DefaultBackoff,
+		deliveries: make(map[int][]*Delivery),
+		ctx:        ctx,
+		cancel:     cancel,
+		log:        logger,
+	}
+	// a proxy would be dialed instead of the webhook, so the addresses couldn't be checked
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second, Control: d.checkDial}
+	transport.DialContext = dialer.DialContext
+	d.Client = &http.Client{Timeout: 10 * time.Second, Transport: transport}
+	if path == "" {
+		d.mem = &fileStore{}
+		return d, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		if err = os.WriteFile(path, []byte{}, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return d, nil
+}
+
+// Close stops retrying deliveries and waits for the running ones.
+func (d *Dispatcher) Close() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Hooks returns the webhooks of a user.
+func (d *Dispatcher) Hooks(user string) ([]Hook, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	store, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	hooks := make([]Hook, 0)
+	for _, h := range store.Hooks {
+		if h.User == user {
+			hooks = append(hooks, *h)
+		}
+	}
+	return hooks, nil
+}
+
+// AddHook registers a webhook, the ID and creation time are set by AddHook. If the hook has no secret, a random one is
+// generated. The returned hook includes the secret.
+func (d *Dispatcher) AddHook(h Hook) (Hook, error) {
+	if err := d.validate(h); err != nil {
+		return Hook{}, err
+	}
+	if h.Secret == "" {
+		secret, err := randomSecret()
+		if err != nil {
+			return Hook{}, err
+		}
+		h.Secret = secret
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	store, err := d.load()
+	if err != nil {
+		return Hook{}, err
+	}
+	h.ID = 1
+	for _, other := range store.Hooks {
+		h.ID = max(h.ID, other.ID+1)
+	}
+	h.Created = time.Now()
+	store.Hooks = append(store.Hooks, &h)
+	if err = d.save(store); err != nil {
+		return Hook{}, err
+	}
+	return h, nil
+}
+
+// DeleteHook deletes a webhook of a user with its delivery log. Running deliveries are finished.
+func (d *Dispatcher) DeleteHook(user string, id int) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	store, err := d.load()
+	if err != nil {
+		return err
+	}
+	for i, h := range store.Hooks {
+		if h.ID == id && h.User == user {
+			store.Hooks = append(store.Hooks[:i], store.Hooks[i+1:]...)
+			delete(d.deliveries, id)
+			return d.save(store)
+		}
+	}
+	return ErrNotFound
+}
+
+// DeleteHooks deletes all webhooks of a user with their delivery logs, e.g. when the user is deleted.
+func (d *Dispatcher) DeleteHooks(user string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	store, err := d.load()
+	if err != nil {
+		return err
+	}
+	store.Hooks = slices.DeleteFunc(store.Hooks, func(h *Hook) bool {
+		if h.User == user {
+			delete(d.deliveries, h.ID)
+			return true
+		}
+		return false
+	})
+	return d.save(store)
+}
+
+// Deliveries returns the latest deliveries to a webhook of a user, newest first.
+func (d *Dispatcher) Deliveries(user string, id int) ([]Delivery, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	store, err := d.load()
+	if err != nil {
+		return nil, err
+	}
+	if findHook(store, user, id) == nil {
+		return nil, ErrNotFound
+	}
+	deliveries := make([]Delivery, 0, len(d.deliveries[id]))
+	for i := len(d.deliveries[id]) - 1; i >= 0; i-- {
+		deliveries = append(deliveries, *d.deliveries[id][i])
+	}
+	return deliveries, nil
+}
+
+// Notify delivers a change to all matching webhooks in the background.
+func (d *Dispatcher) Notify(c repository.Change) {
+	if d.ctx.Err() != nil {
+		return
+	}
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		d.dispatch(c)
+	}()
+}
+
+func (d *Dispatcher) dispatch(c repository.Change) {
+	body, err := json.Marshal(c.Event())
+	if err != nil {
+		d.log.WithError(err).Error("Failed to encode webhook event.")
+		return
+	}
+
+	d.mu.Lock()
+	store, err := d.load()
+	if err != nil {
+		d.mu.Unlock()
+		d.log.WithError(err).Error("Failed to load webhooks.")
+		return
+	}
+	var hooks []Hook
+	var deliveries []*Delivery
+	for _, h := range store.Hooks {
+		if !matches(*h, c) {
+			continue
+		}
+		d.lastDelivery++
+		dl := &Delivery{ID: d.lastDelivery, Hook: h.ID, Event: c.Type, Time: c.Time, State: Pending}
+		latest := append(d.deliveries[h.ID], dl)
+		if len(latest) > maxDeliveries {
+			latest = latest[len(latest)-maxDeliveries:]
+		}
+		d.deliveries[h.ID] = latest
+		hooks = append(hooks, *h)
+		deliveries = append(deliveries, dl)
+	}
+	d.mu.Unlock()
+
+	var wg sync.WaitGroup
+	for i := range hooks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliver(hooks[i], deliveries[i], body)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliver sends a payload to a webhook until it is accepted or there are no retries left.
+func (d *Dispatcher) deliver(h Hook, dl *Delivery, body []byte) {
+	for attempt := 0; ; attempt++ {
+		status, err := d.post(h, dl, body)
+
+		d.mu.Lock()
+		dl.Attempts++
+		dl.StatusCode = status
+		dl.Error = ""
+		switch {
+		case err == nil:
+			dl.State = Delivered
+		case !retry(status) || attempt == len(d.Backoff):
+			dl.State = Failed
+		}
+		if err != nil {
+			dl.Error = err.Error()
+		}
+		state := dl.State
+		d.mu.Unlock()
+
+		if state != Pending {
+			if state == Failed {
+				d.log.WithError(err).WithFields(log.Fields{"webhook": h.ID, "event": dl.Event}).Warn("Webhook delivery failed.")
+			}
+			return
+		}
+
+		select {
+		case <-time.After(d.Backoff[attempt]):
+		case <-d.ctx.Done():
+			d.mu.Lock()
+			dl.State = Failed
+			d.mu.Unlock()
+			return
+		}
+	}
+}
+
+// post sends a payload to a webhook and returns the status code of the response, if there is one.
+func (d *Dispatcher) post(h Hook, dl *Delivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, h.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(EventHeader, string(dl.Event))
+	req.Header.Set(DeliveryHeader, strconv.Itoa(dl.ID))
+	req.Header.Set(SignatureHeader, Sign(h.Secret, body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the signature of a payload sent in the X-Gotasks-Signature header, receivers can compare it with
+// hmac.Equal.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retry reports whether a delivery with the given response status should be retried. Requests without response,
+// rate limited requests and server errors are retried, other errors are permanent.
+func retry(status int) bool {
+	return status == 0 || status == http.StatusTooManyRequests || status >= 500
+}
+
+// matches reports whether a change is sent to a webhook.
+func matches(h Hook, c repository.Change) bool {
+	if !c.VisibleTo(h.User) {
+		return false
+	}
+	if len(h.Events) > 0 && !slices.Contains(h.Events, c.Type) {
+		return false
+	}
+	if c.Task != nil && len(h.Filter.RuleSets) > 0 {
+		return h.Filter.ForUser(h.User).ForGroups(c.GroupLists(h.User)).Evaluate(*c.Task)
+	}
+	return true
+}
+
+func (d *Dispatcher) validate(h Hook) error {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return &api.FieldError{Field: "url", Message: "url must be an absolute http or https URL"}
+	}
+	if !d.AllowPrivate {
+		if err = checkHost(u.Hostname()); err != nil {
+			return &api.FieldError{Field: "url", Message: err.Error()}
+		}
+	}
+	for _, e := range h.Events {
+		if !slices.Contains(repository.ChangeTypes, e) {
+			return &api.FieldError{Field: "events", Message: fmt.Sprintf("unknown event %q", e)}
+		}
+	}
+	return nil
+}
+
+// checkHost returns an error if host is or resolves to an address webhooks can't use. Hosts that don't resolve are
+// accepted, they are checked again when a delivery connects.
+func checkHost(host string) error {
+	if ip := net.ParseIP(host); ip != nil {
+		return checkIP(ip)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if err = checkIP(addr.IP); err != nil {
+			return fmt.Errorf("%s: %w", host, err)
+		}
+	}
+	return nil
+}
+
+// checkIP returns an error for loopback, private, link-local, multicast and unspecified addresses.
+func checkIP(ip net.IP) error {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return fmt.Errorf("address %s isn't allowed for webhooks", ip)
+	}
+	return nil
+}
+
+// checkDial is the Control of the dialer of Client, it checks the resolved address of every connection, including
+// redirects and hosts whose address changed since the webhook was added.
+func (d *Dispatcher) checkDial(_, address string, _ syscall.RawConn) error {
+	if d.AllowPrivate {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("address %s isn't an IP", host)
+	}
+	return checkIP(ip)
+}
+
+func (d *Dispatcher) load() (*fileStore, error) {
+	if d.mem != nil {
+		return d.mem, nil
+	}
+	data, err := os.ReadFile(d.path)
+	if err != nil {
+		return nil, err
+	}
+	store := &fileStore{}
+	err = yaml.Unmarshal(data, store)
+	return store, err
+}
+
+// save writes the store to a temporary file and renames it over the webhook file.
+func (d *Dispatcher) save(store *fileStore) error {
+	if d.mem != nil {
+		d.mem = store
+		return nil
+	}
+	data, err := yaml.Marshal(store)
+	if err != nil {
+		return err
+	}
+	tmp := d.path + ".tmp"
+	if err = os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, d.path)
+}
+
+func findHook(store *fileStore, user string, id int) *Hook {
+	for _, h := range store.Hooks {
+		if h.ID == id && h.User == user {
+			return h
+		}
+	}
+	return nil
+}
+
+func randomSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,248 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/api"
+	"github.com/jniewt/gotodo/internal/core"
+	"github.com/jniewt/gotodo/internal/filter"
+	"github.com/jniewt/gotodo/internal/repository"
+)
+
+// receiver records the requests of a webhook and fails the first fail requests with 503.
+type receiver struct {
+	mu       sync.Mutex
+	fail     int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	if len(rc.requests) <= rc.fail {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+}
+
+func newTestDispatcher(t *testing.T) *Dispatcher {
+	logger := log.New()
+	logger.SetOutput(io.Discard)
+	d, err := NewDispatcher("", log.NewEntry(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.Backoff = []time.Duration{time.Millisecond, time.Millisecond}
+	// the test servers listen on loopback
+	d.AllowPrivate = true
+	return d
+}
+
+func change(typ repository.ChangeType, task *core.Task) repository.Change {
+	list := core.List{Name: "Work", Owner: "alice", Members: []core.Member{{User: "bob", Role: core.RoleViewer}}}
+	return repository.Change{Type: typ, Time: time.Now(), User: "alice", List: list, Task: task}
+}
+
+func TestDispatcher_Notify(t *testing.T) {
+	rc := &receiver{fail: 1}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d := newTestDispatcher(t)
+
+	h, err := d.AddHook(Hook{User: "bob", URL: srv.URL, Events: []repository.ChangeType{repository.TaskCompleted}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h.Secret == "" {
+		t.Error("no secret generated")
+	}
+	// not visible to carol, wrong event type for bob
+	_, _ = d.AddHook(Hook{User: "carol", URL: srv.URL})
+	d.Notify(change(repository.TaskUpdated, &core.Task{ID: 1, Title: "Ship it", List: "Work"}))
+	d.Notify(change(repository.TaskCompleted, &core.Task{ID: 1, Title: "Ship it", List: "Work", Done: true}))
+	// wait for the deliveries including retries, Close would cancel them
+	d.wg.Wait()
+
+	if len(rc.requests) != 2 {
+		t.Fatalf("got %d requests, want one retry and one delivery", len(rc.requests))
+	}
+	r, body := rc.requests[1], rc.bodies[1]
+	if got := r.Header.Get(SignatureHeader); got != Sign(h.Secret, body) {
+		t.Errorf("signature = %q, want %q", got, Sign(h.Secret, body))
+	}
+	if got := r.Header.Get(EventHeader); got != string(repository.TaskCompleted) {
+		t.Errorf("event header = %q", got)
+	}
+	var event api.ChangeEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Event != "task.completed" || event.List != "Work" || event.Task == nil || !event.Task.Done {
+		t.Errorf("event = %+v", event)
+	}
+
+	deliveries, err := d.Deliveries("bob", h.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deliveries) != 1 || deliveries[0].State != Delivered || deliveries[0].Attempts != 2 {
+		t.Errorf("deliveries = %+v", deliveries)
+	}
+	if _, err = d.Deliveries("carol", h.ID); err != ErrNotFound {
+		t.Errorf("carol sees bob's deliveries: %v", err)
+	}
+}
+
+func TestDispatcher_Retries(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		wantAttempts int
+	}{
+		{"Server errors are retried", http.StatusInternalServerError, 3},
+		{"Rate limits are retried", http.StatusTooManyRequests, 3},
+		{"Client errors are permanent", http.StatusGone, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+			d := newTestDispatcher(t)
+			h, _ := d.AddHook(Hook{User: "alice", URL: srv.URL})
+			d.Notify(change(repository.ListUpdated, nil))
+			d.wg.Wait()
+
+			deliveries, _ := d.Deliveries("alice", h.ID)
+			if len(deliveries) != 1 {
+				t.Fatalf("deliveries = %+v", deliveries)
+			}
+			dl := deliveries[0]
+			if dl.State != Failed || dl.Attempts != tt.wantAttempts || dl.StatusCode != tt.status || dl.Error == "" {
+				t.Errorf("delivery = %+v", dl)
+			}
+		})
+	}
+}
+
+func TestMatches(t *testing.T) {
+	important, _ := filter.NewRule("prio_min", "2")
+	mine, _ := filter.NewRule("assignee", "me")
+	task := &core.Task{ID: 1, Title: "Deploy", List: "Work", Priority: 2, Assignee: "bob"}
+	office, _ := filter.NewRule("group", "Office")
+	grouped := change(repository.TaskCreated, task)
+	grouped.Groups = map[string]string{"bob": "Office"}
+
+	tests := []struct {
+		name string
+		hook Hook
+		c    repository.Change
+		want bool
+	}{
+		{"Member", Hook{User: "bob"}, change(repository.TaskCreated, task), true},
+		{"Not a member", Hook{User: "carol"}, change(repository.TaskCreated, task), false},
+		{"Without authentication", Hook{}, change(repository.TaskCreated, task), true},
+		{"Other event", Hook{User: "bob", Events: []repository.ChangeType{repository.TaskDeleted}}, change(repository.TaskCreated, task), false},
+		{"Filter matches", Hook{User: "bob", Filter: filterOf(important, mine)}, change(repository.TaskCreated, task), true},
+		{"Filter doesn't match", Hook{User: "alice", Filter: filterOf(mine)}, change(repository.TaskCreated, task), false},
+		{"Group matches", Hook{User: "bob", Filter: filterOf(office)}, grouped, true},
+		{"Group of other user", Hook{User: "alice", Filter: filterOf(office)}, grouped, false},
+		{"Not in group", Hook{User: "bob", Filter: filterOf(office)}, change(repository.TaskCreated, task), false},
+		{"Filter ignored for lists", Hook{User: "alice", Filter: filterOf(mine)}, change(repository.ListUpdated, nil), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matches(tt.hook, tt.c); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcher_PrivateAddresses(t *testing.T) {
+	d := newTestDispatcher(t)
+	d.AllowPrivate = false
+	for _, u := range []string{
+		"http://localhost:8080/hook",
+		"http://127.0.0.1/hook",
+		"http://[::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+		"http://10.0.0.5/hook",
+		"http://192.168.1.1/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[fe80::1]/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := d.AddHook(Hook{User: "alice", URL: u}); err == nil {
+			t.Errorf("AddHook(%s) = nil, want an error", u)
+		}
+	}
+	if _, err := d.AddHook(Hook{User: "alice", URL: "https://203.0.113.7/hook"}); err != nil {
+		t.Errorf("AddHook(public address) = %v", err)
+	}
+
+	// a host may resolve to a private address after the webhook was added
+	rc := &receiver{}
+	srv := httptest.NewServer(rc)
+	defer srv.Close()
+	d.AllowPrivate = true
+	h, err := d.AddHook(Hook{User: "alice", URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.AllowPrivate = false
+	d.Backoff = nil
+	d.Notify(change(repository.TaskCreated, &core.Task{ID: 1, Title: "Report", List: "Work"}))
+	d.wg.Wait()
+
+	deliveries, _ := d.Deliveries("alice", h.ID)
+	if len(deliveries) != 1 || deliveries[0].Error == "" || len(rc.requests) != 0 {
+		t.Errorf("deliveries = %+v, requests = %d, want one failed delivery", deliveries, len(rc.requests))
+	}
+}
+
+func TestDispatcher_File(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.yml")
+	logger := log.NewEntry(log.New())
+	d, _ := NewDispatcher(path, logger)
+	rule, _ := filter.NewRule("list", "Work")
+	h, err := d.AddHook(Hook{User: "alice", URL: "https://chat.example.com/hook", Filter: filterOf(rule)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = d.AddHook(Hook{User: "alice", URL: "ftp://example.com"}); err == nil {
+		t.Error("no error for ftp URL")
+	}
+
+	d, _ = NewDispatcher(path, logger)
+	hooks, err := d.Hooks("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hooks) != 1 || hooks[0].Secret != h.Secret || !hooks[0].Filter.Evaluate(core.Task{List: "Work"}) {
+		t.Fatalf("hooks = %+v", hooks)
+	}
+	if err = d.DeleteHook("bob", h.ID); err != ErrNotFound {
+		t.Errorf("bob deletes alice's webhook: %v", err)
+	}
+	if err = d.DeleteHook("alice", h.ID); err != nil {
+		t.Error(err)
+	}
+}
+
+func filterOf(rules ...filter.Rule) filter.Filter {
+	return filter.Filter{RuleSets: []filter.RuleSet{{Rules: rules}}}
+}
@@ -1,69 +1,78 @@
 package main
 
 import (
-	"embed"
-	"flag"
-	"io/fs"
-	"net/http"
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
-	log "github.com/sirupsen/logrus"
-
 	"github.com/jniewt/gotodo/api"
 	"github.com/jniewt/gotodo/internal/core"
 	"github.com/jniewt/gotodo/internal/filter"
 	"github.com/jniewt/gotodo/internal/repository"
-	"github.com/jniewt/gotodo/internal/rest"
-	"github.com/jniewt/gotodo/internal/storage"
 )
 
-//go:embed static
-var staticFiles embed.FS
-
-func main() {
-
-	logger := log.New()
-	logger.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+// commands maps subcommand names to their implementations. Each gets the arguments following its name.
+var commands = map[string]func(args []string) error{
+	"serve":  runServe,
+	"import": runImport,
+	"add":    runAdd,
+	"ls":     runList,
+	"done":   runDone,
+	"mv":     runMove,
+	"snooze": runSnooze,
+	"timer":  runTimer,
+	"rm":     runRemove,
+	"tui":    runTUI,
+	"user":   runUser,
+	"token":  runToken,
+}
 
-	var web string
-	var demo bool
-	flag.StringVar(&web, "addr", ":8080", "address and port to listen on (<addr>:<port>)")
-	flag.BoolVar(&demo, "demo", false, "add demo data to the repository")
-	flag.Parse()
+const usage = `Usage: gotasks <command> [flags] [arguments]
+
+Commands:
+  serve                      run the web server (default if no command is given)
+  add <title> [-l list] [-p prio] [--due date | --on date]
+                             add a task, e.g. add "Call client tomorrow 9am !high #Work"
+  ls [list|filtered]         show all lists or the tasks of a list
+  done <id>...               mark tasks as done
+  mv <id> <list>             move a task to another list
+  snooze <id> <until>        hide a task until e.g. 2h, tomorrow or "next week", "" shows it again
+  timer start|stop <id>      track the time spent on a task
+  rm <id>...                 delete tasks
+  tui                        interactive terminal interface
+  import -format <format> <file>
+                             import a Taskwarrior or Todoist dump
+  user add [-admin]|passwd <name>
+                             add a user or change a password
+  token create|ls|revoke -user <name> [name|id]
+                             manage API tokens, e.g. for "-token" or $GOTASKS_TOKEN
+
+Commands other than serve, user and token talk to the running server, or use the database directly if no server is
+running. user and token edit the auth file directly.
+Run "gotasks <command> -h" for the flags of a command.
+`
 
-	// Create a subdirectory in the embedded filesystem
-	staticFS, err := fs.Sub(staticFiles, "static")
-	if err != nil {
-		logger.WithError(err).Error("Failed to create sub filesystem")
-		os.Exit(1)
+func main() {
+	// without a command, or with only flags, behave like before subcommands were introduced
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
 	}
 
-	var repo *repository.Repository
-
-	// TODO remove this in production
-	if demo {
-		store := &storage.Fake{}
-		repo = repository.NewRepository(store)
-		addTestData(repo)
-	} else {
-		// Get the home directory
-		homeDir, err := os.UserHomeDir()
-		if err != nil {
-			// Handle error
-			panic(err)
-		}
-		store := storage.NewFile(filepath.Join(homeDir, ".gotasks/db.yml"))
-		repo = repository.NewRepository(store)
+	if name == "help" {
+		fmt.Print(usage)
+		return
+	}
+	cmd, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
 	}
 
-	server := rest.NewServer(staticFS, repo, log.NewEntry(logger))
-
-	log.WithField("addr", web).Info("Server started.")
-	srv := http.Server{Handler: server, Addr: web}
-	if err := srv.ListenAndServe(); err != nil {
-		logger.WithError(err).Error("Failed to start server")
+	if err := cmd(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
@@ -133,6 +142,27 @@ func addTestData(repo *repository.Repository) {
 	}
 }
 
+// defaultDBPath returns the path of the database in the user's home directory.
+func defaultDBPath() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		panic(err)
+	}
+	return filepath.Join(homeDir, ".gotasks/db.yml")
+}
+
+func defaultAuthPath() string {
+	return filepath.Join(filepath.Dir(defaultDBPath()), "auth.yml")
+}
+
+func defaultWebhooksPath() string {
+	return filepath.Join(filepath.Dir(defaultDBPath()), "webhooks.yml")
+}
+
+func defaultMailPath() string {
+	return filepath.Join(filepath.Dir(defaultDBPath()), "mail.yml")
+}
+
 // timeAtHourInDays takes a time and number of days from now and returns a time at that hour of the day that many days from now.
 func timeAtHourInDays(hh int, days int) time.Time {
 	t := time.Now()
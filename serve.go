@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/jniewt/gotodo/internal/auth"
+	"github.com/jniewt/gotodo/internal/email"
+	"github.com/jniewt/gotodo/internal/events"
+	"github.com/jniewt/gotodo/internal/pomodoro"
+	"github.com/jniewt/gotodo/internal/reminder"
+	"github.com/jniewt/gotodo/internal/repository"
+	"github.com/jniewt/gotodo/internal/rest"
+	"github.com/jniewt/gotodo/internal/storage"
+	"github.com/jniewt/gotodo/internal/webhook"
+)
+
+//go:embed static
+var staticFiles embed.FS
+
+// runServe implements the serve command, which runs the web server.
+func runServe(args []string) error {
+
+	logger := log.New()
+	logger.SetFormatter(&log.TextFormatter{FullTimestamp: true})
+
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	var web, db, authPath, hooksPath, mailPath string
+	var demo, hooksPrivate bool
+	flags.StringVar(&web, "addr", ":8080", "address and port to listen on (<addr>:<port>)")
+	flags.StringVar(&db, "db", defaultDBPath(), "path to the database")
+	flags.StringVar(&authPath, "auth", defaultAuthPath(), "path to the file with users and API tokens")
+	flags.StringVar(&hooksPath, "webhooks", defaultWebhooksPath(), "path to the file with webhooks")
+	flags.BoolVar(&hooksPrivate, "webhooks-allow-private", false, "allow webhooks on loopback, private and link-local addresses")
+	flags.StringVar(&mailPath, "mail", defaultMailPath(), "path to the SMTP config, emails are disabled if it doesn't exist")
+	flags.BoolVar(&demo, "demo", false, "add demo data to the repository")
+	_ = flags.Parse(args)
+
+	// Create a subdirectory in the embedded filesystem
+	staticFS, err := fs.Sub(staticFiles, "static")
+	if err != nil {
+		return fmt.Errorf("failed to create sub filesystem: %w", err)
+	}
+
+	var repo *repository.Repository
+	var authStore *auth.Store
+
+	// TODO remove this in production
+	if demo {
+		store := &storage.Fake{}
+		repo = repository.NewRepository(store)
+		addTestData(repo)
+		authStore, _ = auth.NewStore("")
+		if err = authStore.AddUser(demoUser, demoPassword); err != nil {
+			return err
+		}
+		if err = authStore.SetAdmin(demoUser, true); err != nil {
+			return err
+		}
+		if _, err = repo.ClaimUnowned(demoUser); err != nil {
+			return err
+		}
+		logger.WithField("user", demoUser).Info("Demo user added.")
+		// passwords are kept out of the log, which may be shipped and retained
+		fmt.Fprintf(os.Stderr, "Demo user %q has the password %q.\n", demoUser, demoPassword)
+		hooksPath = ""
+		mailPath = ""
+	} else {
+		store := storage.NewFile(db)
+		repo = repository.NewRepository(store)
+		if authStore, err = auth.NewStore(authPath); err != nil {
+			return fmt.Errorf("failed to open auth file: %w", err)
+		}
+		admin, err := initAdmin(authStore, logger)
+		if err != nil {
+			return err
+		}
+		// lists from before there were users belong to the admin
+		n, err := repo.ClaimUnowned(admin)
+		if err != nil {
+			return fmt.Errorf("failed to assign lists to %s: %w", admin, err)
+		}
+		if n > 0 {
+			logger.WithFields(log.Fields{"user": admin, "lists": n}).Info("Assigned lists without owner.")
+		}
+	}
+
+	hooks, err := webhook.NewDispatcher(hooksPath, log.NewEntry(logger))
+	if err != nil {
+		return fmt.Errorf("failed to open webhook file: %w", err)
+	}
+	hooks.AllowPrivate = hooksPrivate
+	defer hooks.Close()
+	repo.Subscribe(hooks.Notify)
+	stream := events.NewBroker()
+	repo.Subscribe(stream.Notify)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	notifiers := []reminder.Notifier{stream, hooks}
+	mailer, err := newMailer(mailPath, repo, logger)
+	if err != nil {
+		return err
+	}
+	if mailer != nil {
+		defer mailer.Close()
+		notifiers = append(notifiers, mailer)
+		go mailer.Run(ctx)
+	}
+	go reminder.NewScheduler(repo, log.NewEntry(logger), notifiers...).Run(ctx)
+	go archiveDone(ctx, repo, logger)
+	pomodoros := pomodoro.NewTimer(repo, log.NewEntry(logger), stream, hooks)
+	defer pomodoros.Close()
+
+	orga := func(user string) rest.Organiser { return repo.As(user) }
+	server := rest.NewServer(staticFS, orga, authStore, hooks, stream, pomodoros, log.NewEntry(logger))
+
+	logger.WithField("addr", web).Info("Server started.")
+	srv := http.Server{Handler: server, Addr: web}
+	if err := srv.ListenAndServe(); err != nil {
+		return fmt.Errorf("failed to start server: %w", err)
+	}
+	return nil
+}
+
+// newMailer returns the mailer configured in the file at path, or nil if there is no such file.
+func newMailer(path string, repo *repository.Repository, logger *log.Logger) (*email.Mailer, error) {
+	if path == "" {
+		return nil, nil
+	}
+	cfg, err := email.LoadConfig(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	mailer, err := email.NewMailer(cfg, repo, log.NewEntry(logger))
+	if err != nil {
+		return nil, err
+	}
+	logger.WithFields(log.Fields{"host": cfg.Host, "recipients": len(cfg.Recipients)}).Info("Emails enabled.")
+	return mailer, nil
+}
+
+// archiveInterval is how often done tasks are moved to the archive.
+const archiveInterval = time.Hour
+
+// archiveDone moves done tasks to the archive, see repository.ArchiveDone, right away and then every archiveInterval
+// until ctx is cancelled.
+func archiveDone(ctx context.Context, repo *repository.Repository, logger *log.Logger) {
+	ticker := time.NewTicker(archiveInterval)
+	defer ticker.Stop()
+
+	for {
+		n, err := repo.ArchiveDone(time.Now())
+		if err != nil {
+			logger.WithError(err).Error("Failed to archive done tasks.")
+		} else if n > 0 {
+			logger.WithField("tasks", n).Info("Archived done tasks.")
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+const (
+	demoUser     = "demo"
+	demoPassword = "demo-password"
+	initialUser  = "admin"
+)
+
+// initAdmin makes sure there is an admin and returns the first one. If there are no users yet, it adds an admin with
+// a random password, so that a new server can be logged in to. The password is only logged once. If there are users
+// but no admin, e.g. in an auth file from before there were roles, the first user becomes admin.
+func initAdmin(store *auth.Store, logger *log.Logger) (string, error) {
+	users, err := store.Users()
+	if err != nil {
+		return "", err
+	}
+	for _, u := range users {
+		if u.Admin {
+			return u.Name, nil
+		}
+	}
+
+	if len(users) > 0 {
+		if err = store.SetAdmin(users[0].Name, true); err != nil {
+			return "", err
+		}
+		logger.WithField("user", users[0].Name).Warn("No admin found, made the first user admin.")
+		return users[0].Name, nil
+	}
+
+	password, err := auth.RandomPassword()
+	if err != nil {
+		return "", err
+	}
+	if err = store.AddUser(initialUser, password); err != nil {
+		return "", err
+	}
+	if err = store.SetAdmin(initialUser, true); err != nil {
+		return "", err
+	}
+	logger.WithField("user", initialUser).
+		Warn("No users found, added initial admin. Change the password with \"gotasks user passwd\".")
+	// the password is only printed once and kept out of the log, which may be shipped and retained
+	fmt.Fprintf(os.Stderr, "Initial admin %q has the password %q.\n", initialUser, password)
+	return initialUser, nil
+}
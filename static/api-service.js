@@ -1,5 +1,5 @@
 export class ApiService {
-    constructor(baseURL = '/api') {
+    constructor(baseURL = '/api/v1') {
         this.baseURL = baseURL;
     }
 
@@ -13,6 +13,11 @@ export class ApiService {
 
         try {
             const response = await fetch(url, options);
+            if (response.status === 401) {
+                // the session expired or the user never logged in
+                window.location.href = '/login.html';
+                throw new Error('Not logged in');
+            }
             if (!response.ok) {
                 let errorBody;
                 try {
@@ -21,7 +26,7 @@ export class ApiService {
                     // If there's an error parsing the error body, use a generic message
                     throw new Error('Unknown API error and unable to parse error response');
                 }
-                throw new Error(errorBody.error || 'Unknown API error');
+                throw new Error(errorBody.error?.message || 'Unknown API error');
             }
             // Handle 204 No Content specifically by returning null or a similar indicator
             if (response.status === 204) {
@@ -35,6 +40,10 @@ export class ApiService {
         }
     }
 
+    logout() {
+        return this.request('/logout', { method: 'POST' });
+    }
+
     fetchAllLists() {
         return this.request('/list');
     }
@@ -84,6 +93,27 @@ export class ApiService {
             body: task
         });
     }
+
+    snoozeTask(taskId, until) {
+        return this.request(`/items/${taskId}/snooze`, {
+            method: 'POST',
+            body: { until }
+        });
+    }
+
+    startPomodoro(taskId) {
+        return this.request(`/items/${taskId}/pomodoro`, {
+            method: 'POST',
+            body: {}
+        });
+    }
+
+    // action is start or stop
+    timer(taskId, action) {
+        return this.request(`/items/${taskId}/timer/${action}`, {
+            method: 'POST'
+        });
+    }
 }
 
 function hexToRGB(hex) {
@@ -1,12 +1,19 @@
 import {ApiService} from './api-service.js';
 import {ListManager} from './list-manager.js';
 import {UIManager} from './ui-manager.js';
+import {Reminders} from './reminders.js';
 
 const apiService = new ApiService(); // Assume this is already defined
 const listManager = new ListManager(apiService);
 
 
 document.addEventListener('DOMContentLoaded', async () => {
+    document.getElementById('logoutBtn').addEventListener('click', async (event) => {
+        event.preventDefault();
+        await apiService.logout();
+        window.location.href = '/login.html';
+    });
+    new Reminders().start();
     await listManager.initLists();
     const uiManager = new UIManager(listManager);
     uiManager.displayLists();
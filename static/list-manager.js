@@ -1,5 +1,6 @@
 export class ListManager {
     #lists = [];
+    #groups = [];
     #filteredLists = [];
 
     constructor(apiService) {
@@ -15,6 +16,11 @@ export class ListManager {
         return this.#lists;
     }
 
+    // Groups of lists in order with the names of their lists.
+    get groups() {
+        return this.#groups;
+    }
+
     get filteredLists() {
         return this.#filteredLists;
     }
@@ -22,6 +28,7 @@ export class ListManager {
     async #fetchAllLists() {
         try {
             const data = await this.apiService.fetchAllLists();
+            this.#groups = data.groups;
             this.#lists = data.lists;
             this.#filteredLists = data.filtered_lists;
         } catch (error) {
@@ -102,6 +109,33 @@ export class ListManager {
         }
     }
 
+    async snoozeTask(id, until) {
+        try {
+            await this.apiService.snoozeTask(id, until);
+        } catch (error) {
+            console.error('Failed to snooze task:', error);
+            throw error;
+        }
+    }
+
+    async startPomodoro(id) {
+        try {
+            await this.apiService.startPomodoro(id);
+        } catch (error) {
+            console.error('Failed to start Pomodoro:', error);
+            throw error;
+        }
+    }
+
+    async timer(id, action) {
+        try {
+            await this.apiService.timer(id, action);
+        } catch (error) {
+            console.error(`Failed to ${action} timer:`, error);
+            throw error;
+        }
+    }
+
     listByName(name) {
         return this.#lists.find(list => list.name === name);
     }
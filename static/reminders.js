@@ -0,0 +1,44 @@
+// Reminders shows the reminders and Pomodoro phase changes of the event stream as browser notifications, or as toasts
+// if notifications aren't allowed.
+export class Reminders {
+    constructor(baseURL = '/api/v1') {
+        this.url = `${baseURL}/events`;
+    }
+
+    start() {
+        if ('Notification' in window && Notification.permission === 'default') {
+            Notification.requestPermission();
+        }
+        // EventSource reconnects by itself if the connection is lost
+        const source = new EventSource(this.url);
+        source.addEventListener('task.reminder', (event) => {
+            const data = JSON.parse(event.data);
+            this.show(`Reminder: ${data.task.title}`, `List ${data.list}`);
+        });
+        source.addEventListener('pomodoro.break', (event) => {
+            const data = JSON.parse(event.data);
+            this.show(`Focus done: ${data.task.title}`, `Take a break of ${data.pomodoro.break}`);
+        });
+        source.addEventListener('pomodoro.finished', (event) => {
+            const data = JSON.parse(event.data);
+            this.show('Break over', `Pomodoro on ${data.task.title} finished`);
+        });
+    }
+
+    show(title, body) {
+        if ('Notification' in window && Notification.permission === 'granted') {
+            new Notification(title, {body});
+            return;
+        }
+
+        const toast = document.createElement('div');
+        toast.className = 'toast align-items-center text-bg-primary border-0 position-fixed bottom-0 end-0 m-3';
+        toast.setAttribute('role', 'alert');
+        toast.innerHTML = `<div class="d-flex"><div class="toast-body"></div>
+            <button type="button" class="btn-close btn-close-white me-2 m-auto" data-bs-dismiss="toast"></button></div>`;
+        toast.querySelector('.toast-body').textContent = `${title} (${body})`;
+        document.body.appendChild(toast);
+        toast.addEventListener('hidden.bs.toast', () => toast.remove());
+        new bootstrap.Toast(toast, {autohide: false}).show();
+    }
+}
@@ -132,6 +132,12 @@ export class TaskUIManager {
             contentContainer.append(priorityIcon);
         }
         contentContainer.append(titleSpan)
+        if (task.blocked) {
+            const blockedIcon = document.createElement('i');
+            blockedIcon.className = 'bi bi-lock text-secondary ms-2';
+            blockedIcon.title = `Waiting for task ${task.blocked_by.join(', ')}`;
+            contentContainer.append(blockedIcon);
+        }
 
         itemEl.append(contentContainer);
 
@@ -220,6 +226,11 @@ export class TaskUIManager {
     initContextMenu() {
         this.contextMenu = document.createElement('div');
         this.contextMenu.innerHTML = `<ul class="list-group">
+            <a class="list-group-item list-group-item-action" data-snooze="tomorrow" href="#">Snooze until tomorrow</a>
+            <a class="list-group-item list-group-item-action" data-snooze="next week" href="#">Snooze until next week</a>
+            <a class="list-group-item list-group-item-action" data-timer="start" href="#">Start timer</a>
+            <a class="list-group-item list-group-item-action" data-timer="stop" href="#">Stop timer</a>
+            <a class="list-group-item list-group-item-action" id="start-pomodoro" href="#">Start Pomodoro</a>
             <a class="list-group-item list-group-item-action" id="delete-task" href="#">Delete</a>
         </ul>`;
         this.contextMenu.style.position = 'absolute';
@@ -238,6 +249,59 @@ export class TaskUIManager {
             }
             this.contextMenu.style.display = 'none';
         });
+
+        this.contextMenu.querySelectorAll('[data-snooze]').forEach(item => item.addEventListener('click', () => {
+            if (this.currentRightClickedTaskId) {
+                this.handleSnoozeTask(this.currentRightClickedTaskId, item.dataset.snooze);
+                this.currentRightClickedTaskId = null;
+            }
+            this.contextMenu.style.display = 'none';
+        }));
+
+        this.contextMenu.querySelector('#start-pomodoro').addEventListener('click', () => {
+            if (this.currentRightClickedTaskId) {
+                this.handleStartPomodoro(this.currentRightClickedTaskId);
+                this.currentRightClickedTaskId = null;
+            }
+            this.contextMenu.style.display = 'none';
+        });
+
+        this.contextMenu.querySelectorAll('[data-timer]').forEach(item => item.addEventListener('click', () => {
+            if (this.currentRightClickedTaskId) {
+                this.handleTimer(this.currentRightClickedTaskId, item.dataset.timer);
+                this.currentRightClickedTaskId = null;
+            }
+            this.contextMenu.style.display = 'none';
+        }));
+    }
+
+    handleStartPomodoro(taskId) {
+        this.listManager.startPomodoro(taskId).then(() => {
+            this.showAlert('Pomodoro started, focus for 25 minutes', 'success');
+        }).catch(error => {
+            console.error('Error starting Pomodoro:', error);
+            this.showAlert(`Failed to start the Pomodoro: ${error.message}`, 'danger');
+        });
+    }
+
+    handleTimer(taskId, action) {
+        this.listManager.timer(taskId, action).then(() => {
+            this.onTaskListChange(this.currentList);
+            this.showAlert(action === 'start' ? 'Timer started' : 'Timer stopped', 'success');
+        }).catch(error => {
+            console.error(`Error trying to ${action} timer:`, error);
+            this.showAlert(`Failed to ${action} the timer: ${error.message}`, 'danger');
+        });
+    }
+
+    handleSnoozeTask(taskId, until) {
+        this.listManager.snoozeTask(taskId, until).then(() => {
+            this.onTaskListChange(this.currentList);
+            this.showAlert(`Task snoozed until ${until}`, 'success');
+        }).catch(error => {
+            console.error('Error snoozing task:', error);
+            this.showAlert(`Failed to snooze the task: ${error.message}`, 'danger');
+        });
     }
 
     initAddTaskButton() {
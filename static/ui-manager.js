@@ -37,13 +37,38 @@ export class UIManager {
     displayLists() {
         this.dom.listsDisplay.innerHTML = ''; // Clear current lists
 
-        let combinedLists = this.listManager.lists.map(l => ({ ...l, filtered: false }))
+        this.listManager.groups.forEach(group => {
+            this.createGroupElement(group);
+            group.lists.map(name => this.listManager.listByName(name))
+                .filter(list => list)
+                .forEach(list => this.createListElement({ ...list, filtered: false }));
+        });
+
+        const grouped = new Set(this.listManager.groups.flatMap(g => g.lists));
+        let combinedLists = this.listManager.lists.filter(l => !grouped.has(l.name)).map(l => ({ ...l, filtered: false }))
             .concat(this.listManager.filteredLists.map(l => ({ ...l, filtered: true })));
 
 
         combinedLists.forEach(list => this.createListElement(list));
     }
 
+    // Create the header of a group of lists
+    createGroupElement(group) {
+        const groupElement = document.createElement('div');
+        groupElement.classList.add('list-group-item', 'fw-bold', 'text-uppercase', 'small');
+
+        const icon = document.createElement('i');
+        icon.classList.add('bi', 'bi-folder', 'me-2');
+        icon.style.color = `rgb(${group.colour.r}, ${group.colour.g}, ${group.colour.b})`;
+        groupElement.appendChild(icon);
+
+        const text = document.createElement('span');
+        text.textContent = group.name;
+        groupElement.appendChild(text);
+
+        this.dom.listsDisplay.appendChild(groupElement);
+    }
+
     displayDefaultList() {
         const defaultList = this.listManager.listByName(this.defaultListName) || this.listManager.filteredListByName(this.defaultListName);
         if (defaultList === null) {
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"flag"
+	"time"
+
+	"github.com/jniewt/gotodo/internal/tui"
+)
+
+// runTUI implements the tui command.
+func runTUI(args []string) error {
+	flags := flag.NewFlagSet("tui", flag.ExitOnError)
+	var b backend
+	b.register(flags)
+	var refresh time.Duration
+	flags.DurationVar(&refresh, "refresh", 5*time.Second, "interval for reloading the data, 0 disables it")
+	_ = flags.Parse(args)
+
+	orga, err := b.open()
+	if err != nil {
+		return err
+	}
+	return tui.New(orga, refresh).Run()
+}
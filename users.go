@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/term"
+
+	"github.com/jniewt/gotodo/internal/auth"
+)
+
+// runUser implements the user command, which adds users and changes passwords in the auth file.
+func runUser(args []string) error {
+	flags := flag.NewFlagSet("user", flag.ExitOnError)
+	var authPath string
+	var admin bool
+	flags.StringVar(&authPath, "auth", defaultAuthPath(), "path to the file with users and API tokens")
+	flags.BoolVar(&admin, "admin", false, "make the added user an admin, who can manage other users")
+	pos := parseArgs(flags, args)
+	if len(pos) != 2 || (pos[0] != "add" && pos[0] != "passwd") {
+		return errors.New("usage: gotasks user add [-admin]|passwd <name>")
+	}
+
+	store, err := auth.NewStore(authPath)
+	if err != nil {
+		return err
+	}
+	password, err := readPassword()
+	if err != nil {
+		return err
+	}
+
+	switch pos[0] {
+	case "add":
+		if err = store.AddUser(pos[1], password); err != nil {
+			return err
+		}
+		if admin {
+			if err = store.SetAdmin(pos[1], true); err != nil {
+				return err
+			}
+		}
+		fmt.Printf("Added user %q.\n", pos[1])
+	case "passwd":
+		if err = store.SetPassword(pos[1], password); err != nil {
+			return err
+		}
+		fmt.Printf("Changed password of %q.\n", pos[1])
+	}
+	return nil
+}
+
+// runToken implements the token command, which manages the API tokens of a user in the auth file.
+func runToken(args []string) error {
+	flags := flag.NewFlagSet("token", flag.ExitOnError)
+	var authPath, user string
+	flags.StringVar(&authPath, "auth", defaultAuthPath(), "path to the file with users and API tokens")
+	flags.StringVar(&user, "user", os.Getenv("USER"), "user owning the tokens")
+	pos := parseArgs(flags, args)
+	if len(pos) == 0 {
+		return errors.New("usage: gotasks token create|ls|revoke -user <name> [name|id]")
+	}
+
+	store, err := auth.NewStore(authPath)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case pos[0] == "create" && len(pos) == 2:
+		_, secret, err := store.CreateToken(user, pos[1])
+		if err != nil {
+			return err
+		}
+		fmt.Println(secret)
+		fmt.Fprintln(os.Stderr, "Store the token now, it can't be shown again.")
+	case pos[0] == "ls" && len(pos) == 1:
+		tokens, err := store.Tokens(user)
+		if err != nil {
+			return err
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED\tLAST USED")
+		for _, t := range tokens {
+			lastUsed := "never"
+			if !t.LastUsed.IsZero() {
+				lastUsed = t.LastUsed.Format("2006-01-02")
+			}
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", t.ID, t.Name, t.Created.Format("2006-01-02"), lastUsed)
+		}
+		return w.Flush()
+	case pos[0] == "revoke" && len(pos) == 2:
+		id, err := strconv.Atoi(pos[1])
+		if err != nil {
+			return fmt.Errorf("invalid token ID %q", pos[1])
+		}
+		if err = store.RevokeToken(user, id); err != nil {
+			return err
+		}
+		fmt.Printf("Revoked token %d.\n", id)
+	default:
+		return errors.New("usage: gotasks token create|ls|revoke -user <name> [name|id]")
+	}
+	return nil
+}
+
+// readPassword asks for a password on the terminal, or reads the first line of stdin if it isn't one.
+func readPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+
+	fmt.Fprint(os.Stderr, "Password: ")
+	password, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	fmt.Fprint(os.Stderr, "Repeat password: ")
+	repeated, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	if string(password) != string(repeated) {
+		return "", errors.New("passwords don't match")
+	}
+	return string(password), nil
+}